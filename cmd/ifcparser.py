@@ -197,6 +197,22 @@ def buildNanoSense(driver):
     })
     return res
 
+def buildFacade(driver):
+    res = {}
+    deviceType = getDeviceType(driver)
+    if deviceType != "blind":
+        return res
+    if "Azimuth" not in driver['properties']:
+        return res
+    res = collections.OrderedDict({
+        "label": driver["Label"],
+        "azimuth": driver['properties'].get("Azimuth", 0),
+        "tilt": driver['properties'].get("Tilt", 90)
+    })
+    if "SlatRatio" in driver['properties']:
+        res["slatRatio"] = driver['properties'].get("SlatRatio")
+    return res
+
 def buildFrame(driver):
     res = {}
     deviceType = getDeviceType(driver)
@@ -222,6 +238,7 @@ def parseIfc(filepath):
     models = collections.OrderedDict()
     drivers = collections.OrderedDict()
     groups = collections.OrderedDict()
+    facades = collections.OrderedDict()
 
     if not os.path.lexists(filepath):
         print("Filepath " + filepath + " not found")
@@ -333,6 +350,9 @@ def parseIfc(filepath):
             if isDriver(deviceType):
                 modbusID = instance['properties'].get("ModbusID", 0)
                 drivers[deviceType][label] = buildDriver(instance)
+                facade = buildFacade(instance)
+                if facade:
+                    facades[label] = facade
                 projects[label] = {
                     "label": label,
                     "modelName": modelName,
@@ -483,7 +503,8 @@ def parseIfc(filepath):
         "switchs": drivers.get("switch", {}),
         "wagos": drivers.get("wago", {}),
         "nanosenses": drivers.get("nanosense", {}),
-        "projects": projects
+        "projects": projects,
+        "facades": facades
     })
     print(json.dumps(dump, indent=4))
     return 0
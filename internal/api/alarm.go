@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/mitchellh/mapstructure"
+	"github.com/romana/rlog"
+)
+
+func parseBoolParam(req *http.Request, name string) (*bool, error) {
+	param := req.FormValue(name)
+	if param == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseBool(param)
+	if err != nil {
+		return nil, NewError("Invalid " + name + " " + param)
+	}
+	return &val, nil
+}
+
+func (api *API) getAlarmFilter(req *http.Request) (*core.AlarmFilter, error) {
+	filter := core.AlarmFilter{
+		Severity: req.FormValue("severity"),
+		Type:     req.FormValue("type"),
+		Mac:      strings.ToUpper(req.FormValue("mac")),
+	}
+	var err error
+	filter.Active, err = parseBoolParam(req, "active")
+	if err != nil {
+		return nil, err
+	}
+	filter.Acknowledged, err = parseBoolParam(req, "acknowledged")
+	if err != nil {
+		return nil, err
+	}
+
+	from := req.FormValue("from")
+	if from != "" {
+		date, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, NewError("Invalid from date " + from)
+		}
+		filter.From = &date
+	}
+
+	to := req.FormValue("to")
+	if to != "" {
+		date, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, NewError("Invalid to date " + to)
+		}
+		filter.To = &date
+	}
+	return &filter, nil
+}
+
+func (api *API) getAlarms(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	filter, err := api.getAlarmFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	alarms := database.GetAlarms(api.db, *filter)
+	inrec, _ := json.MarshalIndent(alarms, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getAlarm(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	id := params["id"]
+	alarm := database.GetAlarm(api.db, id)
+	if alarm == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Alarm "+id+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(alarm)
+}
+
+func (api *API) ackAlarm(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	id := params["id"]
+	alarm := database.GetAlarm(api.db, id)
+	if alarm == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Alarm "+id+" not found", http.StatusInternalServerError)
+		return
+	}
+	if !alarm.Acknowledged {
+		decoded := context.Get(req, "decoded")
+		var auth duser.UserAccess
+		mapstructure.Decode(decoded.(duser.UserAccess), &auth)
+		alarm.Acknowledged = true
+		alarm.AckBy = auth.UserHash
+		alarm.AckDate = time.Now().UTC().Format(time.RFC3339)
+		_, err := database.SaveAlarm(api.db, *alarm)
+		if err != nil {
+			api.sendError(w, APIErrorDatabase, "Alarm "+id+" cannot be saved in database", http.StatusInternalServerError)
+			return
+		}
+		rlog.Info("Alarm " + alarm.Key() + " acknowledged")
+		go func(alarm core.Alarm) {
+			api.eventsAlarm <- alarm
+		}(*alarm)
+	}
+	json.NewEncoder(w).Encode(alarm)
+}
+
+func (api *API) alarmEvents(w http.ResponseWriter, r *http.Request) {
+	if api.hasAccessMode(w, r, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	ws, err := api.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		rlog.Error("Error when switching in alarm websocket " + err.Error())
+		return
+	}
+	api.apiMutex.Lock()
+	api.clientsAlarm[ws] = true
+	api.apiMutex.Unlock()
+}
+
+func (api *API) websocketAlarms() {
+	for {
+		select {
+		case alarm := <-api.eventsAlarm:
+			api.apiMutex.Lock()
+			for client := range api.clientsAlarm {
+				if err := client.WriteJSON(alarm); err != nil {
+					rlog.Error("Error writing in websocket" + err.Error())
+					client.Close()
+					delete(api.clientsAlarm, client)
+				}
+			}
+			api.apiMutex.Unlock()
+		case <-api.loops["websocketAlarms"].Probe:
+		}
+	}
+}
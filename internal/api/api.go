@@ -12,8 +12,6 @@ import (
 	"github.com/energieip/common-components-go/pkg/dnanosense"
 	"github.com/energieip/common-components-go/pkg/dserver"
 
-	"github.com/energieip/common-components-go/pkg/pconst"
-
 	"github.com/energieip/common-components-go/pkg/dwago"
 
 	gm "github.com/energieip/common-components-go/pkg/dgroup"
@@ -41,7 +39,8 @@ import (
 
 //InitAPI start API connection
 func InitAPI(db database.Database, historydb history.HistoryDb, eventsAPI chan map[string]interface{},
-	eventsConso chan core.EventConsumption, uploadValue *string, conf pkg.ServiceConfig) *API {
+	eventsConso chan core.EventConsumption, eventsAlarm chan core.Alarm, uploadValue *string, conf pkg.ServiceConfig, coreConf core.CoreConfig,
+	serviceHealth func() core.Health) *API {
 	api := API{
 		db:              db,
 		apiIP:           conf.ExternalAPI.IP,
@@ -51,9 +50,11 @@ func InitAPI(db database.Database, historydb history.HistoryDb, eventsAPI chan m
 		historydb:       historydb,
 		eventsAPI:       eventsAPI,
 		eventsConso:     eventsConso,
+		eventsAlarm:     eventsAlarm,
 		EventsToBackend: make(chan map[string]interface{}),
 		clients:         make(map[*websocket.Conn]duser.UserAccess),
-		clientsConso:    make(map[*websocket.Conn]duser.UserAccess),
+		clientsConso:    make(map[*websocket.Conn]consumptionSubscription),
+		clientsAlarm:    make(map[*websocket.Conn]bool),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -64,6 +65,13 @@ func InitAPI(db database.Database, historydb history.HistoryDb, eventsAPI chan m
 		browsingFolder: conf.ExternalAPI.BrowsingFolder,
 		dataPath:       conf.DataPath,
 		uploadValue:    uploadValue,
+		coreConf:       coreConf,
+		serviceHealth:  serviceHealth,
+		loops: map[string]*core.LoopProbe{
+			"websocketEvents":       core.NewLoopProbe(),
+			"websocketConsumptions": core.NewLoopProbe(),
+			"websocketAlarms":       core.NewLoopProbe(),
+		},
 	}
 	go api.swagger()
 	return &api
@@ -179,19 +187,23 @@ func (api *API) getStatus(w http.ResponseWriter, req *http.Request) {
 	var hvacs []dhvac.Hvac
 	var wagos []dwago.Wago
 	var nanos []dnanosense.Nanosense
-	var grID *int
-	var isConfig *bool
 	driverType := req.FormValue("type")
 	if driverType == "" {
 		driverType = FilterTypeAll
 	}
 	driverType = strings.ToLower(driverType)
 
+	query, err := api.getDriverQuery(req, SortMac)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	groupID := req.FormValue("groupID")
 	if groupID != "" {
 		i, err := strconv.Atoi(groupID)
 		if err == nil {
-			grID = &i
+			query.filter.Group = &i
 		}
 	}
 
@@ -199,110 +211,114 @@ func (api *API) getStatus(w http.ResponseWriter, req *http.Request) {
 	if isConfigured != "" {
 		b, err := strconv.ParseBool(isConfigured)
 		if err == nil {
-			isConfig = &b
+			query.filter.IsConfigured = &b
 		}
 	}
+	total := make(map[string]int)
 
 	if driverType == FilterTypeAll || driverType == FilterTypeLed {
-		lights := database.GetLedsStatus(api.db)
-		for _, led := range lights {
-			if grID == nil || *grID == led.Group {
-				if isConfig == nil || *isConfig == led.IsConfigured {
-					if auth.Priviledge == duser.PriviledgeUser {
-						if !tools.IntInSlice(led.Group, auth.AccessGroups) {
-							continue
-						}
-					}
-					leds = append(leds, led)
-				}
-			}
-		}
+		leds, total["leds"] = database.GetLedsStatusPage(api.db, query.filter, query.userPage(auth))
 	}
 
 	if driverType == FilterTypeAll || driverType == FilterTypeSensor {
-		cells := database.GetSensorsStatus(api.db)
-		for _, sensor := range cells {
-			if grID == nil || *grID == sensor.Group {
-				if isConfig == nil || *isConfig == sensor.IsConfigured {
-					if auth.Priviledge == duser.PriviledgeUser {
-						if !tools.IntInSlice(sensor.Group, auth.AccessGroups) {
-							continue
-						}
-					}
-					sensors = append(sensors, sensor)
-				}
-			}
-		}
+		sensors, total["sensors"] = database.GetSensorsStatusPage(api.db, query.filter, query.userPage(auth))
 	}
 
 	if driverType == FilterTypeAll || driverType == FilterTypeBlind {
-		drivers := database.GetBlindsStatus(api.db)
-		for _, driver := range drivers {
-			if grID == nil || *grID == driver.Group {
-				if isConfig == nil || *isConfig == driver.IsConfigured {
-					if auth.Priviledge == duser.PriviledgeUser {
-						if !tools.IntInSlice(driver.Group, auth.AccessGroups) {
-							continue
-						}
-					}
-					blinds = append(blinds, driver)
-				}
-			}
-		}
+		blinds, total["blinds"] = database.GetBlindsStatusPage(api.db, query.filter, query.userPage(auth))
 	}
 
 	if driverType == FilterTypeAll || driverType == FilterTypeHvac {
-		drivers := database.GetHvacsStatus(api.db)
-		for _, driver := range drivers {
-			if grID == nil || *grID == driver.Group {
-				if isConfig == nil || *isConfig == driver.IsConfigured {
-					if auth.Priviledge == duser.PriviledgeUser {
-						if !tools.IntInSlice(driver.Group, auth.AccessGroups) {
-							continue
-						}
-					}
-					hvacs = append(hvacs, driver)
-				}
-			}
-		}
+		hvacs, total["hvacs"] = database.GetHvacsStatusPage(api.db, query.filter, query.userPage(auth))
 	}
 
 	if driverType == FilterTypeAll || driverType == FilterTypeWago {
-		drivers := database.GetWagosStatus(api.db)
-		for _, driver := range drivers {
-			if isConfig == nil || *isConfig == driver.IsConfigured {
-				if auth.Priviledge != duser.PriviledgeUser {
-					continue
-				}
-				wagos = append(wagos, driver)
-			}
+		//wagos are not attached to a group
+		filter := query.filter
+		filter.Group = nil
+		total["wagos"] = 0
+		if auth.Priviledge == duser.PriviledgeUser {
+			wagos, total["wagos"] = database.GetWagosStatusPage(api.db, filter, query.dbPage())
 		}
 	}
 
 	if driverType == FilterTypeAll || driverType == FilterTypeNano {
-		drivers := database.GetNanosStatus(api.db)
-		for _, driver := range drivers {
-			if auth.Priviledge != duser.PriviledgeUser {
-				if !tools.IntInSlice(driver.Group, auth.AccessGroups) {
-					continue
-				}
-
-				nanos = append(nanos, driver)
-			}
+		total["nanosenses"] = 0
+		if auth.Priviledge != duser.PriviledgeUser {
+			nanos, total["nanosenses"] = database.GetNanosStatusPage(api.db, query.filter, query.groupsPage(auth.AccessGroups))
 		}
 	}
 
-	status := dserver.Status{
-		Leds:       leds,
-		Sensors:    sensors,
-		Blinds:     blinds,
-		Hvacs:      hvacs,
-		Wagos:      wagos,
-		Nanosenses: nanos,
+	status := Status{
+		Status: dserver.Status{
+			Leds:       leds,
+			Sensors:    sensors,
+			Blinds:     blinds,
+			Hvacs:      hvacs,
+			Wagos:      wagos,
+			Nanosenses: nanos,
+		},
+		Stale: make(map[string]string),
+		Total: total,
+	}
+	offline := database.GetOfflineSwitchs(api.db)
+	for _, driver := range leds {
+		if sw, ok := offline[driver.SwitchMac]; ok {
+			status.Stale[driver.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range sensors {
+		if sw, ok := offline[driver.SwitchMac]; ok {
+			status.Stale[driver.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range blinds {
+		if sw, ok := offline[driver.SwitchMac]; ok {
+			status.Stale[driver.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range hvacs {
+		if sw, ok := offline[driver.SwitchMac]; ok {
+			status.Stale[driver.Mac] = sw.LastSeen
+		}
+	}
+	clusters := api.staleClusters(offline)
+	for _, driver := range wagos {
+		if lastSeen, ok := clusters[driver.Cluster]; ok {
+			status.Stale[driver.Mac] = lastSeen
+		}
+	}
+	for _, driver := range nanos {
+		if lastSeen, ok := clusters[driver.Cluster]; ok {
+			status.Stale[driver.Mac] = lastSeen
+		}
 	}
 
-	inrec, _ := json.MarshalIndent(status, "", "  ")
-	w.Write(inrec)
+	query.writeDrivers(w, status, []string{"leds", "sensors", "blinds", "hvacs", "wagos", "nanosenses"})
+}
+
+//staleClusters return the clusters without online switch and their last dump, the wagos and nanosenses
+//are reported by the switchs of their cluster
+func (api *API) staleClusters(offline map[string]core.OfflineSwitch) map[int]string {
+	res := make(map[int]string)
+	if len(offline) == 0 {
+		return res
+	}
+	online := make(map[int]bool)
+	for _, sw := range database.GetSwitchsDump(api.db) {
+		elt, ok := offline[sw.Mac]
+		if !ok {
+			online[sw.Cluster] = true
+			continue
+		}
+		if elt.LastSeen > res[sw.Cluster] {
+			res[sw.Cluster] = elt.LastSeen
+		}
+	}
+	for cluster := range online {
+		delete(res, cluster)
+	}
+	return res
 }
 
 func (api *API) getDump(w http.ResponseWriter, req *http.Request) {
@@ -321,220 +337,66 @@ func (api *API) getDump(w http.ResponseWriter, req *http.Request) {
 	var wagos []dserver.DumpWago
 	var groups []dserver.DumpGroup
 	var nanos []dserver.DumpNanosense
-	macs := make(map[string]bool)
 	driversMac := make(map[string]bool)
-	labels := make(map[string]bool)
-	filterByMac := false
+
+	query, err := api.getDriverQuery(req, SortLabel)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	MacsParam := req.FormValue("macs")
 	if MacsParam != "" {
-		tempMac := strings.Split(MacsParam, ",")
-
-		for _, v := range tempMac {
-			macs[strings.ToUpper(v)] = true
-			filterByMac = true
+		for _, v := range strings.Split(MacsParam, ",") {
+			query.macs = append(query.macs, strings.ToUpper(v))
 		}
 	}
-
-	filterByLabel := false
 	LabelsParam := req.FormValue("labels")
 	if LabelsParam != "" {
 		for _, v := range strings.Split(LabelsParam, ",") {
-			labels[v] = true
-			filterByLabel = true
+			query.labels = append(query.labels, v)
 		}
 	}
 
-	lights := database.GetLedsStatusByLabel(api.db)
-	lightsConfig := database.GetLedsConfigByLabel(api.db)
-	cells := database.GetSensorsStatusByLabel(api.db)
-	cellsConfig := database.GetSensorsConfigByLabel(api.db)
-	blds := database.GetBlindsStatusByLabel(api.db)
-	bldsConfig := database.GetBlindsConfigByLabel(api.db)
-	hvcs := database.GetHvacsStatusByLabel(api.db)
-	hvcsConfig := database.GetHvacsConfigByLabel(api.db)
-	wags := database.GetWagosStatusByLabel(api.db)
-	wagosConfig := database.GetWagosConfigByLabel(api.db)
-	switchElts := database.GetSwitchsDumpByLabel(api.db)
-	switchEltsConfig := database.GetSwitchsConfigByLabel(api.db)
-	frameElts := database.GetFramesDumpByLabel(api.db)
-	frameEltsConfig := database.GetFramesConfigByLabel(api.db)
-	nans := database.GetNanosStatusByLabel(api.db)
-	nanosConfig := database.GetNanosConfigByLabel(api.db)
-
-	ifcs := database.GetIfcs(api.db)
-	for _, ifc := range ifcs {
-		if filterByLabel {
-			if _, ok := labels[ifc.Label]; !ok {
-				continue
-			}
-		}
-		if filterByMac {
-			if _, ok := macs[ifc.Mac]; !ok {
-				continue
-			}
-		}
-		driversMac[ifc.Mac] = true
-
-		switch ifc.DeviceType {
-		case pconst.LED:
-			dump := dserver.DumpLed{}
-			led, ok := lights[ifc.Label]
-			gr := 0
-			if ok {
-				dump.Status = led
-				gr = led.Group
-			}
-			config, ok := lightsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-				if gr == 0 && config.Group != nil {
-					gr = *config.Group
-				}
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				if !tools.IntInSlice(gr, auth.AccessGroups) {
-					continue
-				}
-			}
-			dump.Ifc = ifc
-
-			leds = append(leds, dump)
-		case pconst.SENSOR:
-			dump := dserver.DumpSensor{}
-			gr := 0
-			sensor, ok := cells[ifc.Label]
-			if ok {
-				dump.Status = sensor
-				gr = sensor.Group
-			}
-			config, ok := cellsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-				if gr == 0 && config.Group != nil {
-					gr = *config.Group
-				}
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				if !tools.IntInSlice(gr, auth.AccessGroups) {
-					continue
-				}
-			}
-			dump.Ifc = ifc
-			sensors = append(sensors, dump)
-		case pconst.BLIND:
-			dump := dserver.DumpBlind{}
-			gr := 0
-			bld, ok := blds[ifc.Label]
-			if ok {
-				dump.Status = bld
-				gr = bld.Group
-			}
-			config, ok := bldsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-				if gr == 0 && config.Group != nil {
-					gr = *config.Group
-				}
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				if !tools.IntInSlice(gr, auth.AccessGroups) {
-					continue
-				}
-			}
-			dump.Ifc = ifc
-			blinds = append(blinds, dump)
-		case pconst.HVAC:
-			dump := dserver.DumpHvac{}
-			gr := 0
-			hvac, ok := hvcs[ifc.Label]
-			if ok {
-				dump.Status = hvac
-				gr = hvac.Group
-			}
-			config, ok := hvcsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-				if gr == 0 && config.Group != nil {
-					gr = *config.Group
-				}
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				if !tools.IntInSlice(gr, auth.AccessGroups) {
-					continue
-				}
-			}
-			dump.Ifc = ifc
-			hvacs = append(hvacs, dump)
-		case pconst.WAGO:
-			dump := dserver.DumpWago{}
-			wago, ok := wags[ifc.Label]
-			if ok {
-				dump.Status = wago
-			}
-			config, ok := wagosConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				continue
-			}
-			dump.Ifc = ifc
-			wagos = append(wagos, dump)
-		case pconst.SWITCH:
-			dump := dserver.DumpSwitch{}
-			switchElt, ok := switchElts[ifc.Label]
-			if ok {
-				dump.Status = switchElt
-			}
-			config, ok := switchEltsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-			}
-			dump.Ifc = ifc
-			if auth.Priviledge == duser.PriviledgeUser {
-				continue
-			}
-			switchs = append(switchs, dump)
-		case pconst.FRAME:
-			dump := dserver.DumpFrame{}
-			frameElt, ok := frameElts[ifc.Label]
-			if ok {
-				dump.Status = frameElt
-			}
-			config, ok := frameEltsConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-			}
-			dump.Ifc = ifc
-			if auth.Priviledge == duser.PriviledgeUser {
-				continue
-			}
-			frames = append(frames, dump)
-		case pconst.NANOSENSE:
-			dump := dserver.DumpNanosense{}
-			gr := 0
-			nano, ok := nans[ifc.Label]
-			if ok {
-				dump.Status = nano
-				gr = nano.Group
-			}
-			config, ok := nanosConfig[ifc.Label]
-			if ok {
-				dump.Config = config
-				if gr == 0 {
-					gr = config.Group
-				}
-			}
-			if auth.Priviledge == duser.PriviledgeUser {
-				if !tools.IntInSlice(gr, auth.AccessGroups) {
-					continue
-				}
-			}
-			dump.Ifc = ifc
-			nanos = append(nanos, dump)
-		}
+	total := make(map[string]int)
+	leds, total["leds"] = database.GetLedsDumpPage(api.db, query.filter, query.userPage(auth))
+	sensors, total["sensors"] = database.GetSensorsDumpPage(api.db, query.filter, query.userPage(auth))
+	blinds, total["blinds"] = database.GetBlindsDumpPage(api.db, query.filter, query.userPage(auth))
+	hvacs, total["hvacs"] = database.GetHvacsDumpPage(api.db, query.filter, query.userPage(auth))
+	nanos, total["nanosenses"] = database.GetNanosDumpPage(api.db, query.filter, query.userPage(auth))
+	total["wagos"] = 0
+	total["switchs"] = 0
+	total["frames"] = 0
+	if auth.Priviledge != duser.PriviledgeUser {
+		wagos, total["wagos"] = database.GetWagosDumpPage(api.db, query.filter, query.dbPage())
+		switchs, total["switchs"] = database.GetSwitchsDumpPage(api.db, query.filter, query.dbPage())
+		frames, total["frames"] = database.GetFramesDumpPage(api.db, query.filter, query.dbPage())
+	}
+	for _, driver := range leds {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range sensors {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range blinds {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range hvacs {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range wagos {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range switchs {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range frames {
+		driversMac[driver.Ifc.Mac] = true
+	}
+	for _, driver := range nanos {
+		driversMac[driver.Ifc.Mac] = true
 	}
 
+	//the groups of the returned drivers
 	groupsStatus := database.GetGroupsStatus(api.db)
 	groupsConfig := database.GetGroupConfigs(api.db, driversMac)
 
@@ -548,20 +410,60 @@ func (api *API) getDump(w http.ResponseWriter, req *http.Request) {
 		groups = append(groups, dump)
 	}
 
-	dump := dserver.Dump{
-		Leds:       leds,
-		Sensors:    sensors,
-		Blinds:     blinds,
-		Hvacs:      hvacs,
-		Wagos:      wagos,
-		Switchs:    switchs,
-		Frames:     frames,
-		Groups:     groups,
-		Nanosenses: nanos,
+	dump := Dump{
+		Dump: dserver.Dump{
+			Leds:       leds,
+			Sensors:    sensors,
+			Blinds:     blinds,
+			Hvacs:      hvacs,
+			Wagos:      wagos,
+			Switchs:    switchs,
+			Frames:     frames,
+			Groups:     groups,
+			Nanosenses: nanos,
+		},
+		Stale: make(map[string]string),
+		Total: total,
+	}
+	offline := database.GetOfflineSwitchs(api.db)
+	for _, driver := range leds {
+		if sw, ok := offline[driver.Status.SwitchMac]; ok {
+			dump.Stale[driver.Status.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range sensors {
+		if sw, ok := offline[driver.Status.SwitchMac]; ok {
+			dump.Stale[driver.Status.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range blinds {
+		if sw, ok := offline[driver.Status.SwitchMac]; ok {
+			dump.Stale[driver.Status.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range hvacs {
+		if sw, ok := offline[driver.Status.SwitchMac]; ok {
+			dump.Stale[driver.Status.Mac] = sw.LastSeen
+		}
+	}
+	for _, driver := range switchs {
+		if sw, ok := offline[driver.Status.Mac]; ok {
+			dump.Stale[driver.Status.Mac] = sw.LastSeen
+		}
+	}
+	clusters := api.staleClusters(offline)
+	for _, driver := range wagos {
+		if lastSeen, ok := clusters[driver.Status.Cluster]; ok && driver.Status.Mac != "" {
+			dump.Stale[driver.Status.Mac] = lastSeen
+		}
+	}
+	for _, driver := range nanos {
+		if lastSeen, ok := clusters[driver.Status.Cluster]; ok && driver.Status.Mac != "" {
+			dump.Stale[driver.Status.Mac] = lastSeen
+		}
 	}
 
-	inrec, _ := json.MarshalIndent(dump, "", "  ")
-	w.Write(inrec)
+	query.writeDrivers(w, dump, []string{"leds", "sensors", "blinds", "hvacs", "wagos", "switchs", "frames", "groups", "nanosenses"})
 }
 
 func (api *API) getHistory(w http.ResponseWriter, req *http.Request) {
@@ -572,29 +474,32 @@ func (api *API) getHistory(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var leds []LedHist
-	ledHistories := make(map[string]LedHist)
-	ledDrivers := history.GetLedsHistory(api.historydb)
-	for _, l := range ledDrivers {
-		val, ok := ledHistories[l.Date]
-		if !ok {
-			ledHistories[l.Date] = LedHist{
-				Energy: l.Energy,
-				Power:  l.Power,
-				Date:   l.Date,
-			}
-		} else {
-			val.Energy += l.Energy
-			val.Power += l.Power
-			ledHistories[l.Date] = val
-		}
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	for _, hist := range ledHistories {
-		leds = append(leds, hist)
+
+	driverType := strings.ToLower(req.FormValue("type"))
+	if driverType == "" {
+		driverType = FilterTypeAll
 	}
 
-	dump := GlobalHistory{
-		Leds: leds,
+	dump := GlobalHistory{}
+	if driverType == FilterTypeAll || driverType == FilterTypeLed {
+		dump.Leds = history.GetHistory(api.historydb, history.LedsTable, *filter)
+	}
+	if driverType == FilterTypeAll || driverType == FilterTypeBlind {
+		dump.Blinds = history.GetHistory(api.historydb, history.BlindsTable, *filter)
+	}
+	if driverType == FilterTypeAll || driverType == FilterTypeHvac {
+		dump.Hvacs = history.GetHistory(api.historydb, history.HvacsTable, *filter)
+	}
+	if driverType == FilterTypeAll || driverType == FilterTypeSwitch {
+		//switchs are not attached to a group
+		if filter.Group == nil && filter.Label == "" {
+			dump.Switchs = history.GetHistory(api.historydb, history.SwitchsTable, *filter)
+		}
 	}
 
 	inrec, _ := json.MarshalIndent(dump, "", "  ")
@@ -620,30 +525,20 @@ func (api *API) setConfig(w http.ResponseWriter, req *http.Request) {
 		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	event := make(map[string]interface{})
-	for _, led := range config.Leds {
-		event["led"] = led
-	}
-	for _, sensor := range config.Sensors {
-		event["sensor"] = sensor
-	}
-	for _, group := range config.Groups {
-		event["group"] = group
-	}
-	for _, sw := range config.Switchs {
-		event["switch"] = sw
-	}
-	for _, sw := range config.Blinds {
-		event["blind"] = sw
-	}
-	for _, sw := range config.Hvacs {
-		event["hvac"] = sw
-	}
-	for _, sw := range config.Wagos {
-		event["wago"] = sw
+	//validateAll: nothing is sent when an item is rejected, the valid items are then
+	//applied one by one by the service and a failing item does not roll back the others
+	validateAll := req.FormValue("validateAll") == "true"
+	batch := api.validateConfig(config)
+	if validateAll && batch.rejected() {
+		batch.abort()
+		inrec, _ := json.MarshalIndent(batch.results, "", "  ")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(inrec)
+		return
 	}
-	api.EventsToBackend <- event
-	w.Write([]byte("{}"))
+	api.sendConfig(req, batch.conf)
+	inrec, _ := json.MarshalIndent(batch.results, "", "  ")
+	w.Write(inrec)
 }
 
 func (api *API) webEvents(w http.ResponseWriter, r *http.Request) {
@@ -659,18 +554,6 @@ func (api *API) webEvents(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (api *API) consumptionEvents(w http.ResponseWriter, r *http.Request) {
-	ws, err := api.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		rlog.Error("Error when switching in consumption websocket " + err.Error())
-		return
-	}
-	decoded := context.Get(r, "decoded")
-	var auth duser.UserAccess
-	mapstructure.Decode(decoded.(duser.UserAccess), &auth)
-	api.clientsConso[ws] = auth
-}
-
 func (api *API) websocketEvents() {
 	for {
 		select {
@@ -765,23 +648,7 @@ func (api *API) websocketEvents() {
 				}
 			}
 			api.apiMutex.Unlock()
-		}
-	}
-}
-
-func (api *API) websocketConsumptions() {
-	for {
-		select {
-		case event := <-api.eventsConso:
-			api.apiMutex.Lock()
-			for client := range api.clientsConso {
-				if err := client.WriteJSON(event); err != nil {
-					rlog.Error("Error writing in websocket" + err.Error())
-					client.Close()
-					delete(api.clientsConso, client)
-				}
-			}
-			api.apiMutex.Unlock()
+		case <-api.loops["websocketEvents"].Probe:
 		}
 	}
 }
@@ -808,8 +675,17 @@ func (api *API) getV1Functions(w http.ResponseWriter, req *http.Request) {
 		apiV1 + "/setup/service", apiV1 + "/setup/blind", apiV1 + "/setup/hvac", apiV1 + "/setup/wago",
 		apiV1 + "/config/led", apiV1 + "/config/sensor", apiV1 + "/config/blind", apiV1 + "/config/hvac",
 		apiV1 + "/config/group", apiV1 + "/config/switch", apiV1 + "/config/wago", apiV1 + "/configs",
-		apiV1 + "/status", apiV1 + "/events", apiV1 + "/events/consumption", apiV1 + "/history",
-		apiV1 + "/command/led", apiV1 + "/command/blind", apiV1 + "/command/hvac", apiV1 + "/command/group", apiV1 + "/project/ifcInfo",
+		apiV1 + "/status", apiV1 + "/events", apiV1 + "/events/consumption", apiV1 + "/events/alarm", apiV1 + "/history",
+		apiV1 + "/history/compaction", apiV1 + "/history/sensors", apiV1 + "/history/nanosenses",
+		apiV1 + "/history/switchs/report", apiV1 + "/history/switchs/connectivity",
+		apiV1 + "/history/cost", apiV1 + "/setup/tariff", apiV1 + "/setup/tariffs",
+		apiV1 + "/setup/schedule", apiV1 + "/setup/schedules", apiV1 + "/setup/calendar", apiV1 + "/setup/calendars",
+		apiV1 + "/schedule/preview", apiV1 + "/schedule/sun", apiV1 + "/schedule/suntracking",
+		apiV1 + "/setup/facade", apiV1 + "/setup/facades", apiV1 + "/setup/scene", apiV1 + "/setup/scenes",
+		apiV1 + "/setup/rule", apiV1 + "/setup/rules", apiV1 + "/rule/evaluate", apiV1 + "/priority", apiV1 + "/priorities",
+		apiV1 + "/setup/webhook", apiV1 + "/setup/webhooks", apiV1 + "/webhook", apiV1 + "/mail/test",
+		apiV1 + "/audit", apiV1 + "/alarm", apiV1 + "/alarms",
+		apiV1 + "/command/led", apiV1 + "/command/blind", apiV1 + "/command/hvac", apiV1 + "/command/group", apiV1 + "/command/scene", apiV1 + "/command/bulk", apiV1 + "/command", apiV1 + "/project/ifcInfo",
 		apiV1 + "/project/model", apiV1 + "/project/bim", apiV1 + "/project", apiV1 + "/dump",
 		apiV1 + "/status/sensor", apiV1 + "/status/group", apiV1 + "/status/led", apiV1 + "/status/blind", apiV1 + "/status/hvac",
 		apiV1 + "/status/groups", apiV1 + "/status/wago", apiV1 + "/maintenance/driver", apiV1 + "/commissioning/install",
@@ -828,7 +704,7 @@ func (api *API) getFunctions(w http.ResponseWriter, req *http.Request) {
 	api.setDefaultHeader(w, req)
 	w.Header().Set("Connection", "close")
 	defer req.Body.Close()
-	functions := []string{"/versions"}
+	functions := []string{"/versions", "/healthz", "/readyz"}
 	apiInfo := APIFunctions{
 		Functions: functions,
 	}
@@ -863,8 +739,12 @@ func (api *API) hasEnoughRight(w http.ResponseWriter, req *http.Request, group i
 }
 
 func (api *API) swagger() {
+	for _, probe := range api.loops {
+		go probe.Run(core.LoopProbePeriod)
+	}
 	go api.websocketConsumptions()
 	go api.websocketEvents()
+	go api.websocketAlarms()
 	router := mux.NewRouter()
 	sh := http.StripPrefix("/swaggerui/", http.FileServer(http.Dir("/data/www/swaggerui/")))
 	router.PathPrefix("/swaggerui/").Handler(sh)
@@ -903,6 +783,42 @@ func (api *API) swagger() {
 	router.HandleFunc(apiV1+"/setup/service/{name}", api.verification(api.getServiceSetup)).Methods("GET")
 	router.HandleFunc(apiV1+"/setup/service/{name}", api.verification(api.removeServiceSetup)).Methods("DELETE")
 	router.HandleFunc(apiV1+"/setup/service", api.verification(api.setServiceSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/tariff/{label}", api.verification(api.getTariffSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/tariff/{label}", api.verification(api.removeTariffSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/tariff", api.verification(api.setTariffSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/tariffs", api.verification(api.getTariffsSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/scene/{name}", api.verification(api.getSceneSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/scene/{name}", api.verification(api.removeSceneSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/scene", api.verification(api.setSceneSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/scenes", api.verification(api.getScenesSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/rule/{name}", api.verification(api.getRuleSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/rule/{name}", api.verification(api.removeRuleSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/rule", api.verification(api.setRuleSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/rules", api.verification(api.getRulesSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/rule/evaluate", api.verification(api.evaluateRule)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/webhook/{name}", api.verification(api.getWebhookSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/webhook/{name}", api.verification(api.removeWebhookSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/webhook", api.verification(api.setWebhookSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/webhooks", api.verification(api.getWebhooksSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/webhook/{name}/deliveries", api.verification(api.getWebhookDeliveries)).Methods("GET")
+	router.HandleFunc(apiV1+"/webhook/{name}/test", api.verification(api.testWebhook)).Methods("POST")
+	router.HandleFunc(apiV1+"/mail/test", api.verification(api.testMail)).Methods("POST")
+	router.HandleFunc(apiV1+"/audit", api.verification(api.getAudit)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/schedule/{groupID}", api.verification(api.getScheduleSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/schedule/{groupID}", api.verification(api.removeScheduleSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/schedule", api.verification(api.setScheduleSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/schedules", api.verification(api.getSchedulesSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/calendar/{name}", api.verification(api.getCalendarSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/calendar/{name}", api.verification(api.removeCalendarSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/calendar/{name}/ics", api.verification(api.importCalendar)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/calendar", api.verification(api.setCalendarSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/calendars", api.verification(api.getCalendarsSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/facade/blind/{label}", api.verification(api.getFacadeSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/facade/blind/{label}", api.verification(api.removeFacadeSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/facade/group/{groupID}", api.verification(api.getFacadeSetup)).Methods("GET")
+	router.HandleFunc(apiV1+"/setup/facade/group/{groupID}", api.verification(api.removeFacadeSetup)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/setup/facade", api.verification(api.setFacadeSetup)).Methods("POST")
+	router.HandleFunc(apiV1+"/setup/facades", api.verification(api.getFacadesSetup)).Methods("GET")
 
 	//config API
 	router.HandleFunc(apiV1+"/config/led", api.verification(api.setLedConfig)).Methods("POST")
@@ -928,12 +844,26 @@ func (api *API) swagger() {
 	//events API
 	router.HandleFunc(apiV1+"/events", api.verification(api.webEvents))
 	router.HandleFunc(apiV1+"/events/consumption", api.verification(api.consumptionEvents))
+	router.HandleFunc(apiV1+"/events/alarm", api.verification(api.alarmEvents))
 
 	//command API
 	router.HandleFunc(apiV1+"/command/led", api.verification(api.sendLedCommand)).Methods("POST")
 	router.HandleFunc(apiV1+"/command/blind", api.verification(api.sendBlindCommand)).Methods("POST")
 	router.HandleFunc(apiV1+"/command/hvac", api.verification(api.sendHvacCommand)).Methods("POST")
 	router.HandleFunc(apiV1+"/command/group", api.verification(api.sendGroupCommand)).Methods("POST")
+	router.HandleFunc(apiV1+"/command/scene/{name}", api.verification(api.sendSceneCommand)).Methods("POST")
+	router.HandleFunc(apiV1+"/command/bulk", api.verification(api.sendBulkCommand)).Methods("POST")
+	router.HandleFunc(apiV1+"/command/{id}", api.verification(api.getCommandAck)).Methods("GET")
+
+	//priority API
+	router.HandleFunc(apiV1+"/priority/{kind}/{id}", api.verification(api.getPriority)).Methods("GET")
+	router.HandleFunc(apiV1+"/priority/{kind}/{id}/{level}", api.verification(api.relinquishPriority)).Methods("DELETE")
+	router.HandleFunc(apiV1+"/priorities", api.verification(api.getPriorities)).Methods("GET")
+
+	//alarm API
+	router.HandleFunc(apiV1+"/alarm/{id}", api.verification(api.getAlarm)).Methods("GET")
+	router.HandleFunc(apiV1+"/alarm/{id}/ack", api.verification(api.ackAlarm)).Methods("POST")
+	router.HandleFunc(apiV1+"/alarms", api.verification(api.getAlarms)).Methods("GET")
 
 	//project API
 	router.HandleFunc(apiV1+"/project/ifcInfo/{label}", api.verification(api.getIfcInfo)).Methods("GET")
@@ -947,6 +877,11 @@ func (api *API) swagger() {
 	router.HandleFunc(apiV1+"/project/bim", api.verification(api.setBim)).Methods("POST")
 	router.HandleFunc(apiV1+"/project", api.verification(api.getIfc)).Methods("GET")
 
+	//schedule API
+	router.HandleFunc(apiV1+"/schedule/preview", api.verification(api.getSchedulePreview)).Methods("GET")
+	router.HandleFunc(apiV1+"/schedule/sun", api.verification(api.getSunTimes)).Methods("GET")
+	router.HandleFunc(apiV1+"/schedule/suntracking", api.verification(api.getSunTracking)).Methods("GET")
+
 	//map API
 	router.HandleFunc(apiV1+"/map/upload", api.verification(api.uploadHandler)).Methods("POST")
 	router.HandleFunc(apiV1+"/map/upload/status", api.verification(api.uploadStatus)).Methods("GET")
@@ -969,10 +904,18 @@ func (api *API) swagger() {
 
 	//History API
 	router.HandleFunc(apiV1+"/history", api.verification(api.getHistory)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/compaction", api.verification(api.getHistoryCompaction)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/sensors", api.verification(api.getSensorsHistory)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/nanosenses", api.verification(api.getNanosensesHistory)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/switchs/report", api.verification(api.getSwitchsReport)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/switchs/connectivity", api.verification(api.getSwitchsConnectivity)).Methods("GET")
+	router.HandleFunc(apiV1+"/history/cost", api.verification(api.getHistoryCost)).Methods("GET")
 
 	//unversionned API
 	router.HandleFunc("/versions", api.getAPIs).Methods("GET")
 	router.HandleFunc("/functions", api.getFunctions).Methods("GET")
+	router.HandleFunc("/healthz", api.getHealthz).Methods("GET")
+	router.HandleFunc("/readyz", api.getReadyz).Methods("GET")
 
 	if api.browsingFolder != "" {
 		sh2 := http.StripPrefix("/", http.FileServer(http.Dir(api.browsingFolder)))
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/energieip/srv200-coreservice-go/internal/history"
+	"github.com/gorilla/context"
+	"github.com/romana/rlog"
+)
+
+//auditOrigin user and request at the origin of backend events
+type auditOrigin struct {
+	user    duser.UserAccess
+	request string
+}
+
+func (api *API) getAuditOrigin(req *http.Request) auditOrigin {
+	origin := auditOrigin{
+		request: req.Method + " " + req.URL.Path,
+	}
+	decoded := context.Get(req, "decoded")
+	if auth, ok := decoded.(duser.UserAccess); ok {
+		origin.user = auth
+	}
+	return origin
+}
+
+//auditTarget return the mac, label, group or name of the object targeted by an event
+func auditTarget(payload interface{}) string {
+	switch val := payload.(type) {
+	case string:
+		return val
+	case map[string]interface{}:
+		for _, key := range []string{"mac", "label", "group", "point", "name"} {
+			switch field := val[key].(type) {
+			case string:
+				if field != "" {
+					return field
+				}
+			case float64:
+				return strconv.Itoa(int(field))
+			}
+		}
+		//commands are nested in the priority command
+		for _, key := range []string{"led", "blind", "hvac", "group"} {
+			if nested, ok := val[key].(map[string]interface{}); ok {
+				return auditTarget(nested)
+			}
+		}
+	}
+	return ""
+}
+
+//auditBefore return the stored configuration changed by an event, nil for commands
+func (api *API) auditBefore(action, target string) interface{} {
+	switch action {
+	case "led", "ledSetup":
+		if cfg, _ := database.GetLedConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "blind", "blindSetup":
+		if cfg, _ := database.GetBlindConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "hvac", "hvacSetup":
+		if cfg, _ := database.GetHvacConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "sensor", "sensorSetup":
+		if cfg, _ := database.GetSensorConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "wago", "wagoSetup":
+		if cfg, _ := database.GetWagoConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "nano":
+		if cfg, _ := database.GetNanoConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "switch":
+		if cfg, _ := database.GetSwitchConfig(api.db, target); cfg != nil {
+			return cfg
+		}
+	case "group":
+		grID, err := strconv.Atoi(target)
+		if err != nil {
+			return nil
+		}
+		if cfg, _ := database.GetGroupConfig(api.db, grID); cfg != nil {
+			return cfg
+		}
+	case "webhook":
+		if cfg, _ := database.GetWebhook(api.db, target); cfg != nil {
+			return cfg.Masked()
+		}
+	}
+	return nil
+}
+
+//sendToBackend record the event in the audit log and forward it to the service
+func (api *API) sendToBackend(req *http.Request, event map[string]interface{}) {
+	api.sendAuditedEvent(api.getAuditOrigin(req), event)
+}
+
+func (api *API) sendAuditedEvent(origin auditOrigin, event map[string]interface{}) {
+	for action, payload := range event {
+		api.audit(origin, action, payload)
+	}
+	api.EventsToBackend <- event
+}
+
+//audit record an event in the audit log
+func (api *API) audit(origin auditOrigin, action string, payload interface{}) {
+	var values interface{}
+	inrec, _ := json.Marshal(payload)
+	json.Unmarshal(inrec, &values)
+	target := auditTarget(values)
+	before := api.auditBefore(action, target)
+
+	entry := history.NewAuditEntry(origin.user, origin.request, action, target, payload, before)
+	err := history.SaveAuditEntry(api.historydb, entry)
+	if err != nil {
+		rlog.Error("Cannot save audit entry " + action + " " + err.Error())
+	}
+}
+
+func writeAuditCSV(w http.ResponseWriter, entries []history.AuditEntry) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+time.Now().Format("01-02-2006")+"_audit.csv")
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "user", "priviledge", "request", "action", "target", "changes", "payload"})
+	for _, entry := range entries {
+		changes := []string{}
+		for _, change := range entry.Changes {
+			before, _ := json.Marshal(change.Before)
+			after, _ := json.Marshal(change.After)
+			changes = append(changes, change.Field+": "+string(before)+" -> "+string(after))
+		}
+		payload, _ := json.Marshal(entry.Payload)
+		writer.Write([]string{entry.Date, entry.User.UserHash, entry.User.Priviledge, entry.Request,
+			entry.Action, entry.Target, strings.Join(changes, "; "), string(payload)})
+	}
+	writer.Flush()
+}
+
+func (api *API) getAudit(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter := history.AuditFilter{
+		User:   req.FormValue("user"),
+		Action: req.FormValue("action"),
+		Target: req.FormValue("target"),
+	}
+	from := req.FormValue("from")
+	if from != "" {
+		date, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid from date "+from, http.StatusInternalServerError)
+			return
+		}
+		filter.From = &date
+	}
+	to := req.FormValue("to")
+	if to != "" {
+		date, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid to date "+to, http.StatusInternalServerError)
+			return
+		}
+		filter.To = &date
+	}
+
+	entries := history.GetAuditEntries(api.historydb, filter)
+	switch strings.ToLower(req.FormValue("format")) {
+	case "", "json":
+		inrec, _ := json.MarshalIndent(entries, "", "  ")
+		w.Write(inrec)
+	case "csv":
+		writeAuditCSV(w, entries)
+	default:
+		api.sendError(w, APIErrorInvalidValue, "Invalid format "+req.FormValue("format"), http.StatusInternalServerError)
+	}
+}
@@ -59,7 +59,7 @@ func (api *API) setBlindSetup(w http.ResponseWriter, req *http.Request) {
 	setup.Mac = strings.ToUpper(setup.Mac)
 	event := make(map[string]interface{})
 	event["blindSetup"] = setup
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -102,12 +102,12 @@ func (api *API) setBlindConfig(w http.ResponseWriter, req *http.Request) {
 			}
 			eventGr := make(map[string]interface{})
 			eventGr["group"] = group
-			api.EventsToBackend <- eventGr
+			api.sendToBackend(req, eventGr)
 		}
 	}
 	event := make(map[string]interface{})
 	event["blind"] = cfg
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -142,10 +142,23 @@ func (api *API) sendBlindCommand(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	rlog.Info("Received Blind cmd", cmd)
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	priority.Blind = &cmd
+	wait, ok := api.commandWait(w, req)
+	if !ok {
+		return
+	}
+	ack := api.newCommandAck(w, priority)
+	if ack == nil {
+		return
+	}
 	event := make(map[string]interface{})
-	event["blindCmd"] = cmd
-	api.EventsToBackend <- event
-	w.Write([]byte("{}"))
+	event["priorityCmd"] = *priority
+	api.sendToBackend(req, event)
+	api.sendCommandAck(w, *ack, wait)
 }
 
 func (api *API) getBlindStatus(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readCalendar(w http.ResponseWriter, name string) {
+	calendar, _ := database.GetCalendar(api.db, name)
+	if calendar == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Calendar "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(calendar)
+}
+
+func (api *API) getCalendarSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	api.readCalendar(w, params["name"])
+}
+
+func (api *API) getCalendarsSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	calendars := database.GetCalendars(api.db)
+	if calendars == nil {
+		calendars = []core.Calendar{}
+	}
+	inrec, _ := json.MarshalIndent(calendars, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeCalendarSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+	res := database.RemoveCalendar(api.db, name)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Calendar "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) saveCalendar(w http.ResponseWriter, calendar core.Calendar) {
+	if calendar.Name == "" {
+		api.sendError(w, APIErrorInvalidValue, "Calendar name is missing", http.StatusInternalServerError)
+		return
+	}
+	err := calendar.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid calendar "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = database.SaveCalendar(api.db, calendar)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Calendar "+calendar.Name+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Calendar " + calendar.Name + " saved")
+	api.readCalendar(w, calendar.Name)
+}
+
+func (api *API) setCalendarSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	calendar := core.Calendar{}
+	err = json.Unmarshal(body, &calendar)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.saveCalendar(w, calendar)
+}
+
+//importCalendar add the events of an iCalendar file to the calendar exceptions
+func (api *API) importCalendar(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	//the imported events have no action to override the schedules with
+	exceptions, err := core.ParseICS(body, core.CalendarModeSuppress, api.coreConf.Site.Location())
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse iCalendar file "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	calendar, _ := database.GetCalendar(api.db, name)
+	if calendar == nil {
+		calendar = &core.Calendar{
+			Name: name,
+		}
+	}
+	if req.FormValue("replace") == "true" {
+		calendar.Exceptions = nil
+	}
+	groupsParam := req.FormValue("groups")
+	if groupsParam != "" {
+		calendar.Groups = nil
+		for _, v := range strings.Split(groupsParam, ",") {
+			gr, err := strconv.Atoi(v)
+			if err != nil {
+				api.sendError(w, APIErrorInvalidValue, "Invalid group "+v, http.StatusInternalServerError)
+				return
+			}
+			calendar.Groups = append(calendar.Groups, gr)
+		}
+	}
+	calendar.Exceptions = append(calendar.Exceptions, exceptions...)
+	api.saveCalendar(w, *calendar)
+}
+
+//getDay parse the date query parameter in the site time zone, today by default
+func (api *API) getDay(req *http.Request) (time.Time, error) {
+	loc := api.coreConf.Site.Location()
+	date := req.FormValue("date")
+	if date == "" {
+		return time.Now().In(loc), nil
+	}
+	day, err := time.ParseInLocation(core.DateFormat, date, loc)
+	if err != nil {
+		return day, NewError("Invalid date " + date)
+	}
+	return day, nil
+}
+
+func (api *API) getSchedulePreview(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	day, err := api.getDay(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var group *int
+	groupID := req.FormValue("groupID")
+	if groupID != "" {
+		i, err := strconv.Atoi(groupID)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid groupID "+groupID, http.StatusInternalServerError)
+			return
+		}
+		group = &i
+	}
+
+	res := []core.SchedulePreview{}
+	planner := core.Planner{
+		Calendars: database.GetCalendars(api.db),
+		Site:      api.coreConf.Site,
+	}
+	schedules := core.ScheduledGroups(database.GetSchedules(api.db), planner.Calendars)
+	if group != nil {
+		schedule, _ := database.GetSchedule(api.db, *group)
+		if schedule == nil {
+			schedule = &core.Schedule{
+				Group: *group,
+			}
+		}
+		schedules = []core.Schedule{*schedule}
+	}
+	for _, schedule := range schedules {
+		res = append(res, planner.Preview(schedule, day))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	inrec, _ := json.MarshalIndent(res, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getSunTimes(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	if !api.coreConf.Site.IsLocated() {
+		api.sendError(w, APIErrorInvalidValue, "Site latitude and longitude are not configured", http.StatusInternalServerError)
+		return
+	}
+	day, err := api.getDay(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	inrec, _ := json.MarshalIndent(api.coreConf.Site.GetSunTimes(day), "", "  ")
+	w.Write(inrec)
+}
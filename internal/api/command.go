@@ -0,0 +1,317 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+const (
+	commandMaxWait  = 60 //seconds
+	commandWaitPoll = 200 * time.Millisecond
+)
+
+//commandWait return the acknowledgement wait requested by the wait parameter (seconds), the errors are sent
+func (api *API) commandWait(w http.ResponseWriter, req *http.Request) (time.Duration, bool) {
+	wait := req.FormValue("wait")
+	if wait == "" {
+		return 0, true
+	}
+	seconds, err := strconv.Atoi(wait)
+	if err != nil || seconds < 0 || seconds > commandMaxWait {
+		api.sendError(w, APIErrorInvalidValue, "Invalid wait "+wait+" (0 to "+strconv.Itoa(commandMaxWait)+"s)", http.StatusInternalServerError)
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+//saveCommandAck save the pending acknowledgement of a driver command and set its ID in the command
+func (api *API) saveCommandAck(cmd *core.PriorityCommand) (*core.CommandAck, error) {
+	now := time.Now().UTC()
+	kind, id := cmd.Point()
+	ack := core.CommandAck{
+		Point:    core.PointName(kind, id),
+		Level:    cmd.Level,
+		Source:   cmd.Source,
+		Status:   core.CommandPending,
+		Date:     now.Format(time.RFC3339),
+		Deadline: now.Add(core.CommandAckTimeout).Format(time.RFC3339),
+		Updated:  now.Format(time.RFC3339),
+		Led:      cmd.Led,
+		Blind:    cmd.Blind,
+		Hvac:     cmd.Hvac,
+	}
+	ackID, err := database.SaveCommandAck(api.db, ack)
+	if err != nil {
+		return nil, err
+	}
+	ack.ID = ackID
+	cmd.Ack = ackID
+	return &ack, nil
+}
+
+//newCommandAck save the pending acknowledgement of a driver command, the errors are sent
+func (api *API) newCommandAck(w http.ResponseWriter, cmd *core.PriorityCommand) *core.CommandAck {
+	ack, err := api.saveCommandAck(cmd)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Command cannot be saved in database", http.StatusInternalServerError)
+		return nil
+	}
+	return ack
+}
+
+//waitCommandAcks reload the pending acknowledgements until they are resolved or the wait is elapsed
+func (api *API) waitCommandAcks(acks []*core.CommandAck, wait time.Duration) {
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		pending := false
+		for _, ack := range acks {
+			if ack.IsPending() {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			return
+		}
+		time.Sleep(commandWaitPoll)
+		for _, ack := range acks {
+			if !ack.IsPending() {
+				continue
+			}
+			current := database.GetCommandAck(api.db, ack.ID)
+			if current != nil {
+				*ack = *current
+			}
+		}
+	}
+}
+
+//sendCommandAck answer the command acknowledgement, once resolved when a wait is requested
+func (api *API) sendCommandAck(w http.ResponseWriter, ack core.CommandAck, wait time.Duration) {
+	api.waitCommandAcks([]*core.CommandAck{&ack}, wait)
+	inrec, _ := json.MarshalIndent(ack, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getCommandAck(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	params := mux.Vars(req)
+	id := params["id"]
+	wait, ok := api.commandWait(w, req)
+	if !ok {
+		return
+	}
+	ack := database.GetCommandAck(api.db, id)
+	if ack == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Command "+id+" not found", http.StatusInternalServerError)
+		return
+	}
+	if api.hasEnoughRight(w, req, api.commandAckGroup(*ack)) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	api.sendCommandAck(w, *ack, wait)
+}
+
+//commandAckGroup return the group of the commanded driver
+func (api *API) commandAckGroup(ack core.CommandAck) int {
+	switch {
+	case ack.Led != nil:
+		if cfg, _ := database.GetLedConfig(api.db, ack.Led.Mac); cfg != nil {
+			return driverGroup(cfg.Group)
+		}
+	case ack.Blind != nil:
+		if cfg, _ := database.GetBlindConfig(api.db, ack.Blind.Mac); cfg != nil {
+			return driverGroup(cfg.Group)
+		}
+	case ack.Hvac != nil:
+		if cfg, _ := database.GetHvacConfig(api.db, ack.Hvac.Mac); cfg != nil {
+			return driverGroup(cfg.Group)
+		}
+	}
+	return 0
+}
+
+//bulkDriver driver addressed by a bulk command target
+type bulkDriver struct {
+	kind  string
+	mac   string
+	group int
+}
+
+func driverGroup(group *int) int {
+	if group == nil {
+		return 0
+	}
+	return *group
+}
+
+//bulkDrivers return the leds, blinds and hvacs addressed by a bulk command target
+func (api *API) bulkDrivers(target core.BulkTarget) ([]bulkDriver, error) {
+	drivers := []bulkDriver{}
+	switch {
+	case target.Mac != "":
+		mac := strings.ToUpper(target.Mac)
+		if led, _ := database.GetLedConfig(api.db, mac); led != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointLed, mac: led.Mac, group: driverGroup(led.Group)})
+		} else if blind, _ := database.GetBlindConfig(api.db, mac); blind != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointBlind, mac: blind.Mac, group: driverGroup(blind.Group)})
+		} else if hvac, _ := database.GetHvacConfig(api.db, mac); hvac != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointHvac, mac: hvac.Mac, group: driverGroup(hvac.Group)})
+		} else {
+			return nil, NewError("Device " + mac + " not found")
+		}
+
+	case target.Label != "":
+		if led, _ := database.GetLedLabelConfig(api.db, target.Label); led != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointLed, mac: led.Mac, group: driverGroup(led.Group)})
+		} else if blind, _ := database.GetBlindLabelConfig(api.db, target.Label); blind != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointBlind, mac: blind.Mac, group: driverGroup(blind.Group)})
+		} else if hvac, _ := database.GetHvacLabelConfig(api.db, target.Label); hvac != nil {
+			drivers = append(drivers, bulkDriver{kind: core.PointHvac, mac: hvac.Mac, group: driverGroup(hvac.Group)})
+		} else {
+			return nil, NewError("Label " + target.Label + " not found")
+		}
+
+	case target.Group != nil:
+		gr, _ := database.GetGroupConfig(api.db, *target.Group)
+		if gr == nil {
+			return nil, NewError("Group " + strconv.Itoa(*target.Group) + " not found")
+		}
+		for _, mac := range gr.Leds {
+			drivers = append(drivers, bulkDriver{kind: core.PointLed, mac: mac, group: gr.Group})
+		}
+		for _, mac := range gr.Blinds {
+			drivers = append(drivers, bulkDriver{kind: core.PointBlind, mac: mac, group: gr.Group})
+		}
+		for _, mac := range gr.Hvacs {
+			drivers = append(drivers, bulkDriver{kind: core.PointHvac, mac: mac, group: gr.Group})
+		}
+	}
+	for i, driver := range drivers {
+		drivers[i].mac = strings.ToUpper(driver.mac)
+	}
+	return drivers, nil
+}
+
+//bulkDriverCommand return the command of a driver, nil when the bulk command has no setpoint for its type
+func bulkDriverCommand(bulk core.BulkCommand, priority core.PriorityCommand, driver bulkDriver) *core.PriorityCommand {
+	cmd := priority
+	switch {
+	case driver.kind == core.PointLed && bulk.Led != nil:
+		led := *bulk.Led
+		led.Mac = driver.mac
+		cmd.Led = &led
+	case driver.kind == core.PointBlind && bulk.Blind != nil:
+		blind := *bulk.Blind
+		blind.Mac = driver.mac
+		cmd.Blind = &blind
+	case driver.kind == core.PointHvac && bulk.Hvac != nil:
+		hvac := *bulk.Hvac
+		hvac.Mac = driver.mac
+		cmd.Hvac = &hvac
+	default:
+		return nil
+	}
+	return &cmd
+}
+
+func (api *API) sendBulkCommand(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	bulk := core.BulkCommand{}
+	err = json.Unmarshal(body, &bulk)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = bulk.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid bulk command "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	wait, ok := api.commandWait(w, req)
+	if !ok {
+		return
+	}
+
+	results := []core.BulkTargetResult{}
+	acks := make(map[string]*core.CommandAck) //by point, a driver addressed twice is commanded once
+	pending := []*core.CommandAck{}
+	cmds := []core.PriorityCommand{}
+	for _, target := range bulk.Targets {
+		result := core.BulkTargetResult{
+			Target:  target,
+			Drivers: []core.BulkDriverResult{},
+		}
+		drivers, err := api.bulkDrivers(target)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		for _, driver := range drivers {
+			cmd := bulkDriverCommand(bulk, *priority, driver)
+			if cmd == nil {
+				continue
+			}
+			res := core.BulkDriverResult{
+				Mac:  driver.mac,
+				Type: driver.kind,
+			}
+			point := core.PointName(driver.kind, driver.mac)
+			ack, ok := acks[point]
+			switch {
+			case ok:
+				res.Ack = ack
+			case api.hasEnoughRight(w, req, driver.group) != nil:
+				res.Error = "Unauthorized Access"
+			default:
+				ack, err = api.saveCommandAck(cmd)
+				if err != nil {
+					res.Error = "Command cannot be saved in database"
+					break
+				}
+				acks[point] = ack
+				pending = append(pending, ack)
+				cmds = append(cmds, *cmd)
+				res.Ack = ack
+			}
+			result.Drivers = append(result.Drivers, res)
+		}
+		if len(result.Drivers) == 0 {
+			result.Error = "No driver to command"
+		}
+		results = append(results, result)
+	}
+
+	if len(cmds) > 0 {
+		rlog.Info("Received bulk cmd for " + strconv.Itoa(len(cmds)) + " drivers")
+		event := make(map[string]interface{})
+		event["bulkCmd"] = cmds
+		api.sendToBackend(req, event)
+		api.waitCommandAcks(pending, wait)
+	}
+	inrec, _ := json.MarshalIndent(results, "", "  ")
+	w.Write(inrec)
+}
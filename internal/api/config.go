@@ -0,0 +1,252 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	gm "github.com/energieip/common-components-go/pkg/dgroup"
+	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+)
+
+//configBatch items of a configuration batch which passed the validation
+type configBatch struct {
+	conf    dserver.Conf
+	groups  map[int]bool //groups configured by the batch
+	results []core.ConfigResult
+}
+
+func newConfigBatch() *configBatch {
+	return &configBatch{
+		groups:  make(map[int]bool),
+		results: []core.ConfigResult{},
+	}
+}
+
+//add record the outcome of an item, return true when it is valid
+func (b *configBatch) add(kind string, index int, id string, err error) bool {
+	res := core.ConfigResult{
+		Type:   kind,
+		Index:  index,
+		ID:     id,
+		Status: core.ConfigQueued,
+	}
+	if err != nil {
+		res.Status = core.ConfigRejected
+		res.Error = err.Error()
+	}
+	b.results = append(b.results, res)
+	return err == nil
+}
+
+//rejected check if an item of the batch is invalid
+func (b *configBatch) rejected() bool {
+	for _, res := range b.results {
+		if res.Status == core.ConfigRejected {
+			return true
+		}
+	}
+	return false
+}
+
+//abort mark the valid items as not applied
+func (b *configBatch) abort() {
+	for i, res := range b.results {
+		if res.Status == core.ConfigQueued {
+			b.results[i].Status = core.ConfigAborted
+		}
+	}
+}
+
+//checkDriverConfig return the mac or label of a driver configuration, an error when the driver is unknown
+func checkDriverConfig(mac string, label *string, group *int, byMac, byLabel func(string) bool) (string, error) {
+	id := mac
+	if id == "" && label != nil {
+		id = *label
+	}
+	if group != nil && *group < 0 {
+		return id, NewError("Invalid groupID " + strconv.Itoa(*group))
+	}
+	if mac != "" {
+		if !byMac(mac) {
+			return id, NewError("Device " + mac + " not found")
+		}
+		return id, nil
+	}
+	if label == nil || *label == "" {
+		return id, NewError("Missing mac or label")
+	}
+	if !byLabel(*label) {
+		return id, NewError("Label " + *label + " not found")
+	}
+	return id, nil
+}
+
+//validateConfig check the items of a configuration batch
+func (api *API) validateConfig(config dserver.Conf) *configBatch {
+	batch := newConfigBatch()
+	for i, gr := range config.Groups {
+		var err error
+		if gr.Group < 0 {
+			err = NewError("Invalid groupID " + strconv.Itoa(gr.Group))
+		}
+		if batch.add("group", i, strconv.Itoa(gr.Group), err) {
+			batch.conf.Groups = append(batch.conf.Groups, gr)
+			batch.groups[gr.Group] = true
+		}
+	}
+
+	for i, sw := range config.Switchs {
+		id := ""
+		var err error
+		if sw.Mac == nil || *sw.Mac == "" {
+			err = NewError("Missing mac")
+		} else {
+			mac := strings.ToUpper(*sw.Mac)
+			sw.Mac = &mac
+			id = mac
+		}
+		if batch.add("switch", i, id, err) {
+			batch.conf.Switchs = append(batch.conf.Switchs, sw)
+		}
+	}
+
+	drivers := []*int{} //groups of the valid drivers
+	for i, led := range config.Leds {
+		led.Mac = strings.ToUpper(led.Mac)
+		id, err := checkDriverConfig(led.Mac, led.Label, led.Group, func(mac string) bool {
+			cfg, _ := database.GetLedConfig(api.db, mac)
+			return cfg != nil
+		}, func(label string) bool {
+			cfg, _ := database.GetLedLabelConfig(api.db, label)
+			return cfg != nil
+		})
+		if batch.add("led", i, id, err) {
+			batch.conf.Leds = append(batch.conf.Leds, led)
+			drivers = append(drivers, led.Group)
+		}
+	}
+
+	for i, sensor := range config.Sensors {
+		sensor.Mac = strings.ToUpper(sensor.Mac)
+		id, err := checkDriverConfig(sensor.Mac, sensor.Label, sensor.Group, func(mac string) bool {
+			cfg, _ := database.GetSensorConfig(api.db, mac)
+			return cfg != nil
+		}, func(label string) bool {
+			cfg, _ := database.GetSensorLabelConfig(api.db, label)
+			return cfg != nil
+		})
+		if batch.add("sensor", i, id, err) {
+			batch.conf.Sensors = append(batch.conf.Sensors, sensor)
+			drivers = append(drivers, sensor.Group)
+		}
+	}
+
+	for i, blind := range config.Blinds {
+		blind.Mac = strings.ToUpper(blind.Mac)
+		id, err := checkDriverConfig(blind.Mac, blind.Label, blind.Group, func(mac string) bool {
+			cfg, _ := database.GetBlindConfig(api.db, mac)
+			return cfg != nil
+		}, func(label string) bool {
+			cfg, _ := database.GetBlindLabelConfig(api.db, label)
+			return cfg != nil
+		})
+		if batch.add("blind", i, id, err) {
+			batch.conf.Blinds = append(batch.conf.Blinds, blind)
+			drivers = append(drivers, blind.Group)
+		}
+	}
+
+	for i, hvac := range config.Hvacs {
+		hvac.Mac = strings.ToUpper(hvac.Mac)
+		id, err := checkDriverConfig(hvac.Mac, hvac.Label, hvac.Group, func(mac string) bool {
+			cfg, _ := database.GetHvacConfig(api.db, mac)
+			return cfg != nil
+		}, func(label string) bool {
+			cfg, _ := database.GetHvacLabelConfig(api.db, label)
+			return cfg != nil
+		})
+		if batch.add("hvac", i, id, err) {
+			batch.conf.Hvacs = append(batch.conf.Hvacs, hvac)
+			drivers = append(drivers, hvac.Group)
+		}
+	}
+
+	for i, wago := range config.Wagos {
+		wago.Mac = strings.ToUpper(wago.Mac)
+		id, err := checkDriverConfig(wago.Mac, wago.Label, nil, func(mac string) bool {
+			cfg, _ := database.GetWagoConfig(api.db, mac)
+			return cfg != nil
+		}, func(label string) bool {
+			cfg, _ := database.GetWagoLabelConfig(api.db, label)
+			return cfg != nil
+		})
+		if batch.add("wago", i, id, err) {
+			batch.conf.Wagos = append(batch.conf.Wagos, wago)
+		}
+	}
+
+	for i, nano := range config.Nanosenses {
+		nano.Mac = strings.ToUpper(nano.Mac)
+		var err error
+		if nano.Label == "" {
+			err = NewError("Missing label")
+		}
+		if batch.add("nano", i, nano.Label, err) {
+			batch.conf.Nanosenses = append(batch.conf.Nanosenses, nano)
+		}
+	}
+
+	//the unknown groups of the drivers are created as by the driver configuration
+	for _, group := range drivers {
+		if group == nil || batch.groups[*group] {
+			continue
+		}
+		batch.groups[*group] = true
+		gr, _ := database.GetGroupConfig(api.db, *group)
+		if gr != nil {
+			continue
+		}
+		name := "Group " + strconv.Itoa(*group)
+		batch.conf.Groups = append(batch.conf.Groups, gm.GroupConfig{
+			Group:        *group,
+			FriendlyName: &name,
+		})
+		batch.add("group", -1, strconv.Itoa(*group), nil)
+	}
+	return batch
+}
+
+//sendConfig record each item in the audit log and forward the batch to the service
+func (api *API) sendConfig(req *http.Request, conf dserver.Conf) {
+	origin := api.getAuditOrigin(req)
+	for _, gr := range conf.Groups {
+		api.audit(origin, "group", gr)
+	}
+	for _, sw := range conf.Switchs {
+		api.audit(origin, "switch", sw)
+	}
+	for _, led := range conf.Leds {
+		api.audit(origin, "led", led)
+	}
+	for _, sensor := range conf.Sensors {
+		api.audit(origin, "sensor", sensor)
+	}
+	for _, blind := range conf.Blinds {
+		api.audit(origin, "blind", blind)
+	}
+	for _, hvac := range conf.Hvacs {
+		api.audit(origin, "hvac", hvac)
+	}
+	for _, wago := range conf.Wagos {
+		api.audit(origin, "wago", wago)
+	}
+	for _, nano := range conf.Nanosenses {
+		api.audit(origin, "nano", nano)
+	}
+	event := make(map[string]interface{})
+	event["config"] = conf
+	api.EventsToBackend <- event
+}
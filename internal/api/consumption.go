@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/common-components-go/pkg/tools"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/gorilla/context"
+	"github.com/mitchellh/mapstructure"
+	"github.com/romana/rlog"
+)
+
+func (api *API) consumptionEvents(w http.ResponseWriter, r *http.Request) {
+	sub := consumptionSubscription{}
+	groupsParam := r.FormValue("groups")
+	if groupsParam == "all" {
+		sub.allGroups = true
+	} else if groupsParam != "" {
+		for _, v := range strings.Split(groupsParam, ",") {
+			gr, err := strconv.Atoi(v)
+			if err != nil {
+				api.sendError(w, APIErrorInvalidValue, "Invalid group "+v, http.StatusInternalServerError)
+				return
+			}
+			sub.groups = append(sub.groups, gr)
+		}
+	}
+	zonesParam := r.FormValue("zones")
+	if zonesParam == "all" {
+		sub.allZones = true
+	} else if zonesParam != "" {
+		sub.zones = strings.Split(zonesParam, ",")
+	}
+
+	ws, err := api.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		rlog.Error("Error when switching in consumption websocket " + err.Error())
+		return
+	}
+	decoded := context.Get(r, "decoded")
+	mapstructure.Decode(decoded.(duser.UserAccess), &sub.auth)
+	api.apiMutex.Lock()
+	api.clientsConso[ws] = sub
+	api.apiMutex.Unlock()
+}
+
+//filter return the consumption visible by the client with the subscribed breakdowns
+//users only get the consumption of their access groups
+func (sub consumptionSubscription) filter(event core.EventConsumption) core.EventConsumption {
+	res := core.EventConsumption{
+		Leds:   event.Leds,
+		Blinds: event.Blinds,
+		Hvac:   event.Hvac,
+		Date:   event.Date,
+	}
+	isUser := sub.auth.Priviledge == duser.PriviledgeUser
+	if isUser {
+		res.Leds = 0
+		res.Blinds = 0
+		res.Hvac = 0
+	}
+	for _, gr := range event.Groups {
+		if isUser {
+			if !tools.IntInSlice(gr.Group, sub.auth.AccessGroups) {
+				continue
+			}
+			res.Leds += gr.Leds
+			res.Blinds += gr.Blinds
+			res.Hvac += gr.Hvac
+		}
+		if sub.allGroups || tools.IntInSlice(gr.Group, sub.groups) {
+			res.Groups = append(res.Groups, gr)
+		}
+	}
+	if isUser {
+		//zones mix several groups
+		return res
+	}
+	for _, zone := range event.Zones {
+		if sub.allZones {
+			res.Zones = append(res.Zones, zone)
+			continue
+		}
+		for _, frame := range zone.Frames {
+			if tools.StringInSlice(frame, sub.zones) {
+				res.Zones = append(res.Zones, zone)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (api *API) websocketConsumptions() {
+	for {
+		select {
+		case event := <-api.eventsConso:
+			api.apiMutex.Lock()
+			for client, sub := range api.clientsConso {
+				if err := client.WriteJSON(sub.filter(event)); err != nil {
+					rlog.Error("Error writing in websocket" + err.Error())
+					client.Close()
+					delete(api.clientsConso, client)
+				}
+			}
+			api.apiMutex.Unlock()
+		case <-api.loops["websocketConsumptions"].Probe:
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/duser"
 	"github.com/energieip/srv200-coreservice-go/internal/core"
 	"github.com/energieip/srv200-coreservice-go/internal/database"
@@ -31,6 +32,13 @@ const (
 	FilterTypeHvac   = "hvac"
 	FilterTypeWago   = "wago"
 	FilterTypeNano   = "nanosense"
+	FilterTypeSwitch = "switch"
+
+	SortMac       = "mac"
+	SortLabel     = "label"
+	SortGroup     = "group"
+	SortSwitchMac = "switchMac"
+	SortError     = "error"
 )
 
 //APIError Message error code
@@ -41,12 +49,14 @@ type APIError struct {
 
 type API struct {
 	clients         map[*websocket.Conn]duser.UserAccess
-	clientsConso    map[*websocket.Conn]duser.UserAccess
+	clientsConso    map[*websocket.Conn]consumptionSubscription
+	clientsAlarm    map[*websocket.Conn]bool
 	upgrader        websocket.Upgrader
 	db              database.Database
 	historydb       history.HistoryDb
 	eventsAPI       chan map[string]interface{}
 	eventsConso     chan core.EventConsumption
+	eventsAlarm     chan core.Alarm
 	EventsToBackend chan map[string]interface{}
 	access          cmap.ConcurrentMap
 	apiMutex        sync.Mutex
@@ -61,6 +71,18 @@ type API struct {
 	exportDBPath    string
 	exportDBStatus  string
 	importDBStatus  string
+	coreConf        core.CoreConfig
+	serviceHealth   func() core.Health
+	loops           map[string]*core.LoopProbe //websocket loops lag
+}
+
+//consumptionSubscription consumption breakdowns requested by a websocket client
+type consumptionSubscription struct {
+	auth      duser.UserAccess
+	allGroups bool
+	groups    []int
+	allZones  bool
+	zones     []string
 }
 
 type JwtToken struct {
@@ -83,14 +105,26 @@ type UserAuthorization struct {
 	AccessGroup []int    `json:"accessGroups"`
 }
 
-type LedHist struct {
-	Energy float64 `json:"energy"`
-	Power  int     `json:"power"`
-	Date   string  `json:"date"`
+//GlobalHistory consumption history per driver type
+type GlobalHistory struct {
+	Leds    []history.Point `json:"leds,omitempty"`
+	Blinds  []history.Point `json:"blinds,omitempty"`
+	Hvacs   []history.Point `json:"hvacs,omitempty"`
+	Switchs []history.Point `json:"switchs,omitempty"`
 }
 
-type GlobalHistory struct {
-	Leds []LedHist `json:"leds"`
+//Status drivers status, the drivers of an offline switch keep their last status
+type Status struct {
+	dserver.Status
+	Stale map[string]string `json:"stale"` //stale driver mac: unreachable since
+	Total map[string]int    `json:"total"` //matching drivers by list, before the pagination
+}
+
+//Dump drivers dump, the drivers of an offline switch keep their last status
+type Dump struct {
+	dserver.Dump
+	Stale map[string]string `json:"stale"` //stale driver or switch mac: unreachable since
+	Total map[string]int    `json:"total"` //matching drivers by list, before the pagination
 }
 
 type APIInfo struct {
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readFacade(w http.ResponseWriter, cfg core.Facade) {
+	facade := database.GetFacade(api.db, cfg)
+	if facade == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Facade not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(facade)
+}
+
+//getFacadeKey return the facade identifier of the blind label or group request parameter
+func (api *API) getFacadeKey(req *http.Request) (*core.Facade, error) {
+	params := mux.Vars(req)
+	label, ok := params["label"]
+	if ok {
+		return &core.Facade{
+			Label: strings.Replace(label, "-", "_", -1),
+		}, nil
+	}
+	grID := params["groupID"]
+	i, err := strconv.Atoi(grID)
+	if err != nil {
+		return nil, NewError("Group " + grID + " not found")
+	}
+	return &core.Facade{
+		Group: &i,
+	}, nil
+}
+
+func (api *API) getFacadeSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	key, err := api.getFacadeKey(req)
+	if err != nil {
+		api.sendError(w, APIErrorDeviceNotFound, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.readFacade(w, *key)
+}
+
+func (api *API) getFacadesSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	facades := database.GetFacades(api.db)
+	if facades == nil {
+		facades = []core.Facade{}
+	}
+	inrec, _ := json.MarshalIndent(facades, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeFacadeSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	key, err := api.getFacadeKey(req)
+	if err != nil {
+		api.sendError(w, APIErrorDeviceNotFound, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := database.RemoveFacade(api.db, *key)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Facade not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) setFacadeSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	facade := core.Facade{}
+	err = json.Unmarshal(body, &facade)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	facade.Label = strings.Replace(facade.Label, "-", "_", -1)
+	if facade.Label != "" {
+		//the blind group comes from its setup
+		facade.Group = nil
+		facade.SunTracking = false
+		if facade.Azimuth == nil {
+			api.sendError(w, APIErrorInvalidValue, "Blind facade azimuth is missing", http.StatusInternalServerError)
+			return
+		}
+	} else if facade.Group == nil {
+		api.sendError(w, APIErrorInvalidValue, "Facade label or group is missing", http.StatusInternalServerError)
+		return
+	}
+
+	err = database.SaveFacade(api.db, facade)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Facade cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Facade saved")
+	api.readFacade(w, facade)
+}
+
+func (api *API) getSunTracking(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	if !api.coreConf.Site.IsLocated() {
+		api.sendError(w, APIErrorInvalidValue, "Site latitude and longitude are not configured", http.StatusInternalServerError)
+		return
+	}
+	date := time.Now()
+	param := req.FormValue("date")
+	if param != "" {
+		d, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid date "+param, http.StatusInternalServerError)
+			return
+		}
+		date = d
+	}
+
+	res := []core.SunTrackingStatus{}
+	sunAzimuth, sunElevation := api.coreConf.Site.SunPosition(date)
+	for _, facade := range database.GetGroupFacades(api.db) {
+		if facade.Azimuth == nil {
+			continue
+		}
+		res = append(res, facade.GetSunTracking(sunAzimuth, sunElevation))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	inrec, _ := json.MarshalIndent(res, "", "  ")
+	w.Write(inrec)
+}
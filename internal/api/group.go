@@ -72,7 +72,7 @@ func (api *API) setGroupConfig(w http.ResponseWriter, req *http.Request) {
 	}
 	event := make(map[string]interface{})
 	event["group"] = gr
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -95,9 +95,14 @@ func (api *API) sendGroupCommand(w http.ResponseWriter, req *http.Request) {
 		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
 		return
 	}
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	priority.Group = &gr
 	event := make(map[string]interface{})
-	event["groupCmd"] = gr
-	api.EventsToBackend <- event
+	event["priorityCmd"] = *priority
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
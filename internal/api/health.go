@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/energieip/srv200-coreservice-go/internal/history"
+)
+
+const healthTimeout = 2 * time.Second
+
+//pingDatabase run the database request, a hanging request is reported after the timeout
+func pingDatabase(ping func() error) core.DatabaseHealth {
+	start := time.Now()
+	res := make(chan error, 1)
+	go func() {
+		res <- ping()
+	}()
+
+	var err error
+	select {
+	case err = <-res:
+	case <-time.After(healthTimeout):
+		err = errors.New("No response after " + healthTimeout.String())
+	}
+	health := core.DatabaseHealth{
+		Connected: err == nil,
+		Latency:   float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
+
+func (api *API) readHealth() core.Health {
+	health := core.Health{}
+	if api.serviceHealth != nil {
+		health = api.serviceHealth()
+	}
+	if health.Loops == nil {
+		health.Loops = make(map[string]core.LoopHealth)
+	}
+	for name, probe := range api.loops {
+		health.Loops[name] = probe.Health()
+	}
+	health.Database = pingDatabase(func() error {
+		return database.Ping(api.db)
+	})
+	health.HistoryDb = pingDatabase(func() error {
+		return history.Ping(api.historydb)
+	})
+
+	api.apiMutex.Lock()
+	health.Websockets = map[string]int{
+		"events":       len(api.clients),
+		"consumptions": len(api.clientsConso),
+		"alarms":       len(api.clientsAlarm),
+	}
+	api.apiMutex.Unlock()
+	return health
+}
+
+func (api *API) sendHealth(w http.ResponseWriter, health core.Health, ok bool) {
+	code := http.StatusOK
+	health.Status = core.HealthOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+		health.Status = core.HealthError
+	}
+	inrec, _ := json.MarshalIndent(health, "", "  ")
+	w.WriteHeader(code)
+	w.Write(inrec)
+}
+
+func (api *API) getHealthz(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	health := api.readHealth()
+	api.sendHealth(w, health, health.Alive())
+}
+
+func (api *API) getReadyz(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	health := api.readHealth()
+	api.sendHealth(w, health, health.Ready())
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/energieip/srv200-coreservice-go/internal/history"
+)
+
+//getHistoryFilter parse the history query parameters
+func (api *API) getHistoryFilter(req *http.Request) (*history.Filter, error) {
+	filter := history.Filter{
+		Resolution: history.ResolutionMinute,
+		Mac:        strings.ToUpper(req.FormValue("mac")),
+		Label:      req.FormValue("label"),
+	}
+
+	resolution := strings.ToLower(req.FormValue("resolution"))
+	if resolution != "" {
+		if !history.IsValidResolution(resolution) {
+			return nil, NewError("Invalid resolution " + resolution)
+		}
+		filter.Resolution = resolution
+	}
+
+	groupID := req.FormValue("groupID")
+	if groupID != "" {
+		i, err := strconv.Atoi(groupID)
+		if err != nil {
+			return nil, NewError("Invalid groupID " + groupID)
+		}
+		filter.Group = &i
+	}
+
+	from := req.FormValue("from")
+	if from != "" {
+		date, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, NewError("Invalid from date " + from)
+		}
+		filter.From = &date
+	}
+
+	to := req.FormValue("to")
+	if to != "" {
+		date, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, NewError("Invalid to date " + to)
+		}
+		filter.To = &date
+	}
+	filter.Bound(time.Now().UTC())
+	if !filter.From.Before(*filter.To) {
+		return nil, NewError("Invalid range, from must be before to")
+	}
+	return &filter, nil
+}
+
+func (api *API) getHistoryCompaction(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	status := history.GetCompactionStatus(api.historydb)
+	inrec, _ := json.MarshalIndent(status, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getSensorsHistory(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sensors := history.GetSensorsHistory(api.historydb, *filter)
+	inrec, _ := json.MarshalIndent(sensors, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getNanosensesHistory(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	thresholds := history.AirQualityThresholds{
+		CO2: api.coreConf.AirQuality.CO2Threshold,
+		COV: api.coreConf.AirQuality.COVThreshold,
+	}
+	co2 := req.FormValue("co2Threshold")
+	if co2 != "" {
+		val, err := strconv.ParseFloat(co2, 64)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid co2Threshold "+co2, http.StatusInternalServerError)
+			return
+		}
+		thresholds.CO2 = val
+	}
+	cov := req.FormValue("covThreshold")
+	if cov != "" {
+		val, err := strconv.ParseFloat(cov, 64)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid covThreshold "+cov, http.StatusInternalServerError)
+			return
+		}
+		thresholds.COV = val
+	}
+
+	nanos := history.GetNanosensesHistory(api.historydb, *filter, thresholds)
+	inrec, _ := json.MarshalIndent(nanos, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getSwitchsReport(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	threshold := api.coreConf.Metering.DiscrepancyThreshold
+	param := req.FormValue("threshold")
+	if param != "" {
+		threshold, err = strconv.ParseFloat(param, 64)
+		if err != nil {
+			api.sendError(w, APIErrorInvalidValue, "Invalid threshold "+param, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	switchs := make(map[string]string)
+	for mac, sw := range database.GetSwitchsConfig(api.db) {
+		if filter.Mac != "" && filter.Mac != mac {
+			continue
+		}
+		label := ""
+		if sw.Label != nil {
+			label = *sw.Label
+		}
+		switchs[mac] = label
+	}
+	reports := history.GetSwitchsReport(api.historydb, switchs, filter.From, filter.To, threshold)
+
+	inrec, _ := json.MarshalIndent(reports, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getHistoryCost(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if req.FormValue("resolution") == "" {
+		filter.Resolution = history.ResolutionMonth
+	}
+
+	driverType := strings.ToLower(req.FormValue("type"))
+	if driverType == "" {
+		driverType = FilterTypeAll
+	}
+	tables := make(map[string]string)
+	if driverType == FilterTypeAll || driverType == FilterTypeLed {
+		tables[FilterTypeLed] = history.LedsTable
+	}
+	if driverType == FilterTypeAll || driverType == FilterTypeBlind {
+		tables[FilterTypeBlind] = history.BlindsTable
+	}
+	if driverType == FilterTypeAll || driverType == FilterTypeHvac {
+		tables[FilterTypeHvac] = history.HvacsTable
+	}
+
+	report := history.GetCost(api.historydb, tables, database.GetTariffs(api.db), api.coreConf.Site.Location(), *filter)
+	inrec, _ := json.MarshalIndent(report, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) getSwitchsConnectivity(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := api.getHistoryFilter(req)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events := history.GetConnectivityHistory(api.historydb, *filter)
+	inrec, _ := json.MarshalIndent(events, "", "  ")
+	w.Write(inrec)
+}
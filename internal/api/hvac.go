@@ -59,7 +59,7 @@ func (api *API) setHvacSetup(w http.ResponseWriter, req *http.Request) {
 	setup.Mac = strings.ToUpper(setup.Mac)
 	event := make(map[string]interface{})
 	event["hvacSetup"] = setup
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -102,12 +102,12 @@ func (api *API) setHvacConfig(w http.ResponseWriter, req *http.Request) {
 			}
 			eventGr := make(map[string]interface{})
 			eventGr["group"] = group
-			api.EventsToBackend <- eventGr
+			api.sendToBackend(req, eventGr)
 		}
 	}
 	event := make(map[string]interface{})
 	event["hvac"] = cfg
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -142,10 +142,23 @@ func (api *API) sendHvacCommand(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	rlog.Info("Received Hvac cmd", cmd)
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	priority.Hvac = &cmd
+	wait, ok := api.commandWait(w, req)
+	if !ok {
+		return
+	}
+	ack := api.newCommandAck(w, priority)
+	if ack == nil {
+		return
+	}
 	event := make(map[string]interface{})
-	event["hvacCmd"] = cmd
-	api.EventsToBackend <- event
-	w.Write([]byte("{}"))
+	event["priorityCmd"] = *priority
+	api.sendToBackend(req, event)
+	api.sendCommandAck(w, *ack, wait)
 }
 
 func (api *API) getHvacStatus(w http.ResponseWriter, req *http.Request) {
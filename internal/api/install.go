@@ -49,6 +49,6 @@ func (api *API) installDriver(w http.ResponseWriter, req *http.Request) {
 	}
 	event := make(map[string]interface{})
 	event["installDriver"] = driver
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
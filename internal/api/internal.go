@@ -17,6 +17,7 @@ import (
 	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 	pkg "github.com/energieip/common-components-go/pkg/service"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
 	"github.com/energieip/srv200-coreservice-go/internal/database"
 	"github.com/gorilla/mux"
 	"github.com/romana/rlog"
@@ -307,6 +308,13 @@ func (api *InternalAPI) getDump(w http.ResponseWriter, req *http.Request) {
 	w.Write(inrec)
 }
 
+//internalPriority set the manual level of the internal API commands
+func internalPriority(cmd core.PriorityCommand) core.PriorityCommand {
+	cmd.Level = core.PriorityManual
+	cmd.Source = "internal"
+	return cmd
+}
+
 func (api *InternalAPI) sendLedCommand(w http.ResponseWriter, req *http.Request) {
 	api.setDefaultHeader(w, req)
 	defer req.Body.Close()
@@ -331,7 +339,7 @@ func (api *InternalAPI) sendLedCommand(w http.ResponseWriter, req *http.Request)
 
 	rlog.Info("Received led cmd", led)
 	event := make(map[string]interface{})
-	event["ledCmd"] = led
+	event["priorityCmd"] = internalPriority(core.PriorityCommand{Led: &led})
 	api.EventsToBackend <- event
 	w.Write([]byte("{}"))
 }
@@ -360,7 +368,7 @@ func (api *InternalAPI) sendBlindCommand(w http.ResponseWriter, req *http.Reques
 
 	rlog.Info("Received Blind cmd", cmd)
 	event := make(map[string]interface{})
-	event["blindCmd"] = cmd
+	event["priorityCmd"] = internalPriority(core.PriorityCommand{Blind: &cmd})
 	api.EventsToBackend <- event
 	w.Write([]byte("{}"))
 }
@@ -389,7 +397,7 @@ func (api *InternalAPI) sendHvacCommand(w http.ResponseWriter, req *http.Request
 
 	rlog.Info("Received Hvac cmd", cmd)
 	event := make(map[string]interface{})
-	event["hvacCmd"] = cmd
+	event["priorityCmd"] = internalPriority(core.PriorityCommand{Hvac: &cmd})
 	api.EventsToBackend <- event
 	w.Write([]byte("{}"))
 }
@@ -411,7 +419,7 @@ func (api *InternalAPI) sendGroupCommand(w http.ResponseWriter, req *http.Reques
 	}
 
 	event := make(map[string]interface{})
-	event["groupCmd"] = gr
+	event["priorityCmd"] = internalPriority(core.PriorityCommand{Group: &gr})
 	api.EventsToBackend <- event
 	w.Write([]byte("{}"))
 }
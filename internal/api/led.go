@@ -61,7 +61,7 @@ func (api *API) setLedSetup(w http.ResponseWriter, req *http.Request) {
 
 	event := make(map[string]interface{})
 	event["ledSetup"] = led
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -104,12 +104,12 @@ func (api *API) setLedConfig(w http.ResponseWriter, req *http.Request) {
 			}
 			eventGr := make(map[string]interface{})
 			eventGr["group"] = group
-			api.EventsToBackend <- eventGr
+			api.sendToBackend(req, eventGr)
 		}
 	}
 	event := make(map[string]interface{})
 	event["led"] = led
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -142,10 +142,23 @@ func (api *API) sendLedCommand(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	rlog.Info("Received led cmd", led)
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	priority.Led = &led
+	wait, ok := api.commandWait(w, req)
+	if !ok {
+		return
+	}
+	ack := api.newCommandAck(w, priority)
+	if ack == nil {
+		return
+	}
 	event := make(map[string]interface{})
-	event["ledCmd"] = led
-	api.EventsToBackend <- event
-	w.Write([]byte("{}"))
+	event["priorityCmd"] = *priority
+	api.sendToBackend(req, event)
+	api.sendCommandAck(w, *ack, wait)
 }
 
 func (api *API) getLedStatus(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/energieip/srv200-coreservice-go/internal/history"
+	"github.com/romana/rlog"
+)
+
+func (api *API) testMail(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	test := core.MailTest{}
+	err = json.Unmarshal(body, &test)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mail := api.coreConf.Mail
+	var data interface{}
+	to := test.To
+	switch test.Template {
+	case core.MailTemplateAlarm:
+		now := time.Now().UTC().Format(time.RFC3339)
+		data = core.Alarm{
+			Type:        core.AlarmSwitchTimeout,
+			Severity:    core.AlarmSeverityCritical,
+			Mac:         "00:00:00:00:00:00",
+			Label:       "TEST",
+			Message:     "Test alarm, no dump since " + now,
+			Active:      true,
+			Raised:      now,
+			Occurrences: 1,
+		}
+		if len(to) == 0 {
+			to = mail.Recipients[core.AlarmSeverityCritical]
+		}
+	case core.MailTemplateSummary:
+		end := time.Now().In(api.coreConf.Site.Location())
+		begin := end.AddDate(0, 0, -1)
+		data = core.NewMailSummary(begin, end, history.GetSwitchsEnergy(api.historydb, begin, end),
+			database.GetOfflineSwitchs(api.db), database.GetProjects(api.db))
+		if len(to) == 0 {
+			to = mail.Summary
+		}
+	default:
+		api.sendError(w, APIErrorInvalidValue, "Invalid template "+test.Template, http.StatusInternalServerError)
+		return
+	}
+
+	subject, content, err := mail.Render(test.Template, data)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid "+test.Template+" template "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = mail.Send(to, subject, content)
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Cannot send email "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Test " + test.Template + " email sent")
+	w.Write([]byte("{}"))
+}
@@ -53,7 +53,7 @@ func (api *API) replaceDriver(w http.ResponseWriter, req *http.Request) {
 
 	event := make(map[string]interface{})
 	event["replaceDriver"] = driver
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -63,6 +63,7 @@ func (api *API) uploadHandler(w http.ResponseWriter, r *http.Request) {
 			// err is io.EOF, files upload completes.
 			tempFile.Close()
 			rlog.Info("Hit last part of multipart upload / do post treatment")
+			origin := api.getAuditOrigin(r)
 			go func(filename string) {
 				cmd := exec.Command("ifcparser.py", "-i", filename)
 				stdout, err := cmd.StdoutPipe()
@@ -131,7 +132,7 @@ func (api *API) uploadHandler(w http.ResponseWriter, r *http.Request) {
 
 				event := make(map[string]interface{})
 				event["map"] = mapInfo
-				api.EventsToBackend <- event
+				api.sendAuditedEvent(origin, event)
 			}(tempFile.Name())
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("{}"))
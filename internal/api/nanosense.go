@@ -33,6 +33,6 @@ func (api *API) setNanoConfig(w http.ResponseWriter, req *http.Request) {
 
 	event := make(map[string]interface{})
 	event["nano"] = nano
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/mitchellh/mapstructure"
+)
+
+//commandPriority return the priority of a command request from its priority (manual by default)
+//and timeout (minutes) parameters, the errors are sent
+func (api *API) commandPriority(w http.ResponseWriter, req *http.Request) *core.PriorityCommand {
+	decoded := context.Get(req, "decoded")
+	var auth duser.UserAccess
+	mapstructure.Decode(decoded.(duser.UserAccess), &auth)
+	cmd := core.PriorityCommand{
+		Level:  core.PriorityManual,
+		Source: auth.UserHash,
+	}
+	level := req.FormValue("priority")
+	switch level {
+	case "", core.PriorityManual:
+	case core.PrioritySafety, core.PriorityDefault:
+		if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+			api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+			return nil
+		}
+		cmd.Level = level
+	default:
+		api.sendError(w, APIErrorInvalidValue, "Invalid priority "+level, http.StatusInternalServerError)
+		return nil
+	}
+	timeout := req.FormValue("timeout")
+	if timeout != "" {
+		minutes, err := strconv.Atoi(timeout)
+		if err != nil || minutes < 0 {
+			api.sendError(w, APIErrorInvalidValue, "Invalid timeout "+timeout, http.StatusInternalServerError)
+			return nil
+		}
+		cmd.Timeout = &minutes
+	}
+	return &cmd
+}
+
+//priorityGroup return the group of a priority array driver or group
+func (api *API) priorityGroup(array core.PriorityArray) int {
+	var group *int
+	switch array.Kind {
+	case core.PointGroup:
+		id, err := strconv.Atoi(array.ID)
+		if err == nil {
+			group = &id
+		}
+	case core.PointLed:
+		dr, _ := database.GetLedConfig(api.db, array.ID)
+		if dr != nil {
+			group = dr.Group
+		}
+	case core.PointBlind:
+		dr, _ := database.GetBlindConfig(api.db, array.ID)
+		if dr != nil {
+			group = dr.Group
+		}
+	case core.PointHvac:
+		dr, _ := database.GetHvacConfig(api.db, array.ID)
+		if dr != nil {
+			group = dr.Group
+		}
+	}
+	if group == nil {
+		return 0
+	}
+	return *group
+}
+
+func (api *API) getPriority(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	params := mux.Vars(req)
+	point := core.PointName(params["kind"], params["id"])
+	array, _ := database.GetPriorityArray(api.db, point)
+	if array == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Priority array "+point+" not found", http.StatusInternalServerError)
+		return
+	}
+	if api.hasEnoughRight(w, req, api.priorityGroup(*array)) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	json.NewEncoder(w).Encode(array)
+}
+
+func (api *API) getPriorities(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	arrays := []core.PriorityArray{}
+	for _, array := range database.GetPriorityArrays(api.db) {
+		if api.hasEnoughRight(w, req, api.priorityGroup(array)) != nil {
+			continue
+		}
+		arrays = append(arrays, array)
+	}
+	inrec, _ := json.MarshalIndent(arrays, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) relinquishPriority(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	params := mux.Vars(req)
+	point := core.PointName(params["kind"], params["id"])
+	level := params["level"]
+	array, _ := database.GetPriorityArray(api.db, point)
+	if array == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Priority array "+point+" not found", http.StatusInternalServerError)
+		return
+	}
+	if api.hasEnoughRight(w, req, api.priorityGroup(*array)) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	if level != core.PriorityManual && api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	found := false
+	for _, cmd := range array.Commands {
+		if cmd.Level == level {
+			found = true
+		}
+	}
+	if !found {
+		api.sendError(w, APIErrorDeviceNotFound, "Priority "+level+" not set on "+point, http.StatusInternalServerError)
+		return
+	}
+	event := make(map[string]interface{})
+	event["relinquishCmd"] = core.PriorityRelease{
+		Point: point,
+		Level: level,
+	}
+	api.sendToBackend(req, event)
+	w.Write([]byte("{}"))
+}
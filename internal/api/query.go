@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+)
+
+//driverQuery pagination, sorting, projection and filters of the status and dump requests
+type driverQuery struct {
+	filter      database.DriverFilter
+	labels      []interface{} //dumped labels, all the drivers when empty
+	macs        []interface{} //dumped macs, all the drivers when empty
+	labelPrefix string
+	inError     *bool //drivers reporting an error or not
+	sort        string
+	descending  bool
+	offset      int
+	limit       int      //0 for all the drivers
+	fields      []string //dotted paths of the driver fields, all the fields when empty
+}
+
+//sortKeys database keys of the sort parameters
+var sortKeys = map[string]string{
+	SortMac:       "Mac",
+	SortLabel:     "Label",
+	SortGroup:     "Group",
+	SortSwitchMac: "SwitchMac",
+	SortError:     "Error",
+}
+
+func queryInt(req *http.Request, name string) (*int, error) {
+	value := req.FormValue(name)
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return nil, NewError("Invalid " + name + " " + value)
+	}
+	return &i, nil
+}
+
+//getDriverQuery read the query parameters, the drivers are sorted by defaultSort
+func (api *API) getDriverQuery(req *http.Request, defaultSort string) (*driverQuery, error) {
+	q := driverQuery{
+		labelPrefix: req.FormValue("labelPrefix"),
+		sort:        defaultSort,
+	}
+	q.filter.SwitchMac = strings.ToUpper(req.FormValue("switchMac"))
+
+	cluster, err := queryInt(req, "cluster")
+	if err != nil {
+		return nil, err
+	}
+	q.filter.Cluster = cluster
+	offset, err := queryInt(req, "offset")
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil {
+		q.offset = *offset
+	}
+	limit, err := queryInt(req, "limit")
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil {
+		q.limit = *limit
+	}
+
+	inError := req.FormValue("error")
+	if inError != "" {
+		b, err := strconv.ParseBool(inError)
+		if err != nil {
+			return nil, NewError("Invalid error " + inError)
+		}
+		q.inError = &b
+	}
+
+	sortField := req.FormValue("sort")
+	if sortField != "" {
+		q.descending = strings.HasPrefix(sortField, "-")
+		q.sort = strings.TrimPrefix(sortField, "-")
+		switch q.sort {
+		case SortMac, SortLabel, SortGroup, SortSwitchMac, SortError:
+		default:
+			return nil, NewError("Invalid sort " + sortField)
+		}
+	}
+
+	fields := req.FormValue("fields")
+	if fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				q.fields = append(q.fields, field)
+			}
+		}
+	}
+	return &q, nil
+}
+
+//dbPage return the page of the query run by the database
+func (q driverQuery) dbPage() database.Page {
+	p := database.Page{
+		In:          make(map[string][]interface{}),
+		LabelPrefix: q.labelPrefix,
+		InError:     q.inError,
+		Sort:        sortKeys[q.sort],
+		Descending:  q.descending,
+		Offset:      q.offset,
+		Limit:       q.limit,
+	}
+	if len(q.labels) > 0 {
+		p.In["Label"] = q.labels
+	}
+	if len(q.macs) > 0 {
+		p.In["Mac"] = q.macs
+	}
+	return p
+}
+
+//groupsPage return the page of the query restricted to the drivers of the groups
+func (q driverQuery) groupsPage(groups []int) database.Page {
+	p := q.dbPage()
+	in := []interface{}{}
+	for _, group := range groups {
+		in = append(in, group)
+	}
+	p.In["Group"] = in
+	return p
+}
+
+//userPage return the page of the query, a user only reads the drivers of its access groups
+func (q driverQuery) userPage(auth duser.UserAccess) database.Page {
+	if auth.Priviledge == duser.PriviledgeUser {
+		return q.groupsPage(auth.AccessGroups)
+	}
+	return q.dbPage()
+}
+
+//projectFields keep the selected dotted paths of a JSON object
+func projectFields(elt map[string]interface{}, fields []string) map[string]interface{} {
+	res := make(map[string]interface{})
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		src := elt
+		dst := res
+		for i, key := range path {
+			val, ok := src[key]
+			if !ok {
+				break
+			}
+			if i == len(path)-1 {
+				dst[key] = val
+				break
+			}
+			next, ok := val.(map[string]interface{})
+			if !ok {
+				break
+			}
+			sub, ok := dst[key].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				dst[key] = sub
+			}
+			src = next
+			dst = sub
+		}
+	}
+	return res
+}
+
+//writeDrivers answer the drivers lists, restricted to the selected fields
+func (q driverQuery) writeDrivers(w http.ResponseWriter, doc interface{}, lists []string) {
+	if len(q.fields) == 0 {
+		inrec, _ := json.MarshalIndent(doc, "", "  ")
+		w.Write(inrec)
+		return
+	}
+	var values map[string]interface{}
+	inrec, _ := json.Marshal(doc)
+	json.Unmarshal(inrec, &values)
+	for _, list := range lists {
+		elts, ok := values[list].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, elt := range elts {
+			if obj, ok := elt.(map[string]interface{}); ok {
+				elts[i] = projectFields(obj, q.fields)
+			}
+		}
+	}
+	inrec, _ = json.MarshalIndent(values, "", "  ")
+	w.Write(inrec)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readRule(w http.ResponseWriter, name string) {
+	rule, _ := database.GetRule(api.db, name)
+	if rule == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Rule "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (api *API) getRuleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	api.readRule(w, params["name"])
+}
+
+func (api *API) getRulesSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	rules := database.GetRules(api.db)
+	if rules == nil {
+		rules = []core.Rule{}
+	}
+	inrec, _ := json.MarshalIndent(rules, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeRuleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+	res := database.RemoveRule(api.db, name)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Rule "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	api.reloadRules()
+	w.Write([]byte("{}"))
+}
+
+//reloadRules ask the service to read the rules again
+func (api *API) reloadRules() {
+	event := make(map[string]interface{})
+	event["rules"] = true
+	api.EventsToBackend <- event
+}
+
+func (api *API) setRuleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	rule := core.Rule{}
+	err = json.Unmarshal(body, &rule)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rule.Name == "" {
+		api.sendError(w, APIErrorInvalidValue, "Rule name is missing", http.StatusInternalServerError)
+		return
+	}
+	err = rule.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid rule "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = database.SaveRule(api.db, rule)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Rule "+rule.Name+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Rule " + rule.Name + " saved")
+	api.reloadRules()
+	api.readRule(w, rule.Name)
+}
+
+func (api *API) evaluateRule(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	rule := core.Rule{}
+	err = json.Unmarshal(body, &rule)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(rule.Conditions) == 0 && rule.Name != "" {
+		stored, _ := database.GetRule(api.db, rule.Name)
+		if stored == nil {
+			api.sendError(w, APIErrorDeviceNotFound, "Rule "+rule.Name+" not found", http.StatusInternalServerError)
+			return
+		}
+		rule = *stored
+	}
+	err = rule.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid rule "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := rule.DryRun(database.GetRuleValues(api.db))
+	inrec, _ := json.MarshalIndent(res, "", "  ")
+	w.Write(inrec)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+//hasSceneRight check the user access to every group of the scene
+func (api *API) hasSceneRight(w http.ResponseWriter, req *http.Request, scene core.Scene) error {
+	for _, cmd := range scene.Groups {
+		err := api.hasEnoughRight(w, req, cmd.Group)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (api *API) readScene(w http.ResponseWriter, name string) {
+	scene, _ := database.GetScene(api.db, name)
+	if scene == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Scene "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(scene)
+}
+
+func (api *API) getSceneSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	params := mux.Vars(req)
+	name := params["name"]
+	scene, _ := database.GetScene(api.db, name)
+	if scene == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Scene "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	if api.hasSceneRight(w, req, *scene) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	json.NewEncoder(w).Encode(scene)
+}
+
+func (api *API) getScenesSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	scenes := []core.Scene{}
+	for _, scene := range database.GetScenes(api.db) {
+		if api.hasSceneRight(w, req, scene) != nil {
+			continue
+		}
+		scenes = append(scenes, scene)
+	}
+	inrec, _ := json.MarshalIndent(scenes, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeSceneSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+	res := database.RemoveScene(api.db, name)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Scene "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) setSceneSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	scene := core.Scene{}
+	err = json.Unmarshal(body, &scene)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if scene.Name == "" {
+		api.sendError(w, APIErrorInvalidValue, "Scene name is missing", http.StatusInternalServerError)
+		return
+	}
+	err = scene.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid scene "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = database.SaveScene(api.db, scene)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Scene "+scene.Name+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Scene " + scene.Name + " saved")
+	api.readScene(w, scene.Name)
+}
+
+func (api *API) sendSceneCommand(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	params := mux.Vars(req)
+	name := params["name"]
+	scene, _ := database.GetScene(api.db, name)
+	if scene == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Scene "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	if api.hasSceneRight(w, req, *scene) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	priority := api.commandPriority(w, req)
+	if priority == nil {
+		return
+	}
+	for _, cmd := range scene.Groups {
+		groupCmd := cmd
+		priority.Group = &groupCmd
+		event := make(map[string]interface{})
+		event["priorityCmd"] = *priority
+		api.sendToBackend(req, event)
+	}
+	rlog.Info("Scene " + name + " recalled")
+	w.Write([]byte("{}"))
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readSchedule(w http.ResponseWriter, grID int) {
+	schedule, _ := database.GetSchedule(api.db, grID)
+	if schedule == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Schedule of group "+strconv.Itoa(grID)+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(schedule)
+}
+
+func (api *API) getScheduleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	grID := params["groupID"]
+	i, err := strconv.Atoi(grID)
+	if err != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Group "+grID+" not found", http.StatusInternalServerError)
+		return
+	}
+	api.readSchedule(w, i)
+}
+
+func (api *API) getSchedulesSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	schedules := database.GetSchedules(api.db)
+	if schedules == nil {
+		schedules = []core.Schedule{}
+	}
+	inrec, _ := json.MarshalIndent(schedules, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeScheduleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	grID := params["groupID"]
+	i, err := strconv.Atoi(grID)
+	if err != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Group "+grID+" not found", http.StatusInternalServerError)
+		return
+	}
+	res := database.RemoveSchedule(api.db, i)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Schedule of group "+grID+" not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) setScheduleSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	schedule := core.Schedule{}
+	err = json.Unmarshal(body, &schedule)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = schedule.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid schedule "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = database.SaveSchedule(api.db, schedule)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Schedule of group "+strconv.Itoa(schedule.Group)+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Schedule of group " + strconv.Itoa(schedule.Group) + " saved")
+	api.readSchedule(w, schedule.Group)
+}
@@ -64,7 +64,7 @@ func (api *API) setSensorSetup(w http.ResponseWriter, req *http.Request) {
 	sensor.Mac = strings.ToUpper(sensor.Mac)
 	event := make(map[string]interface{})
 	event["sensorSetup"] = sensor
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -106,12 +106,12 @@ func (api *API) setSensorConfig(w http.ResponseWriter, req *http.Request) {
 			}
 			eventGr := make(map[string]interface{})
 			eventGr["group"] = group
-			api.EventsToBackend <- eventGr
+			api.sendToBackend(req, eventGr)
 		}
 	}
 	event := make(map[string]interface{})
 	event["sensor"] = sensor
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
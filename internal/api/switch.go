@@ -67,7 +67,7 @@ func (api *API) setSwitchConfig(w http.ResponseWriter, req *http.Request) {
 	}
 	event := make(map[string]interface{})
 	event["switch"] = device
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -85,5 +85,12 @@ func (api *API) removeSwitchSetup(w http.ResponseWriter, req *http.Request) {
 		api.sendError(w, APIErrorDeviceNotFound, "Switch "+mac+" not found", http.StatusInternalServerError)
 		return
 	}
+	//the last status of an offline switch is kept until it is removed
+	database.RemoveOfflineSwitch(api.db, mac)
+	database.RemoveSwitchStatus(api.db, mac)
+	database.RemoveSwitchLedStatus(api.db, mac)
+	database.RemoveSwitchBlindStatus(api.db, mac)
+	database.RemoveSwitchSensorStatus(api.db, mac)
+	database.RemoveSwitchHvacStatus(api.db, mac)
 	w.Write([]byte("{}"))
 }
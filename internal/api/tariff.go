@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readTariff(w http.ResponseWriter, label string) {
+	tariff, _ := database.GetTariff(api.db, label)
+	if tariff == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Tariff "+label+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tariff)
+}
+
+func (api *API) getTariffSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	api.readTariff(w, params["label"])
+}
+
+func (api *API) getTariffsSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin, duser.PriviledgeMaintainer}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	tariffs := database.GetTariffs(api.db)
+	if tariffs == nil {
+		tariffs = []core.Tariff{}
+	}
+	inrec, _ := json.MarshalIndent(tariffs, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeTariffSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	label := params["label"]
+	res := database.RemoveTariff(api.db, label)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Tariff "+label+" not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) setTariffSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	tariff := core.Tariff{}
+	err = json.Unmarshal(body, &tariff)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tariff.Label == "" {
+		api.sendError(w, APIErrorInvalidValue, "Tariff label is missing", http.StatusInternalServerError)
+		return
+	}
+	err = tariff.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid tariff "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = database.SaveTariff(api.db, tariff)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Tariff "+tariff.Label+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Tariff " + tariff.Label + " saved")
+	api.readTariff(w, tariff.Label)
+}
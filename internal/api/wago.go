@@ -55,7 +55,7 @@ func (api *API) setWagoSetup(w http.ResponseWriter, req *http.Request) {
 	wago.Mac = strings.ToUpper(wago.Mac)
 	event := make(map[string]interface{})
 	event["wagoSetup"] = wago
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
@@ -81,7 +81,7 @@ func (api *API) setWagoConfig(w http.ResponseWriter, req *http.Request) {
 	wago.Mac = strings.ToUpper(wago.Mac)
 	event := make(map[string]interface{})
 	event["wago"] = wago
-	api.EventsToBackend <- event
+	api.sendToBackend(req, event)
 	w.Write([]byte("{}"))
 }
 
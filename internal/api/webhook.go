@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/gorilla/mux"
+	"github.com/romana/rlog"
+)
+
+func (api *API) readWebhook(w http.ResponseWriter, name string) {
+	webhook, _ := database.GetWebhook(api.db, name)
+	if webhook == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Webhook "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(webhook.Masked())
+}
+
+func (api *API) getWebhookSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	api.readWebhook(w, params["name"])
+}
+
+func (api *API) getWebhooksSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	webhooks := []core.Webhook{}
+	for _, webhook := range database.GetWebhooks(api.db) {
+		webhooks = append(webhooks, webhook.Masked())
+	}
+	inrec, _ := json.MarshalIndent(webhooks, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) removeWebhookSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+	res := database.RemoveWebhook(api.db, name)
+	if res != nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Webhook "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (api *API) setWebhookSetup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+
+	webhook := core.Webhook{}
+	err = json.Unmarshal(body, &webhook)
+	if err != nil {
+		api.sendError(w, APIErrorBodyParsing, "Could not parse input format "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if webhook.Name == "" {
+		api.sendError(w, APIErrorInvalidValue, "Webhook name is missing", http.StatusInternalServerError)
+		return
+	}
+	err = webhook.Validate()
+	if err != nil {
+		api.sendError(w, APIErrorInvalidValue, "Invalid webhook "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if webhook.Secret == core.WebhookSecretMask {
+		//the webhook read back from the API: keep the stored secret
+		webhook.Secret = ""
+		stored, _ := database.GetWebhook(api.db, webhook.Name)
+		if stored != nil {
+			webhook.Secret = stored.Secret
+		}
+	}
+
+	api.audit(api.getAuditOrigin(req), "webhook", webhook.Masked())
+	err = database.SaveWebhook(api.db, webhook)
+	if err != nil {
+		api.sendError(w, APIErrorDatabase, "Webhook "+webhook.Name+" cannot be saved in database", http.StatusInternalServerError)
+		return
+	}
+	rlog.Info("Webhook " + webhook.Name + " saved")
+	api.readWebhook(w, webhook.Name)
+}
+
+func (api *API) getWebhookDeliveries(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	deliveries := database.GetWebhookDeliveries(api.db, params["name"])
+	inrec, _ := json.MarshalIndent(deliveries, "", "  ")
+	w.Write(inrec)
+}
+
+func (api *API) testWebhook(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+	defer req.Body.Close()
+	if api.hasAccessMode(w, req, []string{duser.PriviledgeAdmin}) != nil {
+		api.sendError(w, APIErrorUnauthorized, "Unauthorized Access", http.StatusUnauthorized)
+		return
+	}
+	params := mux.Vars(req)
+	name := params["name"]
+	webhook, _ := database.GetWebhook(api.db, name)
+	if webhook == nil {
+		api.sendError(w, APIErrorDeviceNotFound, "Webhook "+name+" not found", http.StatusInternalServerError)
+		return
+	}
+	event := make(map[string]interface{})
+	event["webhookTest"] = name
+	api.sendToBackend(req, event)
+	w.Write([]byte("{}"))
+}
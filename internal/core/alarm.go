@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AlarmSeverityCritical = "critical"
+	AlarmSeverityMajor    = "major"
+	AlarmSeverityMinor    = "minor"
+
+	AlarmSwitchTimeout = "switchTimeout"
+	AlarmDriverLost    = "driverLost"
+	AlarmDriverError   = "driverError"
+	AlarmHvacError     = "hvacError"
+)
+
+//Alarm fault raised on a switch or a driver, it stays in the fault list until
+//it is cleared and acknowledged
+type Alarm struct {
+	ID           string `json:"id,omitempty"`
+	Type         string `json:"type"`
+	Severity     string `json:"severity"`
+	Mac          string `json:"mac"`
+	Label        string `json:"label"`
+	Group        int    `json:"group"`
+	Message      string `json:"message"`
+	Active       bool   `json:"active"` //raised and not cleared
+	Acknowledged bool   `json:"acknowledged"`
+	AckBy        string `json:"ackBy"`   //user hash
+	Raised       string `json:"raised"`  //first occurrence date (RFC3339 UTC)
+	Cleared      string `json:"cleared"` //last clear date
+	AckDate      string `json:"ackDate"`
+	Occurrences  int    `json:"occurrences"` //raised again before being closed
+}
+
+//AlarmFilter alarms list request parameters
+type AlarmFilter struct {
+	Active       *bool
+	Acknowledged *bool
+	Severity     string
+	Type         string
+	Mac          string
+	From         *time.Time //raised after
+	To           *time.Time //raised before
+}
+
+//AlarmKey return the key of the alarm type on a device
+func AlarmKey(alarmType, mac string) string {
+	return alarmType + "/" + mac
+}
+
+//ToAlarm convert map interface to Alarm object
+func ToAlarm(val interface{}) (*Alarm, error) {
+	var a Alarm
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &a)
+	return &a, err
+}
+
+// ToJSON dump Alarm struct
+func (a Alarm) ToJSON() (string, error) {
+	inrec, err := json.Marshal(a)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//Key return the alarm key
+func (a Alarm) Key() string {
+	return AlarmKey(a.Type, a.Mac)
+}
+
+//Closed check if the alarm is cleared and acknowledged
+func (a Alarm) Closed() bool {
+	return !a.Active && a.Acknowledged
+}
+
+//Match check the filter criteria
+func (f AlarmFilter) Match(a Alarm) bool {
+	if f.Active != nil && *f.Active != a.Active {
+		return false
+	}
+	if f.Acknowledged != nil && *f.Acknowledged != a.Acknowledged {
+		return false
+	}
+	if f.Severity != "" && f.Severity != a.Severity {
+		return false
+	}
+	if f.Type != "" && f.Type != a.Type {
+		return false
+	}
+	if f.Mac != "" && f.Mac != a.Mac {
+		return false
+	}
+	if f.From != nil || f.To != nil {
+		date, err := time.Parse(time.RFC3339, a.Raised)
+		if err != nil {
+			return false
+		}
+		if f.From != nil && date.Before(*f.From) {
+			return false
+		}
+		if f.To != nil && !date.Before(*f.To) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+const (
+	ConfigQueued   = "queued" //valid item sent to the service, which applies the batch asynchronously
+	ConfigRejected = "rejected"
+	ConfigAborted  = "aborted" //valid item not sent as the batch holds a rejected item in validateAll mode
+)
+
+//ConfigResult outcome of an item of a configuration batch
+type ConfigResult struct {
+	Type   string `json:"type"`  //led, sensor, group, switch, blind, hvac, wago or nano
+	Index  int    `json:"index"` //position in the list of its type, -1 for the groups created for the drivers
+	ID     string `json:"id"`    //mac, label or group
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+//ToConf convert map interface to Conf object
+func ToConf(val interface{}) (*dserver.Conf, error) {
+	var c dserver.Conf
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &c)
+	return &c, err
+}
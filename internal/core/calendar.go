@@ -0,0 +1,182 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	//DateFormat calendar day format
+	DateFormat = "2006-01-02"
+
+	CalendarModeSuppress = "suppress" //no scheduled action during the exception
+	CalendarModeOverride = "override" //the exception actions replace the weekly ones
+)
+
+//CalendarException days overriding or suppressing the weekly schedules
+type CalendarException struct {
+	Label   string           `json:"label"`
+	Start   string           `json:"start"`             //first day (YYYY-MM-DD)
+	End     string           `json:"end,omitempty"`     //last day included (YYYY-MM-DD), single day when empty
+	Annual  bool             `json:"annual"`            //recurring every year, the year is ignored
+	Mode    string           `json:"mode"`              //suppress or override
+	Actions []ScheduleAction `json:"actions,omitempty"` //actions of an override exception, their days are ignored
+}
+
+//Calendar exceptions applied to some groups or to every group
+type Calendar struct {
+	Name       string              `json:"name"`
+	Groups     []int               `json:"groups,omitempty"` //every group when empty
+	Exceptions []CalendarException `json:"exceptions"`
+}
+
+//SchedulePreview actions applied to a group during one day
+type SchedulePreview struct {
+	Group     int              `json:"group"`
+	Date      string           `json:"date"`                //YYYY-MM-DD
+	Calendar  string           `json:"calendar,omitempty"`  //calendar of the applied exception
+	Exception string           `json:"exception,omitempty"` //label of the applied exception
+	Actions   []ScheduleAction `json:"actions"`
+}
+
+// ToJSON dump Calendar struct
+func (c Calendar) ToJSON() (string, error) {
+	inrec, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToCalendar convert map interface to Calendar object
+func ToCalendar(val interface{}) (*Calendar, error) {
+	var c Calendar
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &c)
+	return &c, err
+}
+
+//Validate check the exceptions dates and actions
+func (c Calendar) Validate() error {
+	for _, e := range c.Exceptions {
+		start, err := time.Parse(DateFormat, e.Start)
+		if err != nil {
+			return err
+		}
+		if e.End != "" {
+			end, err := time.Parse(DateFormat, e.End)
+			if err != nil {
+				return err
+			}
+			if !e.Annual && end.Before(start) {
+				return errors.New("Exception " + e.Label + " ends before its start")
+			}
+		}
+		switch e.Mode {
+		case CalendarModeSuppress:
+		case CalendarModeOverride:
+			err = Schedule{Actions: e.Actions}.Validate()
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New("Invalid exception mode " + e.Mode)
+		}
+	}
+	return nil
+}
+
+//AppliesTo check if the calendar is used by the group
+func (c Calendar) AppliesTo(group int) bool {
+	if len(c.Groups) == 0 {
+		return true
+	}
+	for _, gr := range c.Groups {
+		if gr == group {
+			return true
+		}
+	}
+	return false
+}
+
+//Contains check if the day is in the exception
+func (e CalendarException) Contains(day time.Time) bool {
+	start, err := time.Parse(DateFormat, e.Start)
+	if err != nil {
+		return false
+	}
+	end := start
+	if e.End != "" {
+		end, err = time.Parse(DateFormat, e.End)
+		if err != nil {
+			return false
+		}
+	}
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if !e.Annual {
+		return !date.Before(start) && !date.After(end)
+	}
+	for _, year := range []int{date.Year() - 1, date.Year()} {
+		first := time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		last := time.Date(year, end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+		if last.Before(first) {
+			//over the new year
+			last = last.AddDate(1, 0, 0)
+		}
+		if !date.Before(first) && !date.After(last) {
+			return true
+		}
+	}
+	return false
+}
+
+//getException return the exception applied to the group during the day,
+//the group calendars have precedence over the global ones
+func getException(group int, day time.Time, calendars []Calendar) (*Calendar, *CalendarException) {
+	var global *Calendar
+	var globalException *CalendarException
+	for i, c := range calendars {
+		if !c.AppliesTo(group) {
+			continue
+		}
+		for j, e := range c.Exceptions {
+			if !e.Contains(day) {
+				continue
+			}
+			if len(c.Groups) > 0 {
+				return &calendars[i], &calendars[i].Exceptions[j]
+			}
+			if global == nil {
+				global = &calendars[i]
+				globalException = &calendars[i].Exceptions[j]
+			}
+		}
+	}
+	return global, globalException
+}
+
+//ScheduledGroups return the group schedules completed with an empty weekly schedule
+//for the groups only listed in a calendar
+func ScheduledGroups(schedules []Schedule, calendars []Calendar) []Schedule {
+	res := append([]Schedule{}, schedules...)
+	seen := make(map[int]bool)
+	for _, s := range schedules {
+		seen[s.Group] = true
+	}
+	for _, c := range calendars {
+		for _, gr := range c.Groups {
+			if seen[gr] {
+				continue
+			}
+			seen[gr] = true
+			res = append(res, Schedule{
+				Group: gr,
+			})
+		}
+	}
+	return res
+}
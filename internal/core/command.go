@@ -0,0 +1,148 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dblind"
+	"github.com/energieip/common-components-go/pkg/dhvac"
+	dl "github.com/energieip/common-components-go/pkg/dled"
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+const (
+	CommandPending = "pending"
+	CommandApplied = "applied"
+	CommandTimeout = "timeout"
+	CommandFailed  = "failed"
+
+	CommandAckTimeout = time.Minute //delay for the switch dump to report the command
+)
+
+//CommandAck acknowledgement of a driver command, applied once a switch dump reports the setpoint
+type CommandAck struct {
+	ID       string            `json:"id,omitempty"`
+	Point    string            `json:"point"` //kind/mac of the commanded driver
+	Level    string            `json:"level"`
+	Source   string            `json:"source"`
+	Status   string            `json:"status"`   //pending, applied, timeout or failed
+	Date     string            `json:"date"`     //request date (RFC3339 UTC)
+	Deadline string            `json:"deadline"` //timeout date when pending
+	Updated  string            `json:"updated"`  //last status change
+	Error    string            `json:"error,omitempty"`
+	Led      *dserver.LedCmd   `json:"led,omitempty"`
+	Blind    *dserver.BlindCmd `json:"blind,omitempty"`
+	Hvac     *dserver.HvacCmd  `json:"hvac,omitempty"`
+	Expected *PriorityCommand  `json:"expected,omitempty"` //command sent to the switch, merged with the lower levels
+}
+
+//BulkTarget drivers addressed by a bulk command, one of the fields is set
+type BulkTarget struct {
+	Mac   string `json:"mac,omitempty"`
+	Label string `json:"label,omitempty"` //cable label
+	Group *int   `json:"group,omitempty"`
+}
+
+//BulkCommand setpoints by driver type sent to the drivers of the targets, the drivers without setpoint are ignored
+type BulkCommand struct {
+	Targets []BulkTarget      `json:"targets"`
+	Led     *dserver.LedCmd   `json:"led,omitempty"` //the mac is ignored
+	Blind   *dserver.BlindCmd `json:"blind,omitempty"`
+	Hvac    *dserver.HvacCmd  `json:"hvac,omitempty"`
+}
+
+//BulkDriverResult command of a driver addressed by a bulk command
+type BulkDriverResult struct {
+	Mac   string      `json:"mac"`
+	Type  string      `json:"type"` //led, blind or hvac
+	Ack   *CommandAck `json:"ack,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+//BulkTargetResult drivers commanded for a bulk command target
+type BulkTargetResult struct {
+	Target  BulkTarget         `json:"target"`
+	Drivers []BulkDriverResult `json:"drivers"`
+	Error   string             `json:"error,omitempty"`
+}
+
+//ToCommandAck convert map interface to CommandAck object
+func ToCommandAck(val interface{}) (*CommandAck, error) {
+	var c CommandAck
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &c)
+	return &c, err
+}
+
+//IsPending check if the command is not resolved yet
+func (c CommandAck) IsPending() bool {
+	return c.Status == CommandPending
+}
+
+//MatchLed check if the led status reports the expected command
+func (c CommandAck) MatchLed(led dl.Led) bool {
+	if c.Expected == nil || c.Expected.Led == nil {
+		return false
+	}
+	cmd := c.Expected.Led
+	if cmd.Auto {
+		return led.Auto
+	}
+	return !led.Auto && led.Setpoint == cmd.Setpoint
+}
+
+//MatchBlind check if the blind status reports the expected positions
+func (c CommandAck) MatchBlind(blind dblind.Blind) bool {
+	if c.Expected == nil || c.Expected.Blind == nil {
+		return false
+	}
+	cmd := c.Expected.Blind
+	for _, pos := range []struct {
+		expected *int
+		value    int
+	}{
+		{cmd.Blind1, blind.Blind1},
+		{cmd.Blind2, blind.Blind2},
+		{cmd.Slat1, blind.Slat1},
+		{cmd.Slat2, blind.Slat2},
+	} {
+		if pos.expected != nil && *pos.expected != pos.value {
+			return false
+		}
+	}
+	return true
+}
+
+//MatchHvac check if the hvac status reports the expected temperature shift
+func (c CommandAck) MatchHvac(hvac dhvac.Hvac) bool {
+	if c.Expected == nil || c.Expected.Hvac == nil {
+		return false
+	}
+	return hvac.Shift == c.Expected.Hvac.ShiftTemp
+}
+
+//Validate check that targets and setpoints are set
+func (c BulkCommand) Validate() error {
+	if len(c.Targets) == 0 {
+		return errors.New("No target")
+	}
+	if c.Led == nil && c.Blind == nil && c.Hvac == nil {
+		return errors.New("No setpoint")
+	}
+	for _, target := range c.Targets {
+		count := 0
+		for _, set := range []bool{target.Mac != "", target.Label != "", target.Group != nil} {
+			if set {
+				count++
+			}
+		}
+		if count != 1 {
+			return errors.New("A target is addressed by a single mac, label or group")
+		}
+	}
+	return nil
+}
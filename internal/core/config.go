@@ -0,0 +1,174 @@
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	DefaultRawRetention    = 7   //days
+	DefaultHourlyRetention = 90  //days
+	DefaultDailyRetention  = 730 //days
+
+	DefaultEnvironmentRetention = 365 //days
+
+	DefaultCO2Threshold = 1000 //ppm
+	DefaultCOVThreshold = 1    //ppm
+
+	DefaultDiscrepancyThreshold = 10 //percent
+
+	DefaultManualTimeout = 120 //minutes
+
+	DefaultSMTPPort    = 25
+	DefaultSummaryTime = "07:00"
+)
+
+//HistoryConfig history retention in days, 0 to keep the entries forever
+type HistoryConfig struct {
+	RawRetention         int `json:"rawRetention"`
+	HourlyRetention      int `json:"hourlyRetention"`
+	DailyRetention       int `json:"dailyRetention"`
+	EnvironmentRetention int `json:"environmentRetention"` //sensors and nanosenses measures
+}
+
+//AirQualityConfig exceedance thresholds of the nanosenses measures
+type AirQualityConfig struct {
+	CO2Threshold float64 `json:"co2Threshold"` //ppm
+	COVThreshold float64 `json:"covThreshold"` //ppm
+}
+
+//MeteringConfig switch metering checks
+type MeteringConfig struct {
+	DiscrepancyThreshold float64 `json:"discrepancyThreshold"` //accepted deviation between a switch and its drivers energy (percent)
+}
+
+//SiteConfig building location used by the astronomical clock
+type SiteConfig struct {
+	Latitude  float64 `json:"latitude"`  //degrees, north positive
+	Longitude float64 `json:"longitude"` //degrees, east positive
+	TimeZone  string  `json:"timeZone"`  //IANA name, the system time zone when empty
+}
+
+//PriorityConfig default duration of the commands per priority level in minutes, 0 to keep them until relinquished
+type PriorityConfig struct {
+	SafetyTimeout   int `json:"safetyTimeout"`
+	ManualTimeout   int `json:"manualTimeout"`
+	RuleTimeout     int `json:"ruleTimeout"`
+	ScheduleTimeout int `json:"scheduleTimeout"`
+}
+
+//MailConfig SMTP relay and recipients of the email notifications
+type MailConfig struct {
+	Host            string              `json:"host"` //emails are disabled when empty
+	Port            int                 `json:"port"`
+	TLS             bool                `json:"tls"`      //implicit TLS, STARTTLS is used when offered otherwise
+	Username        string              `json:"username"` //PLAIN authentication when set
+	Password        string              `json:"password"`
+	From            string              `json:"from"`
+	Recipients      map[string][]string `json:"recipients"`      //alarm severity: addresses
+	Summary         []string            `json:"summary"`         //daily summary addresses
+	SummaryTime     string              `json:"summaryTime"`     //HH:MM in the site time zone
+	AlarmTemplate   string              `json:"alarmTemplate"`   //template file path, the default template when empty
+	SummaryTemplate string              `json:"summaryTemplate"` //template file path, the default template when empty
+}
+
+//CoreConfig core service settings, stored in the "core" section of the service configuration file
+type CoreConfig struct {
+	History    HistoryConfig    `json:"history"`
+	AirQuality AirQualityConfig `json:"airQuality"`
+	Metering   MeteringConfig   `json:"metering"`
+	Site       SiteConfig       `json:"site"`
+	Priority   PriorityConfig   `json:"priority"`
+	Mail       MailConfig       `json:"mail"`
+}
+
+type configFile struct {
+	Core *CoreConfig `json:"core"`
+}
+
+//ReadCoreConfig parse the core settings of the configuration file
+func ReadCoreConfig(path string) (*CoreConfig, error) {
+	conf := CoreConfig{
+		History: HistoryConfig{
+			RawRetention:         DefaultRawRetention,
+			HourlyRetention:      DefaultHourlyRetention,
+			DailyRetention:       DefaultDailyRetention,
+			EnvironmentRetention: DefaultEnvironmentRetention,
+		},
+		AirQuality: AirQualityConfig{
+			CO2Threshold: DefaultCO2Threshold,
+			COVThreshold: DefaultCOVThreshold,
+		},
+		Metering: MeteringConfig{
+			DiscrepancyThreshold: DefaultDiscrepancyThreshold,
+		},
+		Priority: PriorityConfig{
+			ManualTimeout: DefaultManualTimeout,
+		},
+		Mail: MailConfig{
+			Port:        DefaultSMTPPort,
+			SummaryTime: DefaultSummaryTime,
+		},
+	}
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := configFile{
+		Core: &conf,
+	}
+	err = json.Unmarshal(file, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Site.TimeZone != "" {
+		_, err = time.LoadLocation(conf.Site.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = time.Parse("15:04", conf.Mail.SummaryTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range []string{MailTemplateAlarm, MailTemplateSummary} {
+		_, err = conf.Mail.Template(kind)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &conf, nil
+}
+
+//IsLocated check if the site coordinates are set
+func (s SiteConfig) IsLocated() bool {
+	return s.Latitude != 0 || s.Longitude != 0
+}
+
+//Location return the site time zone
+func (s SiteConfig) Location() *time.Location {
+	if s.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+//Timeout return the default duration of a priority level in minutes
+func (p PriorityConfig) Timeout(level string) int {
+	switch level {
+	case PrioritySafety:
+		return p.SafetyTimeout
+	case PriorityManual:
+		return p.ManualTimeout
+	case PriorityRule:
+		return p.RuleTimeout
+	case PrioritySchedule:
+		return p.ScheduleTimeout
+	}
+	return 0
+}
@@ -2,8 +2,27 @@ package core
 
 //EventConsumption
 type EventConsumption struct {
-	Leds   int    `json:"leds"`
-	Blinds int    `json:"blinds"`
-	Hvac   int    `json:"hvacs"`
-	Date   string `json:"date"`
+	Leds   int                `json:"leds"`
+	Blinds int                `json:"blinds"`
+	Hvac   int                `json:"hvacs"`
+	Date   string             `json:"date"`
+	Groups []GroupConsumption `json:"groups,omitempty"`
+	Zones  []ZoneConsumption  `json:"zones,omitempty"`
+}
+
+//GroupConsumption power consumed by the drivers of a group
+type GroupConsumption struct {
+	Group  int `json:"group"`
+	Leds   int `json:"leds"`
+	Blinds int `json:"blinds"`
+	Hvac   int `json:"hvacs"`
+}
+
+//ZoneConsumption power consumed by the drivers of a frame cluster
+type ZoneConsumption struct {
+	Cluster int      `json:"cluster"`
+	Frames  []string `json:"frames"` //labels of the cluster frames
+	Leds    int      `json:"leds"`
+	Blinds  int      `json:"blinds"`
+	Hvac    int      `json:"hvacs"`
 }
@@ -50,6 +50,25 @@ type EventSwitch struct {
 	Label  string               `json:"label"`
 }
 
+//OfflineSwitch switch without dump, the status of its drivers is kept and stale
+type OfflineSwitch struct {
+	Mac      string `json:"mac"`
+	Label    string `json:"label"`
+	LastSeen string `json:"lastSeen"` //last dump date (RFC3339 UTC), unreachable since
+	Since    string `json:"since"`    //timeout detection date
+}
+
+//ToOfflineSwitch convert map interface to OfflineSwitch object
+func ToOfflineSwitch(val interface{}) (*OfflineSwitch, error) {
+	var sw OfflineSwitch
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &sw)
+	return &sw, err
+}
+
 //EventStatus
 type EventStatus struct {
 	Leds    []EventLed       `json:"leds"`
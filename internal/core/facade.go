@@ -0,0 +1,154 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+)
+
+const (
+	DefaultFacadeTilt = 90  //degrees, vertical window
+	DefaultSlatRatio  = 1.0 //slat spacing / slat width
+
+	//blind setpoints, same values as the blind commands
+	BlindSetpointOpen  = 1
+	BlindSetpointClose = 2
+)
+
+//Facade window orientation of a blind or of a group of blinds
+type Facade struct {
+	Label       string   `json:"label,omitempty"`     //blind label, empty for a group facade
+	Group       *int     `json:"group,omitempty"`     //group of a group facade
+	Azimuth     *float64 `json:"azimuth,omitempty"`   //degrees from the north, clockwise, of the window normal; group facades use their blinds average when missing
+	Tilt        *float64 `json:"tilt,omitempty"`      //degrees from the horizontal, 90 for a vertical window
+	SlatRatio   *float64 `json:"slatRatio,omitempty"` //slat spacing / slat width
+	SunTracking bool     `json:"sunTracking"`         //group facade automation
+}
+
+//SunTrackingStatus blinds setpoints computed from the sun position
+type SunTrackingStatus struct {
+	Group          int     `json:"group"`
+	Azimuth        float64 `json:"azimuth"` //facade azimuth
+	Tilt           float64 `json:"tilt"`    //facade tilt
+	SunAzimuth     float64 `json:"sunAzimuth"`
+	SunElevation   float64 `json:"sunElevation"`
+	Exposed        bool    `json:"exposed"` //the sun shines on the facade
+	SetpointBlinds int     `json:"setpointBlinds"`
+	SetpointSlats  int     `json:"setpointSlats"` //degrees from the horizontal
+}
+
+// ToJSON dump Facade struct
+func (f Facade) ToJSON() (string, error) {
+	inrec, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToFacade convert map interface to Facade object
+func ToFacade(val interface{}) (*Facade, error) {
+	var f Facade
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &f)
+	return &f, err
+}
+
+//AverageAzimuth return the mean direction of the facades azimuths, nil without azimuth
+func AverageAzimuth(facades []Facade) *float64 {
+	x := 0.0
+	y := 0.0
+	count := 0
+	for _, f := range facades {
+		if f.Azimuth == nil {
+			continue
+		}
+		x += math.Cos(radians(*f.Azimuth))
+		y += math.Sin(radians(*f.Azimuth))
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	azimuth := math.Mod(degrees(math.Atan2(y, x))+360, 360)
+	return &azimuth
+}
+
+//GetSunTracking return the blinds setpoints cutting the direct sun while keeping the daylight:
+//the blinds are open when the sun does not shine on the facade, otherwise they are closed
+//with the smallest slat angle blocking the sun rays
+func (f Facade) GetSunTracking(sunAzimuth, sunElevation float64) SunTrackingStatus {
+	res := SunTrackingStatus{
+		SunAzimuth:     sunAzimuth,
+		SunElevation:   sunElevation,
+		SetpointBlinds: BlindSetpointOpen,
+		Tilt:           DefaultFacadeTilt,
+	}
+	if f.Group != nil {
+		res.Group = *f.Group
+	}
+	if f.Azimuth == nil {
+		return res
+	}
+	res.Azimuth = *f.Azimuth
+	if f.Tilt != nil {
+		res.Tilt = *f.Tilt
+	}
+	ratio := DefaultSlatRatio
+	if f.SlatRatio != nil && *f.SlatRatio > 0 {
+		ratio = *f.SlatRatio
+	}
+
+	gamma := radians(sunAzimuth - res.Azimuth)
+	el := radians(sunElevation)
+	incidence := math.Sin(el)*math.Cos(radians(res.Tilt)) + math.Cos(el)*math.Sin(radians(res.Tilt))*math.Cos(gamma)
+	if sunElevation <= 0 || incidence <= 0 {
+		return res
+	}
+	res.Exposed = true
+	res.SetpointBlinds = BlindSetpointClose
+
+	//profile angle of the sun rays in the plane normal to the facade
+	profile := math.Atan(math.Tan(el) / math.Abs(math.Cos(gamma)))
+	cutoff := math.Asin(math.Min(1, ratio*math.Cos(profile))) - profile
+	slat := int(math.Round(degrees(cutoff)))
+	if slat < 0 {
+		slat = 0
+	}
+	if slat > 90 {
+		slat = 90
+	}
+	res.SetpointSlats = slat
+	return res
+}
+
+//ResolveGroupFacades return the group facades, their missing azimuth is computed from the
+//facades of their blinds (blind label -> group)
+func ResolveGroupFacades(facades []Facade, blindGroups map[string]int) []Facade {
+	var res []Facade
+	blinds := make(map[int][]Facade)
+	for _, f := range facades {
+		if f.Label == "" {
+			continue
+		}
+		group, ok := blindGroups[f.Label]
+		if ok {
+			blinds[group] = append(blinds[group], f)
+		}
+	}
+	for _, f := range facades {
+		if f.Label != "" || f.Group == nil {
+			continue
+		}
+		if f.Azimuth == nil {
+			f.Azimuth = AverageAzimuth(blinds[*f.Group])
+		}
+		if f.Tilt == nil && len(blinds[*f.Group]) > 0 {
+			f.Tilt = blinds[*f.Group][0].Tilt
+		}
+		res = append(res, f)
+	}
+	return res
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	HealthOK    = "ok"
+	HealthError = "error"
+
+	LoopProbePeriod = 5 * time.Second
+	LoopMaxLag      = 30 * time.Second //a loop lagging more is considered stalled
+)
+
+//Health service health report
+type Health struct {
+	Status     string                `json:"status"` //ok or error
+	Started    string                `json:"started"`
+	Database   DatabaseHealth        `json:"db"`
+	HistoryDb  DatabaseHealth        `json:"historyDb"`
+	Server     BrokerHealth          `json:"server"`
+	AuthServer BrokerHealth          `json:"authServer"`
+	Websockets map[string]int        `json:"websockets"` //connected clients by websocket
+	Loops      map[string]LoopHealth `json:"loops"`
+}
+
+//DatabaseHealth database connectivity
+type DatabaseHealth struct {
+	Connected bool    `json:"connected"`
+	Latency   float64 `json:"latency"` //request duration (ms)
+	Error     string  `json:"error,omitempty"`
+}
+
+//BrokerHealth MQTT broker connection state
+type BrokerHealth struct {
+	Connected   bool   `json:"connected"`
+	Attempts    int    `json:"attempts"` //failed connections or sends since the last success
+	Since       string `json:"since"`    //date of the last state change
+	LastMessage string `json:"lastMessage"`
+	Error       string `json:"error,omitempty"`
+}
+
+//LoopHealth event loop lag
+type LoopHealth struct {
+	Lag     float64 `json:"lag"` //time taken by the loop to read a probe (ms)
+	Stalled bool    `json:"stalled"`
+}
+
+//LoopProbe measure the lag of an event loop, the loop reads Probe in its select
+type LoopProbe struct {
+	Probe   chan time.Time
+	mutex   sync.Mutex
+	pending time.Time //date of the probe not read yet
+	lag     time.Duration
+}
+
+//NewLoopProbe create a loop probe
+func NewLoopProbe() *LoopProbe {
+	return &LoopProbe{
+		Probe: make(chan time.Time),
+	}
+}
+
+//Run send a probe periodically and measure how long the loop takes to read it
+func (p *LoopProbe) Run(period time.Duration) {
+	for {
+		start := time.Now()
+		p.mutex.Lock()
+		p.pending = start
+		p.mutex.Unlock()
+
+		p.Probe <- start
+
+		p.mutex.Lock()
+		p.lag = time.Since(start)
+		p.pending = time.Time{}
+		p.mutex.Unlock()
+		time.Sleep(period)
+	}
+}
+
+//Health return the last measured lag, or the age of the pending probe when it is longer
+func (p *LoopProbe) Health() LoopHealth {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	lag := p.lag
+	if !p.pending.IsZero() && time.Since(p.pending) > lag {
+		lag = time.Since(p.pending)
+	}
+	return LoopHealth{
+		Lag:     float64(lag) / float64(time.Millisecond),
+		Stalled: lag > LoopMaxLag,
+	}
+}
+
+//Alive check that no event loop is stalled
+func (h Health) Alive() bool {
+	for _, loop := range h.Loops {
+		if loop.Stalled {
+			return false
+		}
+	}
+	return true
+}
+
+//Ready check that the service is alive and connected to its databases and brokers
+func (h Health) Ready() bool {
+	return h.Alive() && h.Database.Connected && h.HistoryDb.Connected &&
+		h.Server.Connected && h.AuthServer.Connected
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icsDateFormat     = "20060102"
+	icsDateTimeFormat = "20060102T150405"
+
+	//maxICSOccurrences maximum number of exceptions created for a repeated event
+	maxICSOccurrences = 1000
+)
+
+var icsWeekDays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+//icsVEvent VEVENT dates, the days are in the site location
+type icsVEvent struct {
+	label   string
+	start   *time.Time
+	end     *time.Time //last day included
+	rrule   string
+	exdates map[string]bool
+}
+
+//icsRule parsed RRULE
+type icsRule struct {
+	freq     string
+	interval int
+	count    int
+	until    *time.Time
+	days     map[time.Weekday]bool
+}
+
+//unfoldICS return the iCalendar content lines, the folded lines are joined
+func unfoldICS(data string) []string {
+	var lines []string
+	data = strings.Replace(data, "\r\n", "\n", -1)
+	for _, line := range strings.Split(data, "\n") {
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+//parseICSDate return the day in the site location of a DATE or DATE-TIME value and if the value is midnight or a plain date.
+//A UTC time (Z suffix) or a time of the TZID zone is converted to the site location, a floating time is already local
+func parseICSDate(value, tzid string, loc *time.Location) (time.Time, bool, error) {
+	if len(value) == len(icsDateFormat) {
+		day, err := time.Parse(icsDateFormat, value)
+		return day, true, err
+	}
+	var date time.Time
+	var err error
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		date, err = time.Parse(icsDateTimeFormat, strings.TrimSuffix(value, "Z"))
+		date = date.In(loc)
+	case tzid != "":
+		zone, zoneErr := time.LoadLocation(tzid)
+		if zoneErr != nil {
+			return date, false, errors.New("Invalid time zone " + tzid)
+		}
+		date, err = time.ParseInLocation(icsDateTimeFormat, value, zone)
+		date = date.In(loc)
+	default:
+		date, err = time.ParseInLocation(icsDateTimeFormat, value, loc)
+	}
+	if err != nil {
+		return date, false, errors.New("Invalid date " + value)
+	}
+	midnight := date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), midnight, nil
+}
+
+//icsParam return the value of a property parameter
+func icsParam(property, param string) string {
+	for _, elt := range strings.Split(property, ";")[1:] {
+		kv := strings.SplitN(elt, "=", 2)
+		if len(kv) == 2 && strings.ToUpper(kv[0]) == param {
+			return strings.Trim(kv[1], "\"")
+		}
+	}
+	return ""
+}
+
+//parseICSRule parse the RRULE parts handled by the import: FREQ, INTERVAL, COUNT, UNTIL and the BYDAY week days
+func parseICSRule(value string, loc *time.Location) (*icsRule, error) {
+	rule := icsRule{
+		interval: 1,
+		days:     make(map[time.Weekday]bool),
+	}
+	var unsupported []string
+	for _, part := range strings.Split(strings.ToUpper(value), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("Invalid RRULE " + value)
+		}
+		var err error
+		switch kv[0] {
+		case "FREQ":
+			rule.freq = kv[1]
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(kv[1])
+			if err == nil && rule.interval <= 0 {
+				err = errors.New("Invalid interval " + kv[1])
+			}
+		case "COUNT":
+			rule.count, err = strconv.Atoi(kv[1])
+			if err == nil && rule.count <= 0 {
+				err = errors.New("Invalid count " + kv[1])
+			}
+		case "UNTIL":
+			var until time.Time
+			until, _, err = parseICSDate(kv[1], "", loc)
+			rule.until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(kv[1], ",") {
+				weekDay, ok := icsWeekDays[day]
+				if !ok {
+					return nil, errors.New("Unsupported RRULE day " + day)
+				}
+				rule.days[weekDay] = true
+			}
+		case "WKST":
+		default:
+			unsupported = append(unsupported, part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	//the other yearly parts repeat the start date month and day
+	if (len(unsupported) > 0 && rule.freq != "YEARLY") || (rule.freq == "YEARLY" && len(rule.days) > 0) {
+		return nil, errors.New("Unsupported RRULE " + value)
+	}
+	return &rule, nil
+}
+
+//occurrences return the first day of each occurrence of a daily or weekly rule
+func (rule icsRule) occurrences(start time.Time) ([]time.Time, error) {
+	var res []time.Time
+	if rule.count == 0 && rule.until == nil {
+		return nil, errors.New("repeated event without COUNT or UNTIL")
+	}
+	days := rule.days
+	if rule.freq == "WEEKLY" && len(days) == 0 {
+		days = map[time.Weekday]bool{start.Weekday(): true}
+	}
+	//the weeks start on monday
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	last := start.AddDate(0, 0, 7*rule.interval*maxICSOccurrences)
+	for day := start; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if rule.until != nil && day.After(*rule.until) {
+			break
+		}
+		if rule.count > 0 && len(res) >= rule.count {
+			break
+		}
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		period := int(day.Sub(start).Hours() / 24)
+		if rule.freq == "WEEKLY" {
+			period = int(day.Sub(weekStart).Hours() / 24 / 7)
+		}
+		if period%rule.interval != 0 {
+			continue
+		}
+		res = append(res, day)
+		if len(res) > maxICSOccurrences {
+			return nil, errors.New("repeated event with more than " + strconv.Itoa(maxICSOccurrences) + " occurrences")
+		}
+	}
+	return res, nil
+}
+
+//exceptions convert the event to calendar exceptions, one per occurrence of a daily or weekly event
+func (evt icsVEvent) exceptions(mode string, loc *time.Location) ([]CalendarException, error) {
+	if evt.start == nil {
+		return nil, errors.New("Event " + evt.label + " has no start date")
+	}
+	days := 0
+	if evt.end != nil && evt.end.After(*evt.start) {
+		days = int(evt.end.Sub(*evt.start).Hours() / 24)
+	}
+	exception := func(start time.Time, annual bool) CalendarException {
+		e := CalendarException{
+			Label:  evt.label,
+			Start:  start.Format(DateFormat),
+			Annual: annual,
+			Mode:   mode,
+		}
+		if evt.end != nil && !evt.end.Before(*evt.start) {
+			e.End = start.AddDate(0, 0, days).Format(DateFormat)
+		}
+		return e
+	}
+	if evt.rrule == "" {
+		return []CalendarException{exception(*evt.start, false)}, nil
+	}
+
+	rule, err := parseICSRule(evt.rrule, loc)
+	if err != nil {
+		return nil, errors.New("Event " + evt.label + ": " + err.Error())
+	}
+	var starts []time.Time
+	switch rule.freq {
+	case "YEARLY":
+		if rule.count == 0 && rule.until == nil {
+			if rule.interval != 1 {
+				return nil, errors.New("Event " + evt.label + ": repeated event without COUNT or UNTIL")
+			}
+			return []CalendarException{exception(*evt.start, true)}, nil
+		}
+		for year := 0; len(starts) < maxICSOccurrences; year += rule.interval {
+			day := evt.start.AddDate(year, 0, 0)
+			if (rule.until != nil && day.After(*rule.until)) || (rule.count > 0 && len(starts) >= rule.count) {
+				break
+			}
+			starts = append(starts, day)
+		}
+	case "DAILY", "WEEKLY":
+		starts, err = rule.occurrences(*evt.start)
+		if err != nil {
+			return nil, errors.New("Event " + evt.label + ": " + err.Error())
+		}
+	default:
+		return nil, errors.New("Event " + evt.label + ": unsupported RRULE frequency " + rule.freq)
+	}
+	var res []CalendarException
+	for _, start := range starts {
+		if !evt.exdates[start.Format(DateFormat)] {
+			res = append(res, exception(start, false))
+		}
+	}
+	return res, nil
+}
+
+//ParseICS convert the events of an iCalendar file to calendar exceptions in the site location.
+//The events repeated every year become annual exceptions, the daily and weekly ones are expanded
+//into one exception per occurrence and the other repetitions are rejected
+func ParseICS(data []byte, mode string, loc *time.Location) ([]CalendarException, error) {
+	var res []CalendarException
+	var current *icsVEvent
+	for _, line := range unfoldICS(string(data)) {
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		property := line[:sep]
+		name := strings.ToUpper(property)
+		if i := strings.Index(name, ";"); i >= 0 {
+			name = name[:i]
+		}
+		value := strings.TrimSpace(line[sep+1:])
+		tzid := icsParam(property, "TZID")
+
+		switch name {
+		case "BEGIN":
+			if strings.ToUpper(value) == "VEVENT" {
+				current = &icsVEvent{
+					exdates: make(map[string]bool),
+				}
+			}
+		case "END":
+			if strings.ToUpper(value) == "VEVENT" && current != nil {
+				exceptions, err := current.exceptions(mode, loc)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, exceptions...)
+				current = nil
+			}
+		}
+		if current == nil {
+			continue
+		}
+
+		switch name {
+		case "SUMMARY":
+			current.label = strings.Replace(value, "\\,", ",", -1)
+		case "DTSTART":
+			day, _, err := parseICSDate(value, tzid, loc)
+			if err != nil {
+				return nil, err
+			}
+			current.start = &day
+		case "DTEND":
+			day, midnight, err := parseICSDate(value, tzid, loc)
+			if err != nil {
+				return nil, err
+			}
+			if midnight {
+				//the end date is excluded
+				day = day.AddDate(0, 0, -1)
+			}
+			current.end = &day
+		case "RRULE":
+			current.rrule = value
+		case "EXDATE":
+			for _, elt := range strings.Split(value, ",") {
+				day, _, err := parseICSDate(elt, tzid, loc)
+				if err != nil {
+					return nil, err
+				}
+				current.exdates[day.Format(DateFormat)] = true
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("No event found")
+	}
+	return res, nil
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func icsEvent(lines ...string) []byte {
+	data := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VEVENT"}
+	data = append(data, lines...)
+	data = append(data, "END:VEVENT", "END:VCALENDAR")
+	return []byte(strings.Join(data, "\r\n"))
+}
+
+func TestParseICS(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []CalendarException
+	}{
+		{
+			name: "all day event, the end date is excluded",
+			data: icsEvent("SUMMARY:Christmas", "DTSTART;VALUE=DATE:20241225", "DTEND;VALUE=DATE:20241227"),
+			want: []CalendarException{{Label: "Christmas", Start: "2024-12-25", End: "2024-12-26"}},
+		},
+		{
+			name: "single all day event",
+			data: icsEvent("SUMMARY:Closed", "DTSTART;VALUE=DATE:20240301", "DTEND;VALUE=DATE:20240302"),
+			want: []CalendarException{{Label: "Closed", Start: "2024-03-01", End: "2024-03-01"}},
+		},
+		{
+			name: "folded summary",
+			data: icsEvent("SUMMARY:Summer", " closing\\, week 1", "DTSTART:20240805"),
+			want: []CalendarException{{Label: "Summerclosing, week 1", Start: "2024-08-05"}},
+		},
+		{
+			name: "UTC date time ending in the day",
+			data: icsEvent("SUMMARY:Audit", "DTSTART:20240410T080000Z", "DTEND:20240411T170000Z"),
+			want: []CalendarException{{Label: "Audit", Start: "2024-04-10", End: "2024-04-11"}},
+		},
+		{
+			name: "UTC date time on the next site day",
+			data: icsEvent("SUMMARY:New year", "DTSTART:20241231T230000Z", "DTEND:20250101T230000Z"),
+			want: []CalendarException{{Label: "New year", Start: "2025-01-01", End: "2025-01-01"}},
+		},
+		{
+			name: "TZID date time ending at midnight",
+			data: icsEvent("SUMMARY:Works", "DTSTART;TZID=Europe/Paris:20240610T000000", "DTEND;TZID=Europe/Paris:20240612T000000"),
+			want: []CalendarException{{Label: "Works", Start: "2024-06-10", End: "2024-06-11"}},
+		},
+		{
+			name: "TZID of another zone",
+			data: icsEvent("SUMMARY:Call", "DTSTART;TZID=America/New_York:20240610T200000"),
+			want: []CalendarException{{Label: "Call", Start: "2024-06-11"}},
+		},
+		{
+			name: "yearly RRULE",
+			data: icsEvent("SUMMARY:New year", "DTSTART;VALUE=DATE:20240101", "RRULE:FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=1"),
+			want: []CalendarException{{Label: "New year", Start: "2024-01-01", Annual: true}},
+		},
+		{
+			name: "yearly RRULE with a count",
+			data: icsEvent("SUMMARY:Inventory", "DTSTART;VALUE=DATE:20240102", "RRULE:FREQ=YEARLY;COUNT=2"),
+			want: []CalendarException{{Label: "Inventory", Start: "2024-01-02"}, {Label: "Inventory", Start: "2025-01-02"}},
+		},
+		{
+			name: "weekly RRULE with a count",
+			data: icsEvent("SUMMARY:Meeting", "DTSTART;VALUE=DATE:20240108", "RRULE:FREQ=WEEKLY;BYDAY=MO;COUNT=3"),
+			want: []CalendarException{{Label: "Meeting", Start: "2024-01-08"}, {Label: "Meeting", Start: "2024-01-15"}, {Label: "Meeting", Start: "2024-01-22"}},
+		},
+		{
+			name: "weekly RRULE every two weeks until a date",
+			data: icsEvent("SUMMARY:Cleaning", "DTSTART;VALUE=DATE:20240108", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20240124"),
+			want: []CalendarException{{Label: "Cleaning", Start: "2024-01-08"}, {Label: "Cleaning", Start: "2024-01-10"},
+				{Label: "Cleaning", Start: "2024-01-22"}, {Label: "Cleaning", Start: "2024-01-24"}},
+		},
+		{
+			name: "weekly RRULE of a weekend",
+			data: icsEvent("SUMMARY:Event", "DTSTART;VALUE=DATE:20240105", "DTEND;VALUE=DATE:20240108", "RRULE:FREQ=WEEKLY;COUNT=2"),
+			want: []CalendarException{{Label: "Event", Start: "2024-01-05", End: "2024-01-07"}, {Label: "Event", Start: "2024-01-12", End: "2024-01-14"}},
+		},
+		{
+			name: "daily RRULE with an excluded date",
+			data: icsEvent("SUMMARY:Works", "DTSTART;VALUE=DATE:20240805", "RRULE:FREQ=DAILY;UNTIL=20240808T220000Z", "EXDATE;VALUE=DATE:20240807"),
+			want: []CalendarException{{Label: "Works", Start: "2024-08-05"}, {Label: "Works", Start: "2024-08-06"},
+				{Label: "Works", Start: "2024-08-08"}, {Label: "Works", Start: "2024-08-09"}},
+		},
+		{
+			name: "end before start",
+			data: icsEvent("SUMMARY:Typo", "DTSTART;VALUE=DATE:20240510", "DTEND;VALUE=DATE:20240501"),
+			want: []CalendarException{{Label: "Typo", Start: "2024-05-10"}},
+		},
+	}
+	paris, _ := time.LoadLocation("Europe/Paris")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ParseICS(tt.data, CalendarModeSuppress, paris)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for i := range tt.want {
+				tt.want[i].Mode = CalendarModeSuppress
+			}
+			if !reflect.DeepEqual(res, tt.want) {
+				t.Errorf("got %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseICSErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no event", []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR")},
+		{"no start", icsEvent("SUMMARY:Nothing")},
+		{"invalid date", icsEvent("DTSTART:2024")},
+		{"unknown time zone", icsEvent("DTSTART;TZID=Nowhere:20240610T100000")},
+		{"weekly RRULE without end", icsEvent("DTSTART;VALUE=DATE:20240108", "RRULE:FREQ=WEEKLY;BYDAY=MO")},
+		{"monthly RRULE", icsEvent("DTSTART;VALUE=DATE:20240108", "RRULE:FREQ=MONTHLY;COUNT=3")},
+		{"yearly RRULE on a week day", icsEvent("DTSTART;VALUE=DATE:20241128", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH")},
+		{"unsupported weekly part", icsEvent("DTSTART;VALUE=DATE:20240108", "RRULE:FREQ=WEEKLY;COUNT=3;BYSETPOS=1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseICS(tt.data, CalendarModeSuppress, time.UTC); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	MailTemplateAlarm   = "alarm"
+	MailTemplateSummary = "summary"
+
+	mailTimeout = 30 * time.Second
+)
+
+//default templates, each one defines the subject and the body of the message
+const defaultAlarmTemplate = `{{define "subject"}}[{{.Severity}}] {{.Type}} {{if .Label}}{{.Label}}{{else}}{{.Mac}}{{end}}{{end}}
+{{define "body"}}Alarm {{.Type}} raised on {{if .Label}}{{.Label}} ({{.Mac}}){{else}}{{.Mac}}{{end}}
+
+Severity: {{.Severity}}
+Message: {{.Message}}
+Raised: {{.Raised}}
+{{if gt .Occurrences 1}}Occurrences: {{.Occurrences}}
+{{end}}{{if .Group}}Group: {{.Group}}
+{{end}}{{end}}`
+
+const defaultSummaryTemplate = `{{define "subject"}}Daily summary {{.To.Format "2006-01-02"}}{{end}}
+{{define "body"}}Summary from {{.From.Format "2006-01-02 15:04"}} to {{.To.Format "2006-01-02 15:04"}}
+
+Energy: {{printf "%.1f" .EnergyKWh}} kWh
+
+Offline switchs: {{len .OfflineSwitchs}}
+{{range .OfflineSwitchs}}  - {{if .Label}}{{.Label}} ({{.Mac}}){{else}}{{.Mac}}{{end}} unreachable since {{.LastSeen}}
+{{end}}
+Uncommissioned labels: {{len .Uncommissioned}}
+{{range .Uncommissioned}}  - {{.}}
+{{end}}{{end}}`
+
+//MailSummary content of the daily summary email
+type MailSummary struct {
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Energy         float64         `json:"energy"` //reported by the switchs (Wh)
+	OfflineSwitchs []OfflineSwitch `json:"offlineSwitchs"`
+	Uncommissioned []string        `json:"uncommissioned"` //project labels without driver
+}
+
+//MailTest test email request
+type MailTest struct {
+	Template string   `json:"template"` //alarm or summary
+	To       []string `json:"to"`       //the configured recipients of the template when empty
+}
+
+//NewMailSummary prepare the summary of a period
+func NewMailSummary(from, to time.Time, energy float64, offline map[string]OfflineSwitch, projects []Project) MailSummary {
+	summary := MailSummary{
+		From:           from,
+		To:             to,
+		Energy:         energy,
+		OfflineSwitchs: []OfflineSwitch{},
+		Uncommissioned: []string{},
+	}
+	for _, sw := range offline {
+		summary.OfflineSwitchs = append(summary.OfflineSwitchs, sw)
+	}
+	sort.Slice(summary.OfflineSwitchs, func(i, j int) bool {
+		return summary.OfflineSwitchs[i].LastSeen < summary.OfflineSwitchs[j].LastSeen
+	})
+	for _, project := range projects {
+		if project.Mac == nil || *project.Mac == "" {
+			summary.Uncommissioned = append(summary.Uncommissioned, project.Label)
+		}
+	}
+	sort.Strings(summary.Uncommissioned)
+	return summary
+}
+
+//EnergyKWh return the summary energy in kWh
+func (m MailSummary) EnergyKWh() float64 {
+	return m.Energy / 1000
+}
+
+//Template return the template of a message kind, the file set in the configuration or the default one
+func (m MailConfig) Template(kind string) (*template.Template, error) {
+	path := m.AlarmTemplate
+	text := defaultAlarmTemplate
+	if kind == MailTemplateSummary {
+		path = m.SummaryTemplate
+		text = defaultSummaryTemplate
+	}
+	if path != "" {
+		return template.ParseFiles(path)
+	}
+	return template.New(kind).Parse(text)
+}
+
+//Render return the subject and the body of a message
+func (m MailConfig) Render(kind string, data interface{}) (string, string, error) {
+	tmpl, err := m.Template(kind)
+	if err != nil {
+		return "", "", err
+	}
+	var subject, body bytes.Buffer
+	err = tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return "", "", err
+	}
+	err = tmpl.ExecuteTemplate(&body, "body", data)
+	if err != nil {
+		return "", "", err
+	}
+	//the subject is a header, it stays on a single line
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
+
+func (m MailConfig) message(to []string, subject, body string) []byte {
+	var msg bytes.Buffer
+	msg.WriteString("From: " + m.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	body = strings.Replace(body, "\r\n", "\n", -1)
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return msg.Bytes()
+}
+
+//Send send a message through the SMTP relay, STARTTLS is used when the relay offers it
+func (m MailConfig) Send(to []string, subject, body string) error {
+	if !m.IsEnabled() {
+		return errors.New("SMTP relay is not configured")
+	}
+	if len(to) == 0 {
+		return errors.New("No recipient")
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host}
+	dialer := &net.Dialer{Timeout: mailTimeout}
+	var conn net.Conn
+	var err error
+	if m.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(mailTimeout))
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && !m.TLS {
+		err = c.StartTLS(tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host))
+		if err != nil {
+			return err
+		}
+	}
+	err = c.Mail(m.From)
+	if err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		err = c.Rcpt(rcpt)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(m.message(to, subject, body))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+//IsEnabled check if the SMTP relay is set
+func (m MailConfig) IsEnabled() bool {
+	return m.Host != ""
+}
+
+//NextSummary return the next summary date after now in the site time zone
+func (m MailConfig) NextSummary(now time.Time, loc *time.Location) time.Time {
+	clock, err := time.Parse("15:04", m.SummaryTime)
+	if err != nil {
+		clock, _ = time.Parse("15:04", DefaultSummaryTime)
+	}
+	hour, minute := clock.Hour(), clock.Minute()
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, loc)
+	}
+	return next
+}
@@ -26,6 +26,7 @@ type MapInfo struct {
 	Frames     map[string]dserver.Frame             `json:"frames"`
 	Project    map[string]Project                   `json:"projects"`
 	Switchs    map[string]dserver.SwitchConfig      `json:"switchs"`
+	Facades    map[string]Facade                    `json:"facades"`
 }
 
 // ToJSON dump MapInfo struct
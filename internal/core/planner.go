@@ -0,0 +1,95 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+//Planner compute when the schedule actions occur
+type Planner struct {
+	Calendars []Calendar
+	Site      SiteConfig
+}
+
+//resolve return the action with the time of its solar event, false when the event does not occur
+func (p Planner) resolve(action ScheduleAction, sun SunTimes) (ScheduleAction, bool) {
+	if action.Event == "" {
+		return action, true
+	}
+	if !p.Site.IsLocated() {
+		return action, false
+	}
+	date := sun.Sunrise
+	if action.Event == SunEventSunset {
+		date = sun.Sunset
+	}
+	if date == nil {
+		return action, false
+	}
+	action.Time = date.Add(time.Duration(action.Offset) * time.Minute).Format(TimeOfDayFormat)
+	return action, true
+}
+
+//Preview return the actions applied during the day once the calendar exceptions are taken into account
+func (p Planner) Preview(s Schedule, day time.Time) SchedulePreview {
+	day = day.In(p.Site.Location())
+	res := SchedulePreview{
+		Group:   s.Group,
+		Date:    day.Format(DateFormat),
+		Actions: []ScheduleAction{},
+	}
+	actions := []ScheduleAction{}
+	calendar, exception := getException(s.Group, day, p.Calendars)
+	if exception != nil {
+		res.Calendar = calendar.Name
+		res.Exception = exception.Label
+		if exception.Mode == CalendarModeOverride {
+			actions = exception.Actions
+		}
+	} else {
+		for _, action := range s.Actions {
+			if inDays(day.Weekday(), action.Days) {
+				actions = append(actions, action)
+			}
+		}
+	}
+
+	sun := p.Site.GetSunTimes(day)
+	for _, action := range actions {
+		action, ok := p.resolve(action, sun)
+		if ok {
+			res.Actions = append(res.Actions, action)
+		}
+	}
+	sort.SliceStable(res.Actions, func(i, j int) bool {
+		return res.Actions[i].Time < res.Actions[j].Time
+	})
+	return res
+}
+
+//Events return the actions occurring after from and until to (included) sorted by date
+func (p Planner) Events(s Schedule, from, to time.Time) []ScheduleEvent {
+	var res []ScheduleEvent
+	loc := p.Site.Location()
+	from = from.In(loc)
+	to = to.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, action := range p.Preview(s, day).Actions {
+			min, err := minutes(action.Time)
+			if err != nil {
+				continue
+			}
+			date := time.Date(day.Year(), day.Month(), day.Day(), min/60, min%60, 0, 0, loc)
+			if !date.After(from) || date.After(to) {
+				continue
+			}
+			res = append(res, ScheduleEvent{
+				Date:   date,
+				Group:  s.Group,
+				Action: action,
+			})
+		}
+	}
+	return res
+}
@@ -0,0 +1,299 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+const (
+	PrioritySafety   = "safety"
+	PriorityManual   = "manual"
+	PriorityRule     = "rule"
+	PrioritySchedule = "schedule"
+	PriorityDefault  = "default"
+
+	PointLed   = "led"
+	PointBlind = "blind"
+	PointHvac  = "hvac"
+	PointGroup = "group"
+)
+
+//PriorityLevels command levels, highest priority first
+var PriorityLevels = []string{PrioritySafety, PriorityManual, PriorityRule, PrioritySchedule, PriorityDefault}
+
+//PriorityCommand command of one priority level on a driver or a group, one of the commands is set
+type PriorityCommand struct {
+	Level   string            `json:"level"`
+	Source  string            `json:"source"` //user hash, schedule, suntracking or rule name
+	Date    string            `json:"date"`
+	Timeout *int              `json:"timeout,omitempty"` //requested duration (minutes), the level default when unset, 0 for ever
+	Expires string            `json:"expires,omitempty"` //released at this date, never when empty
+	Led     *dserver.LedCmd   `json:"led,omitempty"`
+	Blind   *dserver.BlindCmd `json:"blind,omitempty"`
+	Hvac    *dserver.HvacCmd  `json:"hvac,omitempty"`
+	Group   *dserver.GroupCmd `json:"group,omitempty"`
+	Ack     string            `json:"ack,omitempty"` //acknowledgement ID of the driver command
+}
+
+//PriorityArray commands of a driver or a group by priority level
+type PriorityArray struct {
+	Point      string            `json:"point"`      //kind/id
+	Kind       string            `json:"kind"`       //led, blind, hvac or group
+	ID         string            `json:"id"`         //driver mac or group ID
+	Commands   []PriorityCommand `json:"commands"`   //highest priority first
+	Controller string            `json:"controller"` //level of the highest active command
+	Source     string            `json:"source"`     //source of the highest active command
+	Applied    *PriorityCommand  `json:"applied"`    //active levels merged, the highest wins for each setpoint
+}
+
+//PriorityRelease removal of a level of a priority array
+type PriorityRelease struct {
+	Point string `json:"point"`
+	Level string `json:"level"`
+}
+
+//ToPriorityCommand convert map interface to PriorityCommand object
+func ToPriorityCommand(val interface{}) (*PriorityCommand, error) {
+	var c PriorityCommand
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &c)
+	return &c, err
+}
+
+//ToPriorityCommands convert list interface to PriorityCommand objects
+func ToPriorityCommands(val interface{}) ([]PriorityCommand, error) {
+	var c []PriorityCommand
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &c)
+	return c, err
+}
+
+//ToPriorityRelease convert map interface to PriorityRelease object
+func ToPriorityRelease(val interface{}) (*PriorityRelease, error) {
+	var r PriorityRelease
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &r)
+	return &r, err
+}
+
+//ToPriorityArray convert map interface to PriorityArray object
+func ToPriorityArray(val interface{}) (*PriorityArray, error) {
+	var a PriorityArray
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &a)
+	return &a, err
+}
+
+//PriorityRank return the level index, 0 for the highest priority and -1 for an unknown level
+func PriorityRank(level string) int {
+	for i, l := range PriorityLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+//PointName return the priority array name of a driver or a group
+func PointName(kind, id string) string {
+	return kind + "/" + id
+}
+
+//Point return the kind and the ID of the commanded driver or group
+func (c PriorityCommand) Point() (string, string) {
+	switch {
+	case c.Led != nil:
+		return PointLed, c.Led.Mac
+	case c.Blind != nil:
+		return PointBlind, c.Blind.Mac
+	case c.Hvac != nil:
+		return PointHvac, c.Hvac.Mac
+	case c.Group != nil:
+		return PointGroup, strconv.Itoa(c.Group.Group)
+	}
+	return "", ""
+}
+
+//Validate check the level and that a single command is set
+func (c PriorityCommand) Validate() error {
+	if PriorityRank(c.Level) < 0 {
+		return errors.New("Invalid priority " + c.Level)
+	}
+	count := 0
+	for _, set := range []bool{c.Led != nil, c.Blind != nil, c.Hvac != nil, c.Group != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return errors.New("A single command is expected")
+	}
+	if c.Timeout != nil && *c.Timeout < 0 {
+		return errors.New("Invalid timeout")
+	}
+	return nil
+}
+
+//SameCommand compare the commands regardless of the level
+func (c PriorityCommand) SameCommand(other PriorityCommand) bool {
+	cmd, _ := json.Marshal([]interface{}{c.Led, c.Blind, c.Hvac, c.Group})
+	otherCmd, _ := json.Marshal([]interface{}{other.Led, other.Blind, other.Hvac, other.Group})
+	return string(cmd) == string(otherCmd)
+}
+
+func (c PriorityCommand) expired(now time.Time) bool {
+	if c.Expires == "" {
+		return false
+	}
+	date, err := time.Parse(time.RFC3339, c.Expires)
+	return err == nil && !now.Before(date)
+}
+
+func mergeBlindCmd(dst *dserver.BlindCmd, src dserver.BlindCmd) {
+	if src.Blind1 != nil {
+		dst.Blind1 = src.Blind1
+	}
+	if src.Blind2 != nil {
+		dst.Blind2 = src.Blind2
+	}
+	if src.Slat1 != nil {
+		dst.Slat1 = src.Slat1
+	}
+	if src.Slat2 != nil {
+		dst.Slat2 = src.Slat2
+	}
+}
+
+func mergeGroupCmd(dst *dserver.GroupCmd, src dserver.GroupCmd) {
+	if src.Auto != nil {
+		dst.Auto = src.Auto
+	}
+	if src.SetpointLeds != nil {
+		dst.SetpointLeds = src.SetpointLeds
+	}
+	if src.SetpointBlinds != nil {
+		dst.SetpointBlinds = src.SetpointBlinds
+	}
+	if src.SetpointSlats != nil {
+		dst.SetpointSlats = src.SetpointSlats
+	}
+	if src.SetpointTempOffset != nil {
+		dst.SetpointTempOffset = src.SetpointTempOffset
+	}
+}
+
+//merge override the setpoints set by cmd, the leds and hvacs commands are replaced
+func (c *PriorityCommand) merge(cmd PriorityCommand) {
+	switch {
+	case c.Blind != nil && cmd.Blind != nil:
+		blind := *c.Blind
+		mergeBlindCmd(&blind, *cmd.Blind)
+		c.Blind = &blind
+	case c.Group != nil && cmd.Group != nil:
+		group := *c.Group
+		mergeGroupCmd(&group, *cmd.Group)
+		c.Group = &group
+	default:
+		c.Led = cmd.Led
+		c.Blind = cmd.Blind
+		c.Hvac = cmd.Hvac
+		c.Group = cmd.Group
+	}
+}
+
+//Set store the command of a level, the setpoints already set at this level
+//and not overridden are kept
+func (a *PriorityArray) Set(cmd PriorityCommand) {
+	for i, c := range a.Commands {
+		if c.Level == cmd.Level {
+			c.merge(cmd)
+			cmd.Led, cmd.Blind, cmd.Hvac, cmd.Group = c.Led, c.Blind, c.Hvac, c.Group
+			a.Commands[i] = cmd
+			return
+		}
+	}
+	a.Commands = append(a.Commands, cmd)
+	sort.Slice(a.Commands, func(i, j int) bool {
+		return PriorityRank(a.Commands[i].Level) < PriorityRank(a.Commands[j].Level)
+	})
+}
+
+//Relinquish remove the command of a level, only when it comes from source if set
+func (a *PriorityArray) Relinquish(level, source string) bool {
+	for i, c := range a.Commands {
+		if c.Level == level && (source == "" || c.Source == source) {
+			a.Commands = append(a.Commands[:i], a.Commands[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//Expire remove the expired commands
+func (a *PriorityArray) Expire(now time.Time) bool {
+	var commands []PriorityCommand
+	for _, c := range a.Commands {
+		if !c.expired(now) {
+			commands = append(commands, c)
+		}
+	}
+	changed := len(commands) != len(a.Commands)
+	a.Commands = commands
+	return changed
+}
+
+//Resolve compute the controller and the applied command from the lowest to the highest priority
+func (a *PriorityArray) Resolve() {
+	a.Controller = ""
+	a.Source = ""
+	a.Applied = nil
+	for i := len(a.Commands) - 1; i >= 0; i-- {
+		c := a.Commands[i]
+		if a.Applied == nil {
+			applied := c
+			a.Applied = &applied
+		} else {
+			a.Applied.merge(c)
+		}
+		a.Applied.Level = c.Level
+		a.Applied.Source = c.Source
+		a.Applied.Date = c.Date
+		a.Applied.Timeout = c.Timeout
+		a.Applied.Expires = c.Expires
+		a.Controller = c.Level
+		a.Source = c.Source
+	}
+}
+
+//Overrides return the commands of the group drivers controlled by a level higher than the group command,
+//they are sent again after the group command so that it does not replace them
+func Overrides(group PriorityCommand, drivers []PriorityArray, now time.Time) []PriorityCommand {
+	res := []PriorityCommand{}
+	rank := PriorityRank(group.Level)
+	for _, array := range drivers {
+		array.Expire(now)
+		array.Resolve()
+		if array.Applied == nil || PriorityRank(array.Controller) >= rank {
+			continue
+		}
+		res = append(res, *array.Applied)
+	}
+	return res
+}
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func blindCmd(level, source string, blind1, slat1 *int) PriorityCommand {
+	return PriorityCommand{
+		Level:  level,
+		Source: source,
+		Blind:  &dserver.BlindCmd{Mac: "BLIND", Blind1: blind1, Slat1: slat1},
+	}
+}
+
+func levels(a PriorityArray) []string {
+	var res []string
+	for _, c := range a.Commands {
+		res = append(res, c.Level)
+	}
+	return res
+}
+
+func TestPriorityResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		commands   []PriorityCommand //set in this order
+		levels     []string
+		controller string
+		source     string
+		blind1     *int
+		slat1      *int
+	}{
+		{
+			name:       "highest level wins",
+			commands:   []PriorityCommand{blindCmd(PrioritySchedule, "schedule", intPtr(50), nil), blindCmd(PriorityManual, "user", intPtr(20), nil)},
+			levels:     []string{PriorityManual, PrioritySchedule},
+			controller: PriorityManual,
+			source:     "user",
+			blind1:     intPtr(20),
+		},
+		{
+			name:       "lower level setpoints are kept",
+			commands:   []PriorityCommand{blindCmd(PriorityManual, "user", nil, intPtr(80)), blindCmd(PrioritySchedule, "schedule", intPtr(50), intPtr(10))},
+			levels:     []string{PriorityManual, PrioritySchedule},
+			controller: PriorityManual,
+			source:     "user",
+			blind1:     intPtr(50),
+			slat1:      intPtr(80),
+		},
+		{
+			name:       "same level merged",
+			commands:   []PriorityCommand{blindCmd(PriorityRule, "rule1", intPtr(30), nil), blindCmd(PriorityRule, "rule2", nil, intPtr(60))},
+			levels:     []string{PriorityRule},
+			controller: PriorityRule,
+			source:     "rule2",
+			blind1:     intPtr(30),
+			slat1:      intPtr(60),
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := PriorityArray{Point: PointName(PointBlind, "BLIND"), Kind: PointBlind, ID: "BLIND"}
+			for _, c := range tt.commands {
+				a.Set(c)
+			}
+			a.Resolve()
+			if !reflect.DeepEqual(levels(a), tt.levels) {
+				t.Errorf("levels %v, want %v", levels(a), tt.levels)
+			}
+			if a.Controller != tt.controller || a.Source != tt.source {
+				t.Errorf("controlled by %v %v, want %v %v", a.Controller, a.Source, tt.controller, tt.source)
+			}
+			if tt.controller == "" {
+				if a.Applied != nil {
+					t.Errorf("unexpected applied command %+v", a.Applied)
+				}
+				return
+			}
+			if a.Applied == nil || a.Applied.Level != tt.controller || a.Applied.Blind == nil {
+				t.Fatalf("applied command %+v", a.Applied)
+			}
+			if !reflect.DeepEqual(a.Applied.Blind.Blind1, tt.blind1) || !reflect.DeepEqual(a.Applied.Blind.Slat1, tt.slat1) {
+				t.Errorf("applied blind %+v, want blind1 %v slat1 %v", a.Applied.Blind, tt.blind1, tt.slat1)
+			}
+		})
+	}
+}
+
+func TestPriorityExpire(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	expires := func(cmd PriorityCommand, minutes int) PriorityCommand {
+		cmd.Expires = now.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)
+		return cmd
+	}
+	tests := []struct {
+		name       string
+		commands   []PriorityCommand
+		changed    bool
+		levels     []string
+		controller string
+		blind1     *int
+	}{
+		{
+			name: "highest level expired",
+			commands: []PriorityCommand{
+				expires(blindCmd(PrioritySafety, "alarm", intPtr(100), nil), -1),
+				expires(blindCmd(PriorityManual, "user", intPtr(20), nil), 10),
+				blindCmd(PriorityDefault, "default", intPtr(0), nil),
+			},
+			changed:    true,
+			levels:     []string{PriorityManual, PriorityDefault},
+			controller: PriorityManual,
+			blind1:     intPtr(20),
+		},
+		{
+			name: "expired at now",
+			commands: []PriorityCommand{
+				expires(blindCmd(PriorityManual, "user", intPtr(20), nil), 0),
+				expires(blindCmd(PrioritySchedule, "schedule", intPtr(70), nil), 1),
+			},
+			changed:    true,
+			levels:     []string{PrioritySchedule},
+			controller: PrioritySchedule,
+			blind1:     intPtr(70),
+		},
+		{
+			name: "lower level expired",
+			commands: []PriorityCommand{
+				blindCmd(PriorityManual, "user", intPtr(20), nil),
+				expires(blindCmd(PriorityRule, "rule", intPtr(40), nil), -5),
+			},
+			changed:    true,
+			levels:     []string{PriorityManual},
+			controller: PriorityManual,
+			blind1:     intPtr(20),
+		},
+		{
+			name: "nothing expired",
+			commands: []PriorityCommand{
+				expires(blindCmd(PriorityManual, "user", intPtr(20), nil), 1),
+				blindCmd(PriorityDefault, "default", intPtr(0), nil),
+			},
+			levels:     []string{PriorityManual, PriorityDefault},
+			controller: PriorityManual,
+			blind1:     intPtr(20),
+		},
+		{
+			name: "all expired",
+			commands: []PriorityCommand{
+				expires(blindCmd(PriorityManual, "user", intPtr(20), nil), -1),
+			},
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := PriorityArray{Point: PointName(PointBlind, "BLIND"), Kind: PointBlind, ID: "BLIND"}
+			for _, c := range tt.commands {
+				a.Set(c)
+			}
+			if changed := a.Expire(now); changed != tt.changed {
+				t.Errorf("changed %v, want %v", changed, tt.changed)
+			}
+			a.Resolve()
+			if !reflect.DeepEqual(levels(a), tt.levels) {
+				t.Errorf("levels %v, want %v", levels(a), tt.levels)
+			}
+			if a.Controller != tt.controller {
+				t.Errorf("controller %v, want %v", a.Controller, tt.controller)
+			}
+			if tt.controller != "" && (a.Applied == nil || !reflect.DeepEqual(a.Applied.Blind.Blind1, tt.blind1)) {
+				t.Errorf("applied command %+v, want blind1 %v", a.Applied, *tt.blind1)
+			}
+		})
+	}
+}
+
+func TestPriorityRelinquish(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      string
+		source     string
+		released   bool
+		controller string
+	}{
+		{"level", PriorityManual, "", true, PrioritySchedule},
+		{"level and source", PriorityManual, "user", true, PrioritySchedule},
+		{"other source", PriorityManual, "admin", false, PriorityManual},
+		{"missing level", PrioritySafety, "", false, PriorityManual},
+		{"lower level", PrioritySchedule, "schedule", true, PriorityManual},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := PriorityArray{Point: PointName(PointBlind, "BLIND"), Kind: PointBlind, ID: "BLIND"}
+			a.Set(blindCmd(PrioritySchedule, "schedule", intPtr(50), nil))
+			a.Set(blindCmd(PriorityManual, "user", intPtr(20), nil))
+			if released := a.Relinquish(tt.level, tt.source); released != tt.released {
+				t.Errorf("released %v, want %v", released, tt.released)
+			}
+			a.Resolve()
+			if a.Controller != tt.controller {
+				t.Errorf("controller %v, want %v", a.Controller, tt.controller)
+			}
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	driver := func(kind, mac string, cmds ...PriorityCommand) PriorityArray {
+		a := PriorityArray{Point: PointName(kind, mac), Kind: kind, ID: mac}
+		for _, c := range cmds {
+			a.Set(c)
+		}
+		a.Resolve()
+		return a
+	}
+	led := func(level string, setpoint int) PriorityCommand {
+		return PriorityCommand{Level: level, Source: level, Led: &dserver.LedCmd{Mac: "LED", Setpoint: setpoint}}
+	}
+	expired := led(PriorityManual, 10)
+	expired.Expires = now.Add(-time.Minute).Format(time.RFC3339)
+	tests := []struct {
+		name      string
+		level     string //group command level
+		drivers   []PriorityArray
+		overrides []string //controllers of the commands sent again
+	}{
+		{"schedule under a manual led", PrioritySchedule, []PriorityArray{driver(PointLed, "LED", led(PriorityManual, 80))}, []string{PriorityManual}},
+		{"schedule under a rule blind", PrioritySchedule, []PriorityArray{driver(PointBlind, "BLIND", blindCmd(PriorityRule, "rule", intPtr(30), nil))}, []string{PriorityRule}},
+		{"schedule over a default led", PrioritySchedule, []PriorityArray{driver(PointLed, "LED", led(PriorityDefault, 0))}, []string{}},
+		{"manual group and manual led", PriorityManual, []PriorityArray{driver(PointLed, "LED", led(PriorityManual, 80))}, []string{}},
+		{"expired manual led", PrioritySchedule, []PriorityArray{driver(PointLed, "LED", expired, led(PriorityDefault, 0))}, []string{}},
+		{"safety group", PrioritySafety, []PriorityArray{driver(PointLed, "LED", led(PriorityManual, 80))}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := PriorityCommand{Level: tt.level, Group: &dserver.GroupCmd{Group: 1, SetpointLeds: intPtr(50)}}
+			res := Overrides(group, tt.drivers, now)
+			controllers := []string{}
+			for _, cmd := range res {
+				controllers = append(controllers, cmd.Level)
+			}
+			if !reflect.DeepEqual(controllers, tt.overrides) {
+				t.Errorf("overrides %v, want %v", controllers, tt.overrides)
+			}
+		})
+	}
+}
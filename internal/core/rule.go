@@ -0,0 +1,317 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	gm "github.com/energieip/common-components-go/pkg/dgroup"
+	"github.com/energieip/common-components-go/pkg/dhvac"
+	"github.com/energieip/common-components-go/pkg/dnanosense"
+	ds "github.com/energieip/common-components-go/pkg/dsensor"
+	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/common-components-go/pkg/dwago"
+)
+
+const (
+	RuleSourceSensor    = "sensor"
+	RuleSourceNanosense = "nanosense"
+	RuleSourceHvac      = "hvac"
+	RuleSourceWago      = "wago"
+	RuleSourceGroup     = "group"
+	RuleSourceSwitch    = "switch"
+)
+
+//RuleCondition comparison of a status property with a threshold
+type RuleCondition struct {
+	Source     string  `json:"source"`               //sensor, nanosense, hvac, wago, group or switch
+	Device     string  `json:"device"`               //driver label or mac, group ID for the group source
+	Property   string  `json:"property"`             //status field name (presence, brightness, cO2, temperature...)
+	Operator   string  `json:"operator"`             //>, >=, <, <=, == or !=
+	Value      float64 `json:"value"`                //threshold, booleans are compared as 0 or 1
+	Hysteresis float64 `json:"hysteresis,omitempty"` //margin to cross back before the condition is released
+}
+
+//RuleAction command sent by a rule, one of the commands is set
+type RuleAction struct {
+	Led   *dserver.LedCmd   `json:"led,omitempty"`
+	Blind *dserver.BlindCmd `json:"blind,omitempty"`
+	Hvac  *dserver.HvacCmd  `json:"hvac,omitempty"`
+	Group *dserver.GroupCmd `json:"group,omitempty"`
+}
+
+//Rule commands sent when all the conditions hold and when they stop holding
+type Rule struct {
+	Name           string          `json:"name"`
+	Enabled        bool            `json:"enabled"`
+	Conditions     []RuleCondition `json:"conditions"`
+	Delay          int             `json:"delay,omitempty"`        //seconds the conditions must hold before the actions are sent
+	ReleaseDelay   int             `json:"releaseDelay,omitempty"` //seconds the conditions must fail before the release actions are sent
+	Actions        []RuleAction    `json:"actions"`
+	ReleaseActions []RuleAction    `json:"releaseActions,omitempty"` //the rule level is relinquished when empty
+}
+
+//RuleState evaluation state of a rule
+type RuleState struct {
+	Active     bool
+	Conditions []bool
+	Since      *time.Time //first evaluation with a result different from Active
+}
+
+//ConditionResult condition checked against the current status
+type ConditionResult struct {
+	RuleCondition
+	Current *float64 `json:"current"` //unknown when the status is not reported
+	Holds   bool     `json:"holds"`
+}
+
+//RuleEvaluation rule checked against the current status without sending the actions
+type RuleEvaluation struct {
+	Name       string            `json:"name"`
+	Holds      bool              `json:"holds"`
+	Conditions []ConditionResult `json:"conditions"`
+	Actions    []RuleAction      `json:"actions"` //commands that would be sent once the delay elapsed
+}
+
+//RuleValues last known status properties by source, device and property
+type RuleValues map[string]float64
+
+// ToJSON dump Rule struct
+func (r Rule) ToJSON() (string, error) {
+	inrec, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToRule convert map interface to Rule object
+func ToRule(val interface{}) (*Rule, error) {
+	var r Rule
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &r)
+	return &r, err
+}
+
+func ruleValueKey(source, device, property string) string {
+	return source + "/" + device + "/" + property
+}
+
+//Add register the numeric and boolean fields of a status under each device name
+func (v RuleValues) Add(source string, devices []string, status interface{}) {
+	inrec, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	fields := make(map[string]interface{})
+	err = json.Unmarshal(inrec, &fields)
+	if err != nil {
+		return
+	}
+	for property, field := range fields {
+		var value float64
+		switch val := field.(type) {
+		case float64:
+			value = val
+		case bool:
+			if val {
+				value = 1
+			}
+		default:
+			continue
+		}
+		for _, device := range devices {
+			if device == "" {
+				continue
+			}
+			v[ruleValueKey(source, device, property)] = value
+		}
+	}
+}
+
+//DeviceNames return the names a condition can use for a device
+func DeviceNames(mac string, label *string) []string {
+	names := []string{mac}
+	if label != nil {
+		names = append(names, *label)
+	}
+	return names
+}
+
+//AddDrivers register the drivers and groups status
+func (v RuleValues) AddDrivers(sensors map[string]ds.Sensor, nanos map[string]dnanosense.Nanosense,
+	hvacs map[string]dhvac.Hvac, wagos map[string]dwago.Wago, groups map[int]gm.GroupStatus) {
+	for _, driver := range sensors {
+		v.Add(RuleSourceSensor, DeviceNames(driver.Mac, driver.Label), driver)
+	}
+	for _, driver := range nanos {
+		v.Add(RuleSourceNanosense, []string{driver.Mac, driver.Label}, driver)
+	}
+	for _, driver := range hvacs {
+		v.Add(RuleSourceHvac, DeviceNames(driver.Mac, driver.Label), driver)
+	}
+	for _, driver := range wagos {
+		v.Add(RuleSourceWago, DeviceNames(driver.Mac, driver.Label), driver)
+	}
+	for _, group := range groups {
+		v.Add(RuleSourceGroup, []string{strconv.Itoa(group.Group)}, group)
+	}
+}
+
+func (c RuleCondition) value(values RuleValues) *float64 {
+	val, ok := values[ruleValueKey(c.Source, c.Device, c.Property)]
+	if !ok {
+		return nil
+	}
+	return &val
+}
+
+//holds compare the value with the threshold, a holding condition is released
+//once the value crosses back the threshold by the hysteresis
+func (c RuleCondition) holds(value float64, previous bool) bool {
+	margin := 0.0
+	if previous {
+		margin = c.Hysteresis
+	}
+	switch c.Operator {
+	case ">":
+		return value > c.Value-margin
+	case ">=":
+		return value >= c.Value-margin
+	case "<":
+		return value < c.Value+margin
+	case "<=":
+		return value <= c.Value+margin
+	case "==":
+		return value == c.Value
+	case "!=":
+		return value != c.Value
+	}
+	return false
+}
+
+//Points return the priority arrays commanded by the actions and the release actions
+func (r Rule) Points() []string {
+	var points []string
+	seen := make(map[string]bool)
+	for _, action := range append(append([]RuleAction{}, r.Actions...), r.ReleaseActions...) {
+		point := PointName(action.Command(PriorityRule, "").Point())
+		if !seen[point] {
+			seen[point] = true
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+//Command return the priority command of the action
+func (a RuleAction) Command(level, source string) PriorityCommand {
+	return PriorityCommand{
+		Level:  level,
+		Source: source,
+		Led:    a.Led,
+		Blind:  a.Blind,
+		Hvac:   a.Hvac,
+		Group:  a.Group,
+	}
+}
+
+//Validate check the conditions and the actions
+func (r Rule) Validate() error {
+	if len(r.Conditions) == 0 {
+		return errors.New("Rule without conditions")
+	}
+	for _, c := range r.Conditions {
+		switch c.Source {
+		case RuleSourceSensor, RuleSourceNanosense, RuleSourceHvac, RuleSourceWago, RuleSourceGroup, RuleSourceSwitch:
+		default:
+			return errors.New("Invalid source " + c.Source)
+		}
+		switch c.Operator {
+		case ">", ">=", "<", "<=", "==", "!=":
+		default:
+			return errors.New("Invalid operator " + c.Operator)
+		}
+		if c.Device == "" || c.Property == "" {
+			return errors.New("Condition device or property is missing")
+		}
+		if c.Hysteresis < 0 {
+			return errors.New("Invalid hysteresis")
+		}
+	}
+	if r.Delay < 0 || r.ReleaseDelay < 0 {
+		return errors.New("Invalid delay")
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("Rule without actions")
+	}
+	for _, actions := range [][]RuleAction{r.Actions, r.ReleaseActions} {
+		for _, action := range actions {
+			if action.Led == nil && action.Blind == nil && action.Hvac == nil && action.Group == nil {
+				return errors.New("Empty action")
+			}
+		}
+	}
+	return nil
+}
+
+//Evaluate update the rule state with the current values, return true when the
+//rule switches on or off, a missing value fails its condition
+func (r Rule) Evaluate(values RuleValues, state *RuleState, now time.Time) bool {
+	if len(state.Conditions) != len(r.Conditions) {
+		state.Conditions = make([]bool, len(r.Conditions))
+	}
+	holds := true
+	for i, c := range r.Conditions {
+		val := c.value(values)
+		state.Conditions[i] = val != nil && c.holds(*val, state.Conditions[i])
+		holds = holds && state.Conditions[i]
+	}
+	if holds == state.Active {
+		state.Since = nil
+		return false
+	}
+	if state.Since == nil {
+		state.Since = &now
+	}
+	delay := r.Delay
+	if !holds {
+		delay = r.ReleaseDelay
+	}
+	if now.Sub(*state.Since) < time.Duration(delay)*time.Second {
+		return false
+	}
+	state.Active = holds
+	state.Since = nil
+	return true
+}
+
+//DryRun check the conditions against the current values, without hysteresis nor delay
+func (r Rule) DryRun(values RuleValues) RuleEvaluation {
+	res := RuleEvaluation{
+		Name:       r.Name,
+		Holds:      true,
+		Conditions: []ConditionResult{},
+	}
+	for _, c := range r.Conditions {
+		result := ConditionResult{
+			RuleCondition: c,
+			Current:       c.value(values),
+		}
+		result.Holds = result.Current != nil && c.holds(*result.Current, false)
+		res.Holds = res.Holds && result.Holds
+		res.Conditions = append(res.Conditions, result)
+	}
+	res.Actions = r.ReleaseActions
+	if res.Holds {
+		res.Actions = r.Actions
+	}
+	if res.Actions == nil {
+		res.Actions = []RuleAction{}
+	}
+	return res
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+func TestRuleEvaluate(t *testing.T) {
+	type step struct {
+		at       int     //seconds since the first evaluation
+		temp     float64 //temperature of the hvac
+		missing  bool    //temperature not reported
+		switched bool
+		active   bool
+	}
+	tests := []struct {
+		name  string
+		rule  Rule
+		steps []step
+	}{
+		{
+			name: "hysteresis",
+			rule: Rule{Conditions: []RuleCondition{{Operator: ">", Value: 26, Hysteresis: 1}}},
+			steps: []step{
+				{at: 0, temp: 25.5},
+				{at: 10, temp: 27, switched: true, active: true},
+				{at: 20, temp: 25.5, active: true},
+				{at: 30, temp: 25, switched: true},
+				{at: 40, temp: 25.5},
+				{at: 50, temp: 26},
+				{at: 60, temp: 26.5, switched: true, active: true},
+			},
+		},
+		{
+			name: "lower than with hysteresis",
+			rule: Rule{Conditions: []RuleCondition{{Operator: "<", Value: 19, Hysteresis: 0.5}}},
+			steps: []step{
+				{at: 0, temp: 18, switched: true, active: true},
+				{at: 10, temp: 19.4, active: true},
+				{at: 20, temp: 19.5, switched: true},
+				{at: 30, temp: 19.2},
+			},
+		},
+		{
+			name: "delay",
+			rule: Rule{Delay: 60, Conditions: []RuleCondition{{Operator: ">", Value: 26}}},
+			steps: []step{
+				{at: 0, temp: 27},
+				{at: 30, temp: 27},
+				{at: 60, temp: 27, switched: true, active: true},
+			},
+		},
+		{
+			name: "delay restarted when the condition fails",
+			rule: Rule{Delay: 60, Conditions: []RuleCondition{{Operator: ">", Value: 26}}},
+			steps: []step{
+				{at: 0, temp: 27},
+				{at: 30, temp: 25},
+				{at: 40, temp: 27},
+				{at: 90, temp: 27},
+				{at: 100, temp: 27, switched: true, active: true},
+			},
+		},
+		{
+			name: "release delay",
+			rule: Rule{ReleaseDelay: 30, Conditions: []RuleCondition{{Operator: ">", Value: 26}}},
+			steps: []step{
+				{at: 0, temp: 27, switched: true, active: true},
+				{at: 10, temp: 25, active: true},
+				{at: 20, temp: 27, active: true},
+				{at: 30, temp: 25, active: true},
+				{at: 60, temp: 25, switched: true},
+			},
+		},
+		{
+			name: "missing value",
+			rule: Rule{Conditions: []RuleCondition{{Operator: ">", Value: 26, Hysteresis: 1}}},
+			steps: []step{
+				{at: 0, missing: true},
+				{at: 10, temp: 27, switched: true, active: true},
+				{at: 20, missing: true, switched: true},
+			},
+		},
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.rule.Conditions {
+				tt.rule.Conditions[i].Source = RuleSourceHvac
+				tt.rule.Conditions[i].Device = "HVAC"
+				tt.rule.Conditions[i].Property = "temperature"
+			}
+			var state RuleState
+			for _, s := range tt.steps {
+				values := RuleValues{}
+				if !s.missing {
+					values[ruleValueKey(RuleSourceHvac, "HVAC", "temperature")] = s.temp
+				}
+				switched := tt.rule.Evaluate(values, &state, start.Add(time.Duration(s.at)*time.Second))
+				if switched != s.switched || state.Active != s.active {
+					t.Fatalf("at %vs: switched %v active %v, want %v %v", s.at, switched, state.Active, s.switched, s.active)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleEvaluateConditions(t *testing.T) {
+	rule := Rule{
+		Conditions: []RuleCondition{
+			{Source: RuleSourceSensor, Device: "office", Property: "presence", Operator: "==", Value: 1},
+			{Source: RuleSourceSensor, Device: "office", Property: "brightness", Operator: "<", Value: 300, Hysteresis: 50},
+		},
+	}
+	tests := []struct {
+		name       string
+		presence   bool
+		brightness float64
+		active     bool
+	}{
+		{"dark and empty", false, 100, false},
+		{"dark and occupied", true, 100, true},
+		{"within the hysteresis", true, 320, true},
+		{"bright", true, 400, false},
+		{"within the hysteresis once released", true, 320, false},
+		{"left", false, 100, false},
+	}
+	var state RuleState
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := RuleValues{}
+			values.Add(RuleSourceSensor, []string{"MAC", "office"}, map[string]interface{}{
+				"presence":   tt.presence,
+				"brightness": tt.brightness,
+			})
+			rule.Evaluate(values, &state, now)
+			if state.Active != tt.active {
+				t.Errorf("active %v, want %v", state.Active, tt.active)
+			}
+		})
+	}
+}
+
+func TestRulePoints(t *testing.T) {
+	led := RuleAction{Led: &dserver.LedCmd{Mac: "LED"}}
+	blind := RuleAction{Blind: &dserver.BlindCmd{Mac: "BLIND"}}
+	group := RuleAction{Group: &dserver.GroupCmd{Group: 2}}
+	tests := []struct {
+		name   string
+		rule   Rule
+		points []string
+	}{
+		{"actions", Rule{Actions: []RuleAction{led, group}}, []string{"led/LED", "group/2"}},
+		{"release actions", Rule{Actions: []RuleAction{led}, ReleaseActions: []RuleAction{led, blind}}, []string{"led/LED", "blind/BLIND"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := tt.rule.Points()
+			if !reflect.DeepEqual(points, tt.points) {
+				t.Errorf("points %v, want %v", points, tt.points)
+			}
+		})
+	}
+}
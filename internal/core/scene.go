@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+//Scene named setpoints preset recalled on several groups at once
+type Scene struct {
+	Name   string             `json:"name"`
+	Groups []dserver.GroupCmd `json:"groups"`
+}
+
+// ToJSON dump Scene struct
+func (s Scene) ToJSON() (string, error) {
+	inrec, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToScene convert map interface to Scene object
+func ToScene(val interface{}) (*Scene, error) {
+	var s Scene
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &s)
+	return &s, err
+}
+
+//Validate check that each group is commanded once
+func (s Scene) Validate() error {
+	if len(s.Groups) == 0 {
+		return errors.New("Scene without groups")
+	}
+	seen := make(map[int]bool)
+	for _, cmd := range s.Groups {
+		if seen[cmd.Group] {
+			return errors.New("Group " + strconv.Itoa(cmd.Group) + " set twice")
+		}
+		seen[cmd.Group] = true
+	}
+	return nil
+}
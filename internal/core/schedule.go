@@ -0,0 +1,144 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+)
+
+const (
+	SunEventSunrise = "sunrise"
+	SunEventSunset  = "sunset"
+)
+
+//ScheduleAction group command issued every selected week day at a given time
+//or relatively to the sunrise or the sunset
+type ScheduleAction struct {
+	Label              string `json:"label,omitempty"`
+	Days               []int  `json:"days,omitempty"`   //week days (0 for sunday), every day when empty
+	Time               string `json:"time,omitempty"`   //site time of day (HH:MM), computed for the solar events
+	Event              string `json:"event,omitempty"`  //sunrise or sunset
+	Offset             int    `json:"offset,omitempty"` //minutes after the solar event, negative before
+	Auto               *bool  `json:"auto,omitempty"`
+	SetpointLeds       *int   `json:"setpointLeds,omitempty"`
+	SetpointBlinds     *int   `json:"setpointBlinds,omitempty"`
+	SetpointSlats      *int   `json:"setpointSlats,omitempty"`
+	SetpointTempOffset *int   `json:"setpointTempOffset,omitempty"`
+}
+
+//Schedule weekly calendar of a group
+type Schedule struct {
+	Group   int              `json:"group"`
+	Actions []ScheduleAction `json:"actions"`
+}
+
+//ScheduleEvent occurrence of a schedule action
+type ScheduleEvent struct {
+	Date   time.Time
+	Group  int
+	Action ScheduleAction
+}
+
+//SchedulerStatus last time the schedules were applied
+type SchedulerStatus struct {
+	LastRun string `json:"lastRun"`
+}
+
+// ToJSON dump Schedule struct
+func (s Schedule) ToJSON() (string, error) {
+	inrec, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToSchedule convert map interface to Schedule object
+func ToSchedule(val interface{}) (*Schedule, error) {
+	var s Schedule
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &s)
+	return &s, err
+}
+
+//ToSchedulerStatus convert map interface to SchedulerStatus object
+func ToSchedulerStatus(val interface{}) (*SchedulerStatus, error) {
+	var s SchedulerStatus
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &s)
+	return &s, err
+}
+
+func inDays(day time.Weekday, days []int) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if d == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+//Validate check the action times and days
+func (s Schedule) Validate() error {
+	for _, action := range s.Actions {
+		switch action.Event {
+		case SunEventSunrise, SunEventSunset:
+		case "":
+			_, err := minutes(action.Time)
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New("Invalid event " + action.Event)
+		}
+		for _, day := range action.Days {
+			if day < 0 || day > 6 {
+				return errors.New("Invalid week day " + strconv.Itoa(day))
+			}
+		}
+	}
+	return nil
+}
+
+//GroupCmd return the group command of the action
+func (a ScheduleAction) GroupCmd(group int) dserver.GroupCmd {
+	return dserver.GroupCmd{
+		Group:              group,
+		Auto:               a.Auto,
+		SetpointLeds:       a.SetpointLeds,
+		SetpointBlinds:     a.SetpointBlinds,
+		SetpointSlats:      a.SetpointSlats,
+		SetpointTempOffset: a.SetpointTempOffset,
+	}
+}
+
+//Merge override the command values set by the action
+func (a ScheduleAction) Merge(cmd *dserver.GroupCmd) {
+	if a.Auto != nil {
+		cmd.Auto = a.Auto
+	}
+	if a.SetpointLeds != nil {
+		cmd.SetpointLeds = a.SetpointLeds
+	}
+	if a.SetpointBlinds != nil {
+		cmd.SetpointBlinds = a.SetpointBlinds
+	}
+	if a.SetpointSlats != nil {
+		cmd.SetpointSlats = a.SetpointSlats
+	}
+	if a.SetpointTempOffset != nil {
+		cmd.SetpointTempOffset = a.SetpointTempOffset
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	julianUnixEpoch = 2440587.5 //julian day of 1970-01-01
+	julianJ2000     = 2451545.0 //julian day of 2000-01-01 12:00 UTC
+	earthTilt       = 23.4397   //degrees
+	sunsetElevation = -0.833    //degrees, refraction and solar disc radius
+)
+
+//SunTimes solar events of one day
+type SunTimes struct {
+	Date     string     `json:"date"`               //YYYY-MM-DD
+	Sunrise  *time.Time `json:"sunrise,omitempty"`  //missing during the polar night and the midnight sun
+	Noon     time.Time  `json:"noon"`               //solar noon
+	Sunset   *time.Time `json:"sunset,omitempty"`   //missing during the polar night and the midnight sun
+	Daylight int        `json:"daylight"`           //minutes
+	Location string     `json:"location,omitempty"` //time zone
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+func toJulian(date time.Time) float64 {
+	return float64(date.Unix())/86400 + julianUnixEpoch
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-julianUnixEpoch)*86400)), 0)
+}
+
+//solarTransit return the julian date of the solar noon closest to date, the sun ecliptic longitude
+//and the mean anomaly of the day
+func solarTransit(date time.Time, longitude float64) (float64, float64, float64) {
+	//the day is counted at the longitude, far from Greenwich the UTC day may differ
+	n := math.Round(toJulian(date) - julianJ2000 + longitude/360)
+	mean := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*mean, 360)
+	m := radians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.02*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	ecliptic := radians(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := julianJ2000 + mean + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*ecliptic)
+	return transit, ecliptic, m
+}
+
+//GetSunTimes return the sunrise, solar noon and sunset of the day in the site time zone
+func (s SiteConfig) GetSunTimes(day time.Time) SunTimes {
+	loc := s.Location()
+	day = day.In(loc)
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, loc)
+	transit, ecliptic, _ := solarTransit(noon, s.Longitude)
+
+	res := SunTimes{
+		Date:     day.Format(DateFormat),
+		Noon:     fromJulian(transit).In(loc),
+		Location: loc.String(),
+	}
+	declination := math.Asin(math.Sin(ecliptic) * math.Sin(radians(earthTilt)))
+	lat := radians(s.Latitude)
+	cosHour := (math.Sin(radians(sunsetElevation)) - math.Sin(lat)*math.Sin(declination)) / (math.Cos(lat) * math.Cos(declination))
+	if cosHour > 1 {
+		//polar night
+		return res
+	}
+	if cosHour < -1 {
+		//midnight sun
+		res.Daylight = 24 * 60
+		return res
+	}
+	hourAngle := degrees(math.Acos(cosHour))
+	sunrise := fromJulian(transit - hourAngle/360).In(loc)
+	sunset := fromJulian(transit + hourAngle/360).In(loc)
+	res.Sunrise = &sunrise
+	res.Sunset = &sunset
+	res.Daylight = int(sunset.Sub(sunrise) / time.Minute)
+	return res
+}
+
+//SunPosition return the sun azimuth (degrees from the north, clockwise) and elevation (degrees) at date
+func (s SiteConfig) SunPosition(date time.Time) (float64, float64) {
+	d := toJulian(date) - julianJ2000
+	anomaly := radians(math.Mod(357.529+0.98560028*d, 360))
+	mean := math.Mod(280.459+0.98564736*d, 360)
+	ecliptic := radians(mean + 1.915*math.Sin(anomaly) + 0.020*math.Sin(2*anomaly))
+	obliquity := radians(earthTilt - 0.00000036*d)
+	ascension := math.Atan2(math.Cos(obliquity)*math.Sin(ecliptic), math.Cos(ecliptic))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(ecliptic))
+
+	sidereal := math.Mod(280.46061837+360.98564736629*d+s.Longitude, 360)
+	hourAngle := radians(sidereal) - ascension
+	lat := radians(s.Latitude)
+
+	elevation := math.Asin(math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle))
+	azimuth := math.Atan2(-math.Sin(hourAngle), math.Tan(declination)*math.Cos(lat)-math.Sin(lat)*math.Cos(hourAngle))
+	return math.Mod(degrees(azimuth)+360, 360), degrees(elevation)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetSunTimes(t *testing.T) {
+	paris := SiteConfig{Latitude: 48.8566, Longitude: 2.3522, TimeZone: "Europe/Paris"}
+	tromso := SiteConfig{Latitude: 69.6496, Longitude: 18.956, TimeZone: "Europe/Oslo"}
+	mcmurdo := SiteConfig{Latitude: -77.85, Longitude: 166.67, TimeZone: "Antarctica/McMurdo"}
+	tests := []struct {
+		name     string
+		site     SiteConfig
+		day      string
+		sunrise  string //local time, empty when the sun does not rise or set
+		sunset   string
+		daylight int //minutes
+	}{
+		{"summer solstice", paris, "2024-06-21", "05:47", "21:58", 970},
+		{"winter solstice", paris, "2024-12-21", "08:41", "16:56", 494},
+		{"day before the summer time", paris, "2024-03-30", "06:32", "19:18", 766},
+		{"summer time", paris, "2024-03-31", "07:30", "20:19", 769},
+		{"winter time", paris, "2024-10-27", "07:30", "17:39", 609},
+		{"midnight sun", tromso, "2024-06-21", "", "", 24 * 60},
+		{"polar night", tromso, "2024-12-21", "", "", 0},
+		{"southern polar night", mcmurdo, "2024-06-21", "", "", 0},
+		{"southern midnight sun", mcmurdo, "2024-12-21", "", "", 24 * 60},
+	}
+	const tolerance = 2 * time.Minute
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := tt.site.Location()
+			day, _ := time.ParseInLocation(DateFormat, tt.day, loc)
+			res := tt.site.GetSunTimes(day)
+			if res.Date != tt.day || res.Noon.In(loc).Format(DateFormat) != tt.day {
+				t.Errorf("solar noon %v of %v, want %v", res.Noon, res.Date, tt.day)
+			}
+			if res.Daylight < tt.daylight-2 || res.Daylight > tt.daylight+2 {
+				t.Errorf("daylight %v, want %v", res.Daylight, tt.daylight)
+			}
+			check := func(event string, got *time.Time, want string) {
+				if want == "" {
+					if got != nil {
+						t.Errorf("unexpected %v %v", event, got)
+					}
+					return
+				}
+				expected, _ := time.ParseInLocation(DateFormat+" 15:04", tt.day+" "+want, loc)
+				if got == nil || got.Sub(expected) > tolerance || expected.Sub(*got) > tolerance {
+					t.Errorf("%v %v, want %v", event, got, expected)
+				}
+			}
+			check("sunrise", res.Sunrise, tt.sunrise)
+			check("sunset", res.Sunset, tt.sunset)
+		})
+	}
+}
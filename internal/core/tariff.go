@@ -0,0 +1,132 @@
+package core
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	//TimeOfDayFormat time of day format of the tariff periods and of the schedule actions
+	TimeOfDayFormat = "15:04"
+)
+
+//TariffPeriod time-of-use price
+type TariffPeriod struct {
+	Label string  `json:"label"`
+	Days  []int   `json:"days,omitempty"` //week days (0 for sunday), every day when empty
+	Start string  `json:"start"`          //local time of day (HH:MM)
+	End   string  `json:"end"`            //local time of day (HH:MM), can be before start for a period over midnight
+	Price float64 `json:"price"`          //per kWh
+}
+
+//Tariff energy prices and grid emission factor
+type Tariff struct {
+	Label          string         `json:"label"`
+	Start          *string        `json:"start,omitempty"` //RFC3339 date from which the tariff applies
+	Currency       string         `json:"currency"`
+	Price          float64        `json:"price"`          //per kWh outside the periods
+	EmissionFactor float64        `json:"emissionFactor"` //gCO2e per kWh
+	Periods        []TariffPeriod `json:"periods,omitempty"`
+}
+
+// ToJSON dump Tariff struct
+func (t Tariff) ToJSON() (string, error) {
+	inrec, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(inrec), err
+}
+
+//ToTariff convert map interface to Tariff object
+func ToTariff(val interface{}) (*Tariff, error) {
+	var t Tariff
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &t)
+	return &t, err
+}
+
+//minutes return the number of minutes since midnight
+func minutes(value string) (int, error) {
+	date, err := time.Parse(TimeOfDayFormat, value)
+	if err != nil {
+		return 0, err
+	}
+	return date.Hour()*60 + date.Minute(), nil
+}
+
+//Contains check if the time of date in the site location is in the period
+func (p TariffPeriod) Contains(date time.Time, loc *time.Location) bool {
+	date = date.In(loc)
+	if len(p.Days) > 0 {
+		found := false
+		for _, day := range p.Days {
+			if day == int(date.Weekday()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	start, err := minutes(p.Start)
+	if err != nil {
+		return false
+	}
+	end, err := minutes(p.End)
+	if err != nil {
+		return false
+	}
+	now := date.Hour()*60 + date.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+//Validate check the tariff dates and periods
+func (t Tariff) Validate() error {
+	if t.Start != nil {
+		_, err := time.Parse(time.RFC3339, *t.Start)
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range t.Periods {
+		_, err := minutes(p.Start)
+		if err != nil {
+			return err
+		}
+		_, err = minutes(p.End)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//PriceAt return the energy price at date, the periods are in the site location
+func (t Tariff) PriceAt(date time.Time, loc *time.Location) float64 {
+	for _, p := range t.Periods {
+		if p.Contains(date, loc) {
+			return p.Price
+		}
+	}
+	return t.Price
+}
+
+//StartDate return the date from which the tariff applies, nil when it always applies
+func (t Tariff) StartDate() *time.Time {
+	if t.Start == nil {
+		return nil
+	}
+	date, err := time.Parse(time.RFC3339, *t.Start)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
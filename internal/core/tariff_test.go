@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTariffPriceAt(t *testing.T) {
+	tariff := Tariff{
+		Price: 0.2,
+		Periods: []TariffPeriod{
+			{Label: "night", Start: "22:00", End: "06:00", Price: 0.1},
+			{Label: "monday start", Days: []int{1}, Start: "06:00", End: "07:00", Price: 0.3},
+		},
+	}
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := []struct {
+		name  string
+		date  string
+		loc   *time.Location
+		price float64
+	}{
+		{"night in the site location", "2024-01-10T21:30:00Z", paris, 0.1},
+		{"day in UTC", "2024-01-10T21:30:00Z", time.UTC, 0.2},
+		{"end of the night in summer time", "2024-07-10T03:59:00Z", paris, 0.1},
+		{"day in summer time", "2024-07-10T04:00:00Z", paris, 0.2},
+		{"monday start in the site location", "2024-01-08T05:30:00Z", paris, 0.3},
+		{"monday night in UTC", "2024-01-08T05:30:00Z", time.UTC, 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse(time.RFC3339, tt.date)
+			if price := tariff.PriceAt(date, tt.loc); price != tt.price {
+				t.Errorf("price %v, want %v", price, tt.price)
+			}
+		})
+	}
+}
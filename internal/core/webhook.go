@@ -0,0 +1,162 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+const (
+	WebhookEventAlarm         = "alarm"
+	WebhookEventSwitchOffline = "switchOffline"
+	WebhookEventSwitchOnline  = "switchOnline"
+	WebhookEventCommissioning = "commissioning"
+	WebhookEventAdd           = "add"
+	WebhookEventUpdate        = "update"
+	WebhookEventRemove        = "remove"
+	WebhookEventTest          = "test"
+
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+
+	WebhookSignatureHeader = "X-EIP-Signature"
+	WebhookTimestampHeader = "X-EIP-Timestamp" //attempt date (RFC3339 UTC), signed with the body
+	WebhookEventHeader     = "X-EIP-Event"
+	WebhookDeliveryHeader  = "X-EIP-Delivery"
+
+	//WebhookSecretMask returned instead of the secret, which is write-only
+	WebhookSecretMask = "***"
+)
+
+//WebhookEvents event types a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventAlarm, WebhookEventSwitchOffline, WebhookEventSwitchOnline,
+	WebhookEventCommissioning, WebhookEventAdd, WebhookEventUpdate, WebhookEventRemove}
+
+//WebhookDefaultEvents event types notified when a webhook has no subscription,
+//the frequent status updates must be subscribed explicitly
+var WebhookDefaultEvents = []string{WebhookEventAlarm, WebhookEventSwitchOffline, WebhookEventSwitchOnline,
+	WebhookEventCommissioning, WebhookEventAdd, WebhookEventRemove}
+
+//WebhookCriticalEvents event types which are never dropped
+var WebhookCriticalEvents = []string{WebhookEventAlarm, WebhookEventSwitchOffline, WebhookEventSwitchOnline}
+
+//Webhook HTTP endpoint notified of the subscribed events
+type Webhook struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"` //HMAC-SHA256 key of the payload signature, unsigned when empty
+	Events  []string `json:"events"`           //subscribed event types, the default ones when empty
+	Enabled bool     `json:"enabled"`
+}
+
+//WebhookPayload JSON body posted to a webhook
+type WebhookPayload struct {
+	Delivery string      `json:"delivery"`
+	Event    string      `json:"event"`
+	Date     string      `json:"date"` //event date (RFC3339 UTC)
+	Data     interface{} `json:"data"`
+}
+
+//WebhookDelivery delivery log entry of an event to a webhook
+type WebhookDelivery struct {
+	ID          string `json:"id,omitempty"`
+	Webhook     string `json:"webhook"`
+	Event       string `json:"event"`
+	Date        string `json:"date"`   //event date (RFC3339 UTC)
+	Status      string `json:"status"` //pending, delivered or failed
+	Attempts    int    `json:"attempts"`
+	StatusCode  int    `json:"statusCode"` //HTTP status of the last attempt, 0 when not reached
+	Error       string `json:"error"`      //last attempt error
+	LastAttempt string `json:"lastAttempt"`
+}
+
+//ToWebhook convert map interface to Webhook object
+func ToWebhook(val interface{}) (*Webhook, error) {
+	var w Webhook
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &w)
+	return &w, err
+}
+
+//ToWebhookDelivery convert map interface to WebhookDelivery object
+func ToWebhookDelivery(val interface{}) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &d)
+	return &d, err
+}
+
+//Validate check the URL and the event types
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Invalid URL " + w.URL)
+	}
+	for _, evt := range w.Events {
+		found := false
+		for _, known := range WebhookEvents {
+			if evt == known {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("Invalid event " + evt)
+		}
+	}
+	return nil
+}
+
+//Subscribed check if the webhook is notified of the event type
+func (w Webhook) Subscribed(evtType string) bool {
+	if !w.Enabled {
+		return false
+	}
+	events := w.Events
+	if len(events) == 0 {
+		events = WebhookDefaultEvents
+	}
+	for _, evt := range events {
+		if evt == evtType {
+			return true
+		}
+	}
+	return false
+}
+
+//IsCriticalWebhookEvent check if the event type is never dropped
+func IsCriticalWebhookEvent(evtType string) bool {
+	for _, evt := range WebhookCriticalEvents {
+		if evt == evtType {
+			return true
+		}
+	}
+	return false
+}
+
+//Masked return the webhook with its secret hidden
+func (w Webhook) Masked() Webhook {
+	if w.Secret != "" {
+		w.Secret = WebhookSecretMask
+	}
+	return w
+}
+
+//Sign return the signature header value of a payload posted at timestamp,
+//the signed content is the timestamp, a dot and the body so that a replayed payload can be detected
+func (w Webhook) Sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,25 @@
+package core
+
+import "testing"
+
+func TestWebhookSubscribed(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    Webhook
+		evtType    string
+		subscribed bool
+	}{
+		{"default events", Webhook{Enabled: true}, WebhookEventAlarm, true},
+		{"no update by default", Webhook{Enabled: true}, WebhookEventUpdate, false},
+		{"update subscribed", Webhook{Enabled: true, Events: []string{WebhookEventUpdate}}, WebhookEventUpdate, true},
+		{"not subscribed", Webhook{Enabled: true, Events: []string{WebhookEventUpdate}}, WebhookEventAlarm, false},
+		{"disabled", Webhook{Events: []string{WebhookEventAlarm}}, WebhookEventAlarm, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if subscribed := tt.webhook.Subscribed(tt.evtType); subscribed != tt.subscribed {
+				t.Errorf("subscribed %v, want %v", subscribed, tt.subscribed)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbAlarms = "alarms"
+)
+
+//SaveAlarm dump alarm in database and return its ID
+func SaveAlarm(db Database, alarm core.Alarm) (string, error) {
+	if alarm.ID == "" {
+		return db.InsertRecord(pconst.DbStatus, TbAlarms, alarm)
+	}
+	return alarm.ID, db.UpdateRecord(pconst.DbStatus, TbAlarms, alarm.ID, alarm)
+}
+
+//GetAlarm return the alarm
+func GetAlarm(db Database, id string) *core.Alarm {
+	criteria := make(map[string]interface{})
+	criteria["id"] = id
+	stored, err := db.GetRecord(pconst.DbStatus, TbAlarms, criteria)
+	if err != nil || stored == nil {
+		return nil
+	}
+	alarm, err := core.ToAlarm(stored)
+	if err != nil {
+		return nil
+	}
+	alarm.ID = id
+	return alarm
+}
+
+//GetAlarms return the alarms matching the filter, the most recent first
+func GetAlarms(db Database, f core.AlarmFilter) []core.Alarm {
+	alarms := []core.Alarm{}
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbAlarms)
+	if err != nil || stored == nil {
+		return alarms
+	}
+	for _, st := range stored {
+		alarm, err := core.ToAlarm(st)
+		if err != nil || alarm == nil {
+			continue
+		}
+		m := st.(map[string]interface{})
+		id, ok := m["id"]
+		if ok {
+			alarm.ID = id.(string)
+		}
+		if !f.Match(*alarm) {
+			continue
+		}
+		alarms = append(alarms, *alarm)
+	}
+	sort.Slice(alarms, func(i, j int) bool {
+		return alarms[i].Raised > alarms[j].Raised
+	})
+	return alarms
+}
+
+//GetOpenAlarms return the alarms not closed by their key
+func GetOpenAlarms(db Database) map[string]core.Alarm {
+	alarms := make(map[string]core.Alarm)
+	for _, alarm := range GetAlarms(db, core.AlarmFilter{}) {
+		if alarm.Closed() {
+			continue
+		}
+		alarms[alarm.Key()] = alarm
+	}
+	return alarms
+}
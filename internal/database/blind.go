@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/energieip/common-components-go/pkg/dblind"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
 
@@ -286,3 +287,49 @@ func GetBlindStatus(db Database, mac string) *dblind.Blind {
 	}
 	return driver
 }
+
+//blind tables read by the status and dump requests
+var (
+	blindsStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbBlinds, switchMac: true, group: true, configured: true}
+	blindsConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbBlinds, switchMac: true, group: true}
+)
+
+//GetBlindsStatusPage return the page of the blind status matching the filter and the number of matching blinds
+func GetBlindsStatusPage(db Database, f DriverFilter, p Page) ([]dblind.Blind, int) {
+	var drivers []dblind.Blind
+	stored, total := driverPage(db, blindsStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := dblind.ToBlind(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetBlindsDumpPage return the page of the project blinds with their status and config matching the filter
+//and the number of matching blinds
+func GetBlindsDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpBlind, int) {
+	var dumps []dserver.DumpBlind
+	records, total := driverDumpPage(db, pconst.BLIND, blindsStatusTable, blindsConfigTable, f, p, driverKeys)
+	for _, rec := range records {
+		dump := dserver.DumpBlind{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := dblind.ToBlind(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := dblind.ToBlindSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbCalendars = "calendars"
+)
+
+//SaveCalendar dump calendar in database
+func SaveCalendar(db Database, cfg core.Calendar) error {
+	calendar, dbID := GetCalendar(db, cfg.Name)
+	if calendar == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbCalendars, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbCalendars, dbID, cfg)
+}
+
+//RemoveCalendar remove calendar entry in database
+func RemoveCalendar(db Database, name string) error {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	return db.DeleteRecord(pconst.DbConfig, TbCalendars, criteria)
+}
+
+//GetCalendar return the calendar configuration
+func GetCalendar(db Database, name string) (*core.Calendar, string) {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	stored, err := db.GetRecord(pconst.DbConfig, TbCalendars, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	calendar, err := core.ToCalendar(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return calendar, dbID
+}
+
+//GetCalendars return the calendars configuration
+func GetCalendars(db Database) []core.Calendar {
+	var calendars []core.Calendar
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbCalendars)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		calendar, err := core.ToCalendar(st)
+		if err != nil || calendar == nil {
+			continue
+		}
+		calendars = append(calendars, *calendar)
+	}
+	return calendars
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbCommandAcks = "commandacks"
+)
+
+//SaveCommandAck dump command acknowledgement in database and return its ID
+func SaveCommandAck(db Database, ack core.CommandAck) (string, error) {
+	if ack.ID == "" {
+		return db.InsertRecord(pconst.DbStatus, TbCommandAcks, ack)
+	}
+	return ack.ID, db.UpdateRecord(pconst.DbStatus, TbCommandAcks, ack.ID, ack)
+}
+
+func toCommandAck(stored interface{}) *core.CommandAck {
+	ack, err := core.ToCommandAck(stored)
+	if err != nil || ack == nil {
+		return nil
+	}
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		ack.ID = id.(string)
+	}
+	return ack
+}
+
+//GetCommandAck return the command acknowledgement
+func GetCommandAck(db Database, id string) *core.CommandAck {
+	criteria := make(map[string]interface{})
+	criteria["id"] = id
+	stored, err := db.GetRecord(pconst.DbStatus, TbCommandAcks, criteria)
+	if err != nil || stored == nil {
+		return nil
+	}
+	return toCommandAck(stored)
+}
+
+//GetPendingCommandAcks return the commands waiting for their acknowledgement
+func GetPendingCommandAcks(db Database) []core.CommandAck {
+	acks := []core.CommandAck{}
+	criteria := make(map[string]interface{})
+	criteria["Status"] = core.CommandPending
+	stored, err := db.GetRecords(pconst.DbStatus, TbCommandAcks, criteria)
+	if err != nil || stored == nil {
+		return acks
+	}
+	for _, st := range stored {
+		ack := toCommandAck(st)
+		if ack != nil {
+			acks = append(acks, *ack)
+		}
+	}
+	return acks
+}
+
+//RemoveCommandAcks remove the resolved command acknowledgements older than the date
+func RemoveCommandAcks(db Database, before string) {
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbCommandAcks)
+	if err != nil || stored == nil {
+		return
+	}
+	for _, st := range stored {
+		ack := toCommandAck(st)
+		if ack == nil || ack.ID == "" || ack.IsPending() || ack.Date >= before {
+			continue
+		}
+		criteria := make(map[string]interface{})
+		criteria["id"] = ack.ID
+		db.DeleteRecord(pconst.DbStatus, TbCommandAcks, criteria)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	pkg "github.com/energieip/common-components-go/pkg/service"
 	"github.com/energieip/srv200-coreservice-go/internal/core"
 	"github.com/romana/rlog"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v5"
 )
 
 type databaseError struct {
@@ -30,7 +31,15 @@ func NewError(text string) error {
 	return &databaseError{text}
 }
 
-type Database = database.DatabaseInterface
+//Database config and status database: the common database completed with the paginated queries
+type Database interface {
+	database.DatabaseInterface
+	//GetPage return the page of the records of a table and the number of records matching the page filters
+	GetPage(dbName, tbName string, p Page) ([]interface{}, int, error)
+	//GetJoinedPage return the page of the records of a table joined by label with their status and config,
+	//and the number of records matching the page filters
+	GetJoinedPage(dbName, tbName string, j JoinedPage) ([]JoinedRecord, int, error)
+}
 
 //ConnectDatabase plug datbase
 func ConnectDatabase(ip, port string) (*Database, error) {
@@ -50,6 +59,18 @@ func ConnectDatabase(ip, port string) (*Database, error) {
 		return nil, err
 	}
 
+	session, err := r.Connect(r.ConnectOpts{
+		Address: ip + ":" + port,
+	})
+	if err != nil {
+		rlog.Error("Cannot connect to database " + err.Error())
+		return nil, err
+	}
+	pdb := &pageDb{
+		DatabaseInterface: db,
+		session:           session,
+	}
+
 	for _, dbName := range []string{pconst.DbConfig, pconst.DbStatus} {
 		err = db.CreateDB(dbName)
 		if err != nil {
@@ -71,6 +92,13 @@ func ConnectDatabase(ip, port string) (*Database, error) {
 			tableCfg[pconst.TbFrames] = dserver.Frame{}
 			tableCfg[pconst.TbWagos] = dwago.WagoSetup{}
 			tableCfg[pconst.TbNanosenses] = dnanosense.NanosenseSetup{}
+			tableCfg[TbTariffs] = core.Tariff{}
+			tableCfg[TbSchedules] = core.Schedule{}
+			tableCfg[TbCalendars] = core.Calendar{}
+			tableCfg[TbFacades] = core.Facade{}
+			tableCfg[TbScenes] = core.Scene{}
+			tableCfg[TbRules] = core.Rule{}
+			tableCfg[TbWebhooks] = core.Webhook{}
 		} else {
 			tableCfg[pconst.TbLeds] = dl.Led{}
 			tableCfg[pconst.TbSensors] = ds.Sensor{}
@@ -81,6 +109,12 @@ func ConnectDatabase(ip, port string) (*Database, error) {
 			tableCfg[pconst.TbBlinds] = dblind.Blind{}
 			tableCfg[pconst.TbWagos] = dwago.Wago{}
 			tableCfg[pconst.TbNanosenses] = dnanosense.Nanosense{}
+			tableCfg[TbScheduler] = core.SchedulerStatus{}
+			tableCfg[TbPriorities] = core.PriorityArray{}
+			tableCfg[TbAlarms] = core.Alarm{}
+			tableCfg[TbSwitchsOffline] = core.OfflineSwitch{}
+			tableCfg[TbWebhookDeliveries] = core.WebhookDelivery{}
+			tableCfg[TbCommandAcks] = core.CommandAck{}
 		}
 		for tableName, objs := range tableCfg {
 			err = db.CreateTable(dbName, tableName, &objs)
@@ -88,9 +122,17 @@ func ConnectDatabase(ip, port string) (*Database, error) {
 				rlog.Warn("Create table ", err.Error())
 			}
 		}
+		pdb.createLabelIndexes(dbName)
 	}
 
-	return &db, nil
+	var res Database = pdb
+	return &res, nil
+}
+
+//Ping check the database connectivity with a request on a small table
+func Ping(db Database) error {
+	_, err := db.FetchAllRecords(pconst.DbConfig, pconst.TbServices)
+	return err
 }
 
 func PrepareDB(db Database, withDrop bool) {
@@ -122,6 +164,9 @@ func PrepareDB(db Database, withDrop bool) {
 			tableCfg[pconst.TbBlinds] = dblind.Blind{}
 			tableCfg[pconst.TbWagos] = dwago.Wago{}
 			tableCfg[pconst.TbNanosenses] = dnanosense.Nanosense{}
+			tableCfg[TbPriorities] = core.PriorityArray{}
+			tableCfg[TbCommandAcks] = core.CommandAck{}
+			tableCfg[TbSwitchsOffline] = core.OfflineSwitch{}
 		}
 		for tableName, objs := range tableCfg {
 			if withDrop {
@@ -136,6 +181,9 @@ func PrepareDB(db Database, withDrop bool) {
 				rlog.Warn("Create table ", err.Error())
 			}
 		}
+		if pdb, ok := db.(*pageDb); ok {
+			pdb.createLabelIndexes(dbName)
+		}
 	}
 
 }
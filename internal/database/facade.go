@@ -0,0 +1,82 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbFacades = "facades"
+)
+
+func facadeCriteria(cfg core.Facade) map[string]interface{} {
+	criteria := make(map[string]interface{})
+	if cfg.Label != "" {
+		criteria["Label"] = cfg.Label
+	} else if cfg.Group != nil {
+		criteria["Group"] = *cfg.Group
+	}
+	return criteria
+}
+
+//SaveFacade dump blind or group facade in database
+func SaveFacade(db Database, cfg core.Facade) error {
+	stored, err := db.GetRecord(pconst.DbConfig, TbFacades, facadeCriteria(cfg))
+	if err != nil || stored == nil {
+		_, err := db.InsertRecord(pconst.DbConfig, TbFacades, cfg)
+		return err
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbFacades, dbID, cfg)
+}
+
+//RemoveFacade remove blind or group facade entry in database
+func RemoveFacade(db Database, cfg core.Facade) error {
+	return db.DeleteRecord(pconst.DbConfig, TbFacades, facadeCriteria(cfg))
+}
+
+//GetFacade return the blind or group facade
+func GetFacade(db Database, cfg core.Facade) *core.Facade {
+	stored, err := db.GetRecord(pconst.DbConfig, TbFacades, facadeCriteria(cfg))
+	if err != nil || stored == nil {
+		return nil
+	}
+	facade, err := core.ToFacade(stored)
+	if err != nil {
+		return nil
+	}
+	return facade
+}
+
+//GetFacades return the blinds and groups facades
+func GetFacades(db Database) []core.Facade {
+	var facades []core.Facade
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbFacades)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		facade, err := core.ToFacade(st)
+		if err != nil || facade == nil {
+			continue
+		}
+		facades = append(facades, *facade)
+	}
+	return facades
+}
+
+//GetGroupFacades return the group facades completed with their blinds orientation
+func GetGroupFacades(db Database) []core.Facade {
+	blindGroups := make(map[string]int)
+	for label, blind := range GetBlindsConfigByLabel(db) {
+		if blind.Group != nil {
+			blindGroups[label] = *blind.Group
+		}
+	}
+	return core.ResolveGroupFacades(GetFacades(db), blindGroups)
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/romana/rlog"
+)
+
+//DriverFilter driver query criteria, the empty fields are not filtered
+type DriverFilter struct {
+	SwitchMac    string
+	Group        *int
+	Cluster      *int //the leds, sensors, blinds and hvacs are in the cluster of their switch
+	IsConfigured *bool
+}
+
+//driverTable criteria available in a driver table
+type driverTable struct {
+	dbName     string
+	tbName     string
+	switchMac  bool
+	group      bool
+	cluster    bool
+	configured bool
+}
+
+//driverKeys keys of the dumped drivers attached to a switch: the status values and the config ones
+//when the driver is not seen
+var driverKeys = map[string][]string{
+	"Group":     {"status.Group", "config.Group"},
+	"SwitchMac": {"status.SwitchMac", "config.SwitchMac"},
+	"Error":     {"status.Error"},
+}
+
+//dumpRecord project driver with its status and config records
+type dumpRecord struct {
+	ifc    dserver.IfcInfo
+	status interface{}
+	config interface{}
+}
+
+//clusterSwitchs return the macs of the switchs of a cluster
+func clusterSwitchs(db Database, cluster int) []interface{} {
+	var res []interface{}
+	for _, sw := range GetCluster(db, cluster) {
+		if sw.Mac != nil {
+			res = append(res, *sw.Mac)
+		}
+	}
+	return res
+}
+
+//restrict return the page completed with criteria and allowed values of the keys
+func (p Page) restrict(criteria map[string]interface{}, in map[string][]interface{}) Page {
+	res := p
+	res.Criteria = make(map[string]interface{})
+	res.In = make(map[string][]interface{})
+	for _, elt := range []map[string]interface{}{p.Criteria, criteria} {
+		for name, value := range elt {
+			res.Criteria[name] = value
+		}
+	}
+	for _, elt := range []map[string][]interface{}{p.In, in} {
+		for name, values := range elt {
+			res.In[name] = values
+		}
+	}
+	return res
+}
+
+//driverJoin return the query of the filter on a driver table, the cluster is matched on the switchs
+//of the cluster when it cannot be queried in the table. False when no driver of the table can match
+func driverJoin(db Database, t driverTable, f DriverFilter) (*Join, bool) {
+	j := Join{
+		DbName:   t.dbName,
+		TbName:   t.tbName,
+		Criteria: make(map[string]interface{}),
+		In:       make(map[string][]interface{}),
+	}
+	if f.SwitchMac != "" {
+		if !t.switchMac {
+			return nil, false
+		}
+		j.Criteria["SwitchMac"] = f.SwitchMac
+	}
+	if f.Group != nil {
+		if !t.group {
+			return nil, false
+		}
+		j.Criteria["Group"] = *f.Group
+	}
+	if f.IsConfigured != nil && t.configured {
+		j.Criteria["IsConfigured"] = *f.IsConfigured
+	}
+	if f.Cluster != nil {
+		switch {
+		case t.cluster:
+			j.Criteria["Cluster"] = *f.Cluster
+		case t.switchMac:
+			switchs := clusterSwitchs(db, *f.Cluster)
+			if len(switchs) == 0 {
+				return nil, false
+			}
+			j.In["SwitchMac"] = switchs
+		default:
+			return nil, false
+		}
+	}
+	return &j, true
+}
+
+//driverPage return the page of a driver table matching the filter and the number of matching drivers
+func driverPage(db Database, t driverTable, f DriverFilter, p Page) ([]interface{}, int) {
+	j, ok := driverJoin(db, t, f)
+	if !ok {
+		return nil, 0
+	}
+	p = p.restrict(j.Criteria, j.In)
+
+	stored, total, err := db.GetPage(t.dbName, t.tbName, p)
+	if err != nil {
+		rlog.Error("Cannot read the page of " + t.dbName + "." + t.tbName + ": " + err.Error())
+		return nil, 0
+	}
+	return stored, total
+}
+
+//dumpPage return the page of the project drivers of a device type joined with their status and config
+//and the number of matching drivers
+func dumpPage(db Database, deviceType string, j JoinedPage) ([]dumpRecord, int) {
+	var res []dumpRecord
+	models := make(map[string]core.Model)
+	var names []interface{}
+	for name, model := range GetModels(db) {
+		if model.DeviceType == deviceType {
+			models[name] = model
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return res, 0
+	}
+	j.Page = j.restrict(nil, map[string][]interface{}{"ModelName": names})
+
+	stored, total, err := db.GetJoinedPage(pconst.DbConfig, pconst.TbProjects, j)
+	if err != nil {
+		rlog.Error("Cannot read the " + deviceType + " dump page: " + err.Error())
+		return res, 0
+	}
+	for _, elt := range stored {
+		project, err := core.ToProject(elt.Record)
+		if err != nil || project == nil || project.ModelName == nil {
+			continue
+		}
+		res = append(res, dumpRecord{
+			ifc:    toIfc(*project, models[*project.ModelName]),
+			status: elt.Status,
+			config: elt.Config,
+		})
+	}
+	return res, total
+}
+
+//driverDumpPage return the dump page of the drivers of a device type, when the filter restricts
+//the drivers only the ones with a status or a config matching it are returned
+func driverDumpPage(db Database, deviceType string, status, config driverTable, f DriverFilter, p Page, keys map[string][]string) ([]dumpRecord, int) {
+	j := JoinedPage{
+		Page:    p,
+		Keys:    keys,
+		Matched: f.SwitchMac != "" || f.Cluster != nil,
+	}
+	var statusOk, configOk bool
+	j.Status, statusOk = driverJoin(db, status, f)
+	j.Config, configOk = driverJoin(db, config, f)
+	if !statusOk && !configOk {
+		return nil, 0
+	}
+	return dumpPage(db, deviceType, j)
+}
@@ -80,82 +80,125 @@ func GetFramesConfigByLabel(db Database) map[string]dserver.Frame {
 	return projects
 }
 
+//frameStatus return the consumption and states of the switchs of a frame cluster
+func frameStatus(db Database, fr dserver.Frame) dserver.FrameStatus {
+	ledsPower := int64(0)
+	blindsPower := int64(0)
+	hvacsPower := int64(0)
+	totalPower := int64(0)
+	ledsEnergy := int64(0)
+	blindsEnergy := int64(0)
+	hvacsEnergy := int64(0)
+	totalEnergy := int64(0)
+	baes := 0
+	profil := "none"
+	puls1 := 1
+	puls2 := 1
+	puls3 := 1
+	puls4 := 1
+	puls5 := 1
+
+	clusters := GetSwitchStatusCluster(db, fr.Cluster)
+	for _, cl := range clusters {
+		ledsPower += cl.LedsPower
+		blindsPower += cl.BlindsPower
+		hvacsPower += cl.HvacsPower
+		ledsEnergy += cl.LedsEnergy
+		blindsEnergy += cl.BlindsEnergy
+		hvacsEnergy += cl.HvacsEnergy
+		totalPower += cl.TotalPower
+		totalEnergy += cl.TotalEnergy
+		if cl.StateBaes != 0 {
+			baes = cl.StateBaes
+		}
+		if cl.Profil == "puls" {
+			profil = "puls"
+			if cl.StatePuls1 == 0 {
+				puls1 = cl.StatePuls1
+			}
+			if cl.StatePuls2 == 0 {
+				puls2 = cl.StatePuls2
+			}
+			if cl.StatePuls3 == 0 {
+				puls3 = cl.StatePuls3
+			}
+			if cl.StatePuls4 == 0 {
+				puls4 = cl.StatePuls4
+			}
+			if cl.StatePuls5 == 0 {
+				puls5 = cl.StatePuls5
+			}
+		}
+	}
+
+	return dserver.FrameStatus{
+		Label:        fr.Label,
+		FriendlyName: fr.FriendlyName,
+		Cluster:      fr.Cluster,
+		LedsPower:    ledsPower,
+		BlindsPower:  blindsPower,
+		HvacsPower:   hvacsPower,
+		TotalPower:   totalPower,
+		LedsEnergy:   ledsEnergy,
+		BlindsEnergy: blindsEnergy,
+		HvacsEnergy:  hvacsEnergy,
+		TotalEnergy:  totalEnergy,
+		StateBaes:    baes,
+		Profil:       profil,
+		StatePuls1:   puls1,
+		StatePuls2:   puls2,
+		StatePuls3:   puls3,
+		StatePuls4:   puls4,
+		StatePuls5:   puls5,
+	}
+}
+
 //GetFramesDumpByLabel return the switch status list
 func GetFramesDumpByLabel(db Database) map[string]dserver.FrameStatus {
 	frames := make(map[string]dserver.FrameStatus)
 
 	configs := GetFramesConfigByLabel(db)
 	for _, fr := range configs {
-		ledsPower := int64(0)
-		blindsPower := int64(0)
-		hvacsPower := int64(0)
-		totalPower := int64(0)
-		ledsEnergy := int64(0)
-		blindsEnergy := int64(0)
-		hvacsEnergy := int64(0)
-		totalEnergy := int64(0)
-		baes := 0
-		profil := "none"
-		puls1 := 1
-		puls2 := 1
-		puls3 := 1
-		puls4 := 1
-		puls5 := 1
+		frames[fr.Label] = frameStatus(db, fr)
+	}
+	return frames
+}
 
-		clusters := GetSwitchStatusCluster(db, fr.Cluster)
-		for _, cl := range clusters {
-			ledsPower += cl.LedsPower
-			blindsPower += cl.BlindsPower
-			hvacsPower += cl.HvacsPower
-			ledsEnergy += cl.LedsEnergy
-			blindsEnergy += cl.BlindsEnergy
-			hvacsEnergy += cl.HvacsEnergy
-			totalPower += cl.TotalPower
-			totalEnergy += cl.TotalEnergy
-			if cl.StateBaes != 0 {
-				baes = cl.StateBaes
-			}
-			if cl.Profil == "puls" {
-				profil = "puls"
-				if cl.StatePuls1 == 0 {
-					puls1 = cl.StatePuls1
-				}
-				if cl.StatePuls2 == 0 {
-					puls2 = cl.StatePuls2
-				}
-				if cl.StatePuls3 == 0 {
-					puls3 = cl.StatePuls3
-				}
-				if cl.StatePuls4 == 0 {
-					puls4 = cl.StatePuls4
-				}
-				if cl.StatePuls5 == 0 {
-					puls5 = cl.StatePuls5
-				}
-			}
-		}
+//frameKeys keys of the dumped frames, they are attached to a cluster
+var frameKeys = map[string][]string{
+	"Cluster": {"config.Cluster"},
+}
 
-		frame := dserver.FrameStatus{
-			Label:        fr.Label,
-			FriendlyName: fr.FriendlyName,
-			Cluster:      fr.Cluster,
-			LedsPower:    ledsPower,
-			BlindsPower:  blindsPower,
-			HvacsPower:   hvacsPower,
-			TotalPower:   totalPower,
-			LedsEnergy:   ledsEnergy,
-			BlindsEnergy: blindsEnergy,
-			HvacsEnergy:  hvacsEnergy,
-			TotalEnergy:  totalEnergy,
-			StateBaes:    baes,
-			Profil:       profil,
-			StatePuls1:   puls1,
-			StatePuls2:   puls2,
-			StatePuls3:   puls3,
-			StatePuls4:   puls4,
-			StatePuls5:   puls5,
+//GetFramesDumpPage return the page of the project frames with their status and config matching the filter
+//and the number of matching frames
+func GetFramesDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpFrame, int) {
+	var dumps []dserver.DumpFrame
+	//frames are attached to a cluster
+	if f.SwitchMac != "" {
+		return dumps, 0
+	}
+	criteria := make(map[string]interface{})
+	if f.Cluster != nil {
+		criteria["Cluster"] = *f.Cluster
+	}
+	j := JoinedPage{
+		Page:   p.restrict(criteria, nil),
+		Config: &Join{DbName: pconst.DbConfig, TbName: pconst.TbFrames},
+		Keys:   frameKeys,
+	}
+	records, total := dumpPage(db, pconst.FRAME, j)
+	for _, rec := range records {
+		dump := dserver.DumpFrame{
+			Ifc: rec.ifc,
 		}
-		frames[fr.Label] = frame
+		if rec.config != nil {
+			fr, err := dserver.ToFrame(rec.config)
+			if err == nil && fr != nil {
+				dump.Config = *fr
+				dump.Status = frameStatus(db, *fr)
+			}
+		}
+		dumps = append(dumps, dump)
 	}
-	return frames
+	return dumps, total
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/energieip/common-components-go/pkg/dhvac"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
 
@@ -285,3 +286,49 @@ func GetHvacStatus(db Database, mac string) *dhvac.Hvac {
 	}
 	return driver
 }
+
+//hvac tables read by the status and dump requests
+var (
+	hvacsStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbHvacs, switchMac: true, group: true, configured: true}
+	hvacsConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbHvacs, switchMac: true, group: true}
+)
+
+//GetHvacsStatusPage return the page of the hvac status matching the filter and the number of matching hvacs
+func GetHvacsStatusPage(db Database, f DriverFilter, p Page) ([]dhvac.Hvac, int) {
+	var drivers []dhvac.Hvac
+	stored, total := driverPage(db, hvacsStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := dhvac.ToHvac(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetHvacsDumpPage return the page of the project hvacs with their status and config matching the filter
+//and the number of matching hvacs
+func GetHvacsDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpHvac, int) {
+	var dumps []dserver.DumpHvac
+	records, total := driverDumpPage(db, pconst.HVAC, hvacsStatusTable, hvacsConfigTable, f, p, driverKeys)
+	for _, rec := range records {
+		dump := dserver.DumpHvac{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := dhvac.ToHvac(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := dhvac.ToHvacSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
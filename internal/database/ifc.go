@@ -2,8 +2,32 @@ package database
 
 import (
 	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
 )
 
+func toIfc(project core.Project, model core.Model) dserver.IfcInfo {
+	mac := ""
+	if project.Mac != nil {
+		mac = *project.Mac
+	}
+	ifc := dserver.IfcInfo{
+		Label:          project.Label,
+		ModelName:      model.Name,
+		Mac:            mac,
+		Vendor:         model.Vendor,
+		URL:            model.URL,
+		DeviceType:     model.DeviceType,
+		ProductionYear: model.ProductionYear,
+	}
+	if project.ModbusID != nil {
+		ifc.ModbusID = project.ModbusID
+	}
+	if project.SlaveID != nil {
+		ifc.SlaveID = project.SlaveID
+	}
+	return ifc
+}
+
 func GetIfcs(db Database) []dserver.IfcInfo {
 	var res []dserver.IfcInfo
 	projects := GetProjects(db)
@@ -17,26 +41,7 @@ func GetIfcs(db Database) []dserver.IfcInfo {
 		if !ok {
 			continue
 		}
-		mac := ""
-		if project.Mac != nil {
-			mac = *project.Mac
-		}
-		ifc := dserver.IfcInfo{
-			Label:          project.Label,
-			ModelName:      model.Name,
-			Mac:            mac,
-			Vendor:         model.Vendor,
-			URL:            model.URL,
-			DeviceType:     model.DeviceType,
-			ProductionYear: model.ProductionYear,
-		}
-		if project.ModbusID != nil {
-			ifc.ModbusID = project.ModbusID
-		}
-		if project.SlaveID != nil {
-			ifc.SlaveID = project.SlaveID
-		}
-		res = append(res, ifc)
+		res = append(res, toIfc(project, model))
 	}
 	return res
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	dl "github.com/energieip/common-components-go/pkg/dled"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
 
@@ -288,3 +289,49 @@ func GetLedStatus(db Database, mac string) *dl.Led {
 	}
 	return light
 }
+
+//led tables read by the status and dump requests
+var (
+	ledsStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbLeds, switchMac: true, group: true, configured: true}
+	ledsConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbLeds, switchMac: true, group: true}
+)
+
+//GetLedsStatusPage return the page of the led status matching the filter and the number of matching leds
+func GetLedsStatusPage(db Database, f DriverFilter, p Page) ([]dl.Led, int) {
+	var drivers []dl.Led
+	stored, total := driverPage(db, ledsStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := dl.ToLed(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetLedsDumpPage return the page of the project leds with their status and config matching the filter
+//and the number of matching leds
+func GetLedsDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpLed, int) {
+	var dumps []dserver.DumpLed
+	records, total := driverDumpPage(db, pconst.LED, ledsStatusTable, ledsConfigTable, f, p, driverKeys)
+	for _, rec := range records {
+		dump := dserver.DumpLed{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := dl.ToLed(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := dl.ToLedSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
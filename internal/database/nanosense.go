@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/energieip/common-components-go/pkg/dnanosense"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
 
@@ -265,3 +266,55 @@ func GetNanosConfigByLabel(db Database) map[string]dnanosense.NanosenseSetup {
 	}
 	return drivers
 }
+
+//nanosense tables read by the status and dump requests
+var (
+	nanosStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbNanosenses, group: true, cluster: true, configured: true}
+	nanosConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbNanosenses, group: true, cluster: true}
+)
+
+//nanoKeys keys of the dumped nanosenses, they are attached to a cluster
+var nanoKeys = map[string][]string{
+	"Group": {"status.Group", "config.Group"},
+	"Error": {"status.Error"},
+}
+
+//GetNanosStatusPage return the page of the nanosense status matching the filter and the number of matching nanosenses
+func GetNanosStatusPage(db Database, f DriverFilter, p Page) ([]dnanosense.Nanosense, int) {
+	var drivers []dnanosense.Nanosense
+	stored, total := driverPage(db, nanosStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := dnanosense.ToNanosense(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetNanosDumpPage return the page of the project nanosenses with their status and config matching the filter
+//and the number of matching nanosenses
+func GetNanosDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpNanosense, int) {
+	var dumps []dserver.DumpNanosense
+	records, total := driverDumpPage(db, pconst.NANOSENSE, nanosStatusTable, nanosConfigTable, f, p, nanoKeys)
+	for _, rec := range records {
+		dump := dserver.DumpNanosense{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := dnanosense.ToNanosense(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := dnanosense.ToNanosenseSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
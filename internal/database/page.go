@@ -0,0 +1,264 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/energieip/common-components-go/pkg/database"
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/romana/rlog"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v5"
+)
+
+const (
+	//LabelIndex secondary index of the driver tables on the label, used by the joined pages
+	LabelIndex = "Label"
+	//maxArraySize maximum number of records sorted in one query
+	maxArraySize = 1000000
+)
+
+//labelIndexes tables joined by label to the project by the dump
+var labelIndexes = map[string][]string{
+	pconst.DbConfig: {pconst.TbLeds, pconst.TbSensors, pconst.TbBlinds, pconst.TbHvacs, pconst.TbWagos, pconst.TbNanosenses, pconst.TbSwitchs, pconst.TbFrames},
+	pconst.DbStatus: {pconst.TbLeds, pconst.TbSensors, pconst.TbBlinds, pconst.TbHvacs, pconst.TbWagos, pconst.TbNanosenses, pconst.TbSwitchs},
+}
+
+//keyZero value of the missing or null keys
+var keyZero = map[string]interface{}{
+	"Mac":       "",
+	"Label":     "",
+	"SwitchMac": "",
+	"Group":     0,
+	"Cluster":   0,
+	"Error":     0,
+}
+
+//Page filters, sort and range of a paginated query on the record keys, the empty fields are not filtered
+type Page struct {
+	Criteria    map[string]interface{}   //key values
+	In          map[string][]interface{} //allowed values of the keys
+	LabelPrefix string
+	InError     *bool  //records with a non zero Error key or not
+	Sort        string //sorted key, the records are then sorted by Mac
+	Descending  bool
+	Offset      int
+	Limit       int //0 for all the records
+}
+
+//Join table whose record of the same label is attached to the paginated records
+type Join struct {
+	DbName   string
+	TbName   string
+	Criteria map[string]interface{}
+	In       map[string][]interface{}
+}
+
+//JoinedPage page of a table joined by label with the status and config of its records.
+//A key is read in the joined records: the first non empty value of its paths is used
+type JoinedPage struct {
+	Page
+	Status  *Join
+	Config  *Join
+	Keys    map[string][]string //dotted paths of the keys: status.<field>, config.<field> or record.<field> (default)
+	Matched bool                //only the records with a status or a config
+}
+
+//JoinedRecord paginated record with its status and config, nil when not found
+type JoinedRecord struct {
+	Record interface{}
+	Status interface{}
+	Config interface{}
+}
+
+//pageDb rethinkdb database, the pages are filtered, sorted and paginated by the database
+type pageDb struct {
+	database.DatabaseInterface
+	session *r.Session
+}
+
+//createIndex add a secondary index on a field to a table
+func (db *pageDb) createIndex(dbName, tbName, index string) error {
+	cursor, err := r.DB(dbName).Table(tbName).IndexList().Run(db.session)
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	err = cursor.All(&indexes)
+	if err != nil {
+		return err
+	}
+	for _, elt := range indexes {
+		if elt == index {
+			return nil
+		}
+	}
+	_, err = r.DB(dbName).Table(tbName).IndexCreate(index).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB(dbName).Table(tbName).IndexWait(index).Run(db.session)
+	return err
+}
+
+//createLabelIndexes add the label index to the tables of a database joined by the dump
+func (db *pageDb) createLabelIndexes(dbName string) {
+	for _, tbName := range labelIndexes[dbName] {
+		err := db.createIndex(dbName, tbName, LabelIndex)
+		if err != nil {
+			rlog.Warn("Create index ", err.Error())
+		}
+	}
+}
+
+//keyTerm complete a key value with its zero value
+func keyTerm(value r.Term, name string) r.Term {
+	return value.Default(keyZero[name])
+}
+
+//selectTerm filter a sequence on criteria and allowed values
+func selectTerm(term r.Term, criteria map[string]interface{}, in map[string][]interface{}, key func(row r.Term, name string) r.Term) r.Term {
+	if len(criteria) == 0 && len(in) == 0 {
+		return term
+	}
+	return term.Filter(func(row r.Term) r.Term {
+		cond := r.Expr(true)
+		for name, value := range criteria {
+			cond = cond.And(key(row, name).Eq(value))
+		}
+		for name, values := range in {
+			cond = cond.And(r.Expr(values).Contains(key(row, name)))
+		}
+		return cond
+	})
+}
+
+//pageTerm filter a sequence on the page keys
+func pageTerm(term r.Term, p Page, key func(row r.Term, name string) r.Term) r.Term {
+	term = selectTerm(term, p.Criteria, p.In, key)
+	if p.LabelPrefix != "" {
+		term = term.Filter(func(row r.Term) r.Term {
+			return key(row, "Label").Match("^" + regexp.QuoteMeta(p.LabelPrefix)).Ne(nil)
+		})
+	}
+	if p.InError != nil {
+		term = term.Filter(func(row r.Term) r.Term {
+			return key(row, "Error").Ne(0).Eq(*p.InError)
+		})
+	}
+	return term
+}
+
+//runPage count the records of a filtered sequence and return the sorted page
+func (db *pageDb) runPage(term r.Term, p Page, key func(row r.Term, name string) r.Term) ([]interface{}, int, error) {
+	var res []interface{}
+	total := 0
+	cursor, err := term.Count().Run(db.session)
+	if err != nil {
+		return res, total, err
+	}
+	err = cursor.One(&total)
+	if err != nil {
+		return res, total, err
+	}
+
+	sorts := []string{"Mac"}
+	if p.Sort != "" && p.Sort != "Mac" {
+		sorts = []string{p.Sort, "Mac"}
+	}
+	var orders []interface{}
+	for _, name := range sorts {
+		field := name
+		order := func(row r.Term) interface{} {
+			return key(row, field)
+		}
+		if p.Descending {
+			orders = append(orders, r.Desc(order))
+		} else {
+			orders = append(orders, r.Asc(order))
+		}
+	}
+	term = term.OrderBy(orders...).Skip(p.Offset)
+	if p.Limit > 0 {
+		term = term.Limit(p.Limit)
+	}
+	cursor, err = term.Run(db.session, r.RunOpts{ArrayLimit: maxArraySize})
+	if err != nil {
+		return res, total, err
+	}
+	err = cursor.All(&res)
+	return res, total, err
+}
+
+func (db *pageDb) GetPage(dbName, tbName string, p Page) ([]interface{}, int, error) {
+	key := func(row r.Term, name string) r.Term {
+		return keyTerm(row.Field(name), name)
+	}
+	return db.runPage(pageTerm(r.DB(dbName).Table(tbName), p, key), p, key)
+}
+
+//lookup return the record of the joined table with the given label, null when not found
+func lookup(j *Join, label r.Term) interface{} {
+	if j == nil {
+		return nil
+	}
+	term := r.DB(j.DbName).Table(j.TbName).GetAllByIndex(LabelIndex, label)
+	term = selectTerm(term, j.Criteria, j.In, func(row r.Term, name string) r.Term {
+		return keyTerm(row.Field(name), name)
+	})
+	return term.Nth(0).Default(nil)
+}
+
+func (db *pageDb) GetJoinedPage(dbName, tbName string, j JoinedPage) ([]JoinedRecord, int, error) {
+	var res []JoinedRecord
+	term := r.DB(dbName).Table(tbName).Map(func(row r.Term) interface{} {
+		return map[string]interface{}{
+			"record": row,
+			"status": lookup(j.Status, row.Field("Label")),
+			"config": lookup(j.Config, row.Field("Label")),
+		}
+	})
+	if j.Matched {
+		term = term.Filter(func(row r.Term) r.Term {
+			return row.Field("status").Ne(nil).Or(row.Field("config").Ne(nil))
+		})
+	}
+	key := func(row r.Term, name string) r.Term {
+		paths, ok := j.Keys[name]
+		if !ok {
+			paths = []string{"record." + name}
+		}
+		var values []r.Term
+		for _, path := range paths {
+			value := row
+			for _, field := range strings.Split(path, ".") {
+				value = value.Field(field)
+			}
+			values = append(values, value.Default(nil))
+		}
+		//the zero values of the first paths are not set
+		value := values[len(values)-1]
+		for i := len(values) - 2; i >= 0; i-- {
+			value = r.Branch(values[i].Ne(nil).And(values[i].Ne(0), values[i].Ne("")), values[i], value)
+		}
+		return keyTerm(value, name)
+	}
+
+	stored, total, err := db.runPage(pageTerm(term, j.Page, key), j.Page, key)
+	for _, elt := range stored {
+		m, ok := elt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		res = append(res, JoinedRecord{
+			Record: m["record"],
+			Status: m["status"],
+			Config: m["config"],
+		})
+	}
+	return res, total, err
+}
+
+func (db *pageDb) Close() error {
+	db.session.Close()
+	return db.DatabaseInterface.Close()
+}
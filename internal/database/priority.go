@@ -0,0 +1,65 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbPriorities = "priorities"
+)
+
+//SavePriorityArray dump priority array in database
+func SavePriorityArray(db Database, array core.PriorityArray) error {
+	stored, dbID := GetPriorityArray(db, array.Point)
+	if stored == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbStatus, TbPriorities, array)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbStatus, TbPriorities, dbID, array)
+}
+
+//RemovePriorityArray remove priority array entry in database
+func RemovePriorityArray(db Database, point string) error {
+	criteria := make(map[string]interface{})
+	criteria["Point"] = point
+	return db.DeleteRecord(pconst.DbStatus, TbPriorities, criteria)
+}
+
+//GetPriorityArray return the priority array of a driver or a group
+func GetPriorityArray(db Database, point string) (*core.PriorityArray, string) {
+	criteria := make(map[string]interface{})
+	criteria["Point"] = point
+	stored, err := db.GetRecord(pconst.DbStatus, TbPriorities, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	array, err := core.ToPriorityArray(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return array, dbID
+}
+
+//GetPriorityArrays return the priority arrays
+func GetPriorityArrays(db Database) []core.PriorityArray {
+	var arrays []core.PriorityArray
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbPriorities)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		array, err := core.ToPriorityArray(st)
+		if err != nil || array == nil {
+			continue
+		}
+		arrays = append(arrays, *array)
+	}
+	return arrays
+}
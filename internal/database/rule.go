@@ -0,0 +1,75 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbRules = "rules"
+)
+
+//SaveRule dump rule in database
+func SaveRule(db Database, cfg core.Rule) error {
+	rule, dbID := GetRule(db, cfg.Name)
+	if rule == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbRules, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbRules, dbID, cfg)
+}
+
+//RemoveRule remove rule entry in database
+func RemoveRule(db Database, name string) error {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	return db.DeleteRecord(pconst.DbConfig, TbRules, criteria)
+}
+
+//GetRule return the rule configuration
+func GetRule(db Database, name string) (*core.Rule, string) {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	stored, err := db.GetRecord(pconst.DbConfig, TbRules, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	rule, err := core.ToRule(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return rule, dbID
+}
+
+//GetRules return the rules configuration
+func GetRules(db Database) []core.Rule {
+	var rules []core.Rule
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbRules)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		rule, err := core.ToRule(st)
+		if err != nil || rule == nil {
+			continue
+		}
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+//GetRuleValues return the rule properties of the stored status
+func GetRuleValues(db Database) core.RuleValues {
+	values := make(core.RuleValues)
+	values.AddDrivers(GetSensorsStatus(db), GetNanosStatus(db), GetHvacsStatus(db), GetWagosStatus(db), GetGroupsStatus(db))
+	for _, sw := range GetSwitchsDump(db) {
+		values.Add(core.RuleSourceSwitch, core.DeviceNames(sw.Mac, sw.Label), sw)
+	}
+	return values
+}
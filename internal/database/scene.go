@@ -0,0 +1,65 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbScenes = "scenes"
+)
+
+//SaveScene dump scene in database
+func SaveScene(db Database, cfg core.Scene) error {
+	scene, dbID := GetScene(db, cfg.Name)
+	if scene == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbScenes, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbScenes, dbID, cfg)
+}
+
+//RemoveScene remove scene entry in database
+func RemoveScene(db Database, name string) error {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	return db.DeleteRecord(pconst.DbConfig, TbScenes, criteria)
+}
+
+//GetScene return the scene configuration
+func GetScene(db Database, name string) (*core.Scene, string) {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	stored, err := db.GetRecord(pconst.DbConfig, TbScenes, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	scene, err := core.ToScene(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return scene, dbID
+}
+
+//GetScenes return the scenes configuration
+func GetScenes(db Database) []core.Scene {
+	var scenes []core.Scene
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbScenes)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		scene, err := core.ToScene(st)
+		if err != nil || scene == nil {
+			continue
+		}
+		scenes = append(scenes, *scene)
+	}
+	return scenes
+}
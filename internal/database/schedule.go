@@ -0,0 +1,95 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbSchedules = "schedules"
+	TbScheduler = "scheduler"
+)
+
+//SaveSchedule dump group schedule in database
+func SaveSchedule(db Database, cfg core.Schedule) error {
+	schedule, dbID := GetSchedule(db, cfg.Group)
+	if schedule == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbSchedules, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbSchedules, dbID, cfg)
+}
+
+//RemoveSchedule remove group schedule entry in database
+func RemoveSchedule(db Database, group int) error {
+	criteria := make(map[string]interface{})
+	criteria["Group"] = group
+	return db.DeleteRecord(pconst.DbConfig, TbSchedules, criteria)
+}
+
+//GetSchedule return the group schedule
+func GetSchedule(db Database, group int) (*core.Schedule, string) {
+	criteria := make(map[string]interface{})
+	criteria["Group"] = group
+	stored, err := db.GetRecord(pconst.DbConfig, TbSchedules, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	schedule, err := core.ToSchedule(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return schedule, dbID
+}
+
+//GetSchedules return the groups schedule
+func GetSchedules(db Database) []core.Schedule {
+	var schedules []core.Schedule
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbSchedules)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		schedule, err := core.ToSchedule(st)
+		if err != nil || schedule == nil {
+			continue
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules
+}
+
+//GetSchedulerStatus return the scheduler status and its database ID
+func GetSchedulerStatus(db Database) (*core.SchedulerStatus, string) {
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbScheduler)
+	if err != nil || len(stored) == 0 {
+		return nil, ""
+	}
+	var dbID string
+	m := stored[0].(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	status, err := core.ToSchedulerStatus(stored[0])
+	if err != nil {
+		return nil, dbID
+	}
+	return status, dbID
+}
+
+//SaveSchedulerStatus dump the scheduler status in database
+func SaveSchedulerStatus(db Database, status core.SchedulerStatus) error {
+	_, dbID := GetSchedulerStatus(db)
+	if dbID == "" {
+		_, err := db.InsertRecord(pconst.DbStatus, TbScheduler, status)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbStatus, TbScheduler, dbID, status)
+}
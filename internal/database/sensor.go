@@ -2,6 +2,7 @@ package database
 
 import (
 	ds "github.com/energieip/common-components-go/pkg/dsensor"
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
 
@@ -285,3 +286,49 @@ func GetSensorStatus(db Database, mac string) *ds.Sensor {
 	}
 	return driver
 }
+
+//sensor tables read by the status and dump requests
+var (
+	sensorsStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbSensors, switchMac: true, group: true, configured: true}
+	sensorsConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbSensors, switchMac: true, group: true}
+)
+
+//GetSensorsStatusPage return the page of the sensor status matching the filter and the number of matching sensors
+func GetSensorsStatusPage(db Database, f DriverFilter, p Page) ([]ds.Sensor, int) {
+	var drivers []ds.Sensor
+	stored, total := driverPage(db, sensorsStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := ds.ToSensor(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetSensorsDumpPage return the page of the project sensors with their status and config matching the filter
+//and the number of matching sensors
+func GetSensorsDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpSensor, int) {
+	var dumps []dserver.DumpSensor
+	records, total := driverDumpPage(db, pconst.SENSOR, sensorsStatusTable, sensorsConfigTable, f, p, driverKeys)
+	for _, rec := range records {
+		dump := dserver.DumpSensor{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := ds.ToSensor(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := ds.ToSensorSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
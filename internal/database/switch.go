@@ -4,6 +4,11 @@ import (
 	"github.com/energieip/common-components-go/pkg/dserver"
 	sd "github.com/energieip/common-components-go/pkg/dswitch"
 	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbSwitchsOffline = "switchsoffline"
 )
 
 //SaveSwitchStatus dump switch status in database
@@ -291,6 +296,52 @@ func GetSwitchsDumpByLabel(db Database) map[string]dserver.SwitchDump {
 	return switchs
 }
 
+//switchKeys keys of the dumped switchs, the config values are used when the switch is not seen
+var switchKeys = map[string][]string{
+	"SwitchMac": {"status.Mac", "config.Mac"},
+	"Cluster":   {"status.Cluster", "config.Cluster"},
+	"Error":     {"status.ErrorCode"},
+}
+
+//GetSwitchsDumpPage return the page of the project switchs with their status and config matching the filter
+//and the number of matching switchs
+func GetSwitchsDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpSwitch, int) {
+	var dumps []dserver.DumpSwitch
+	criteria := make(map[string]interface{})
+	if f.SwitchMac != "" {
+		criteria["SwitchMac"] = f.SwitchMac
+	}
+	if f.Cluster != nil {
+		criteria["Cluster"] = *f.Cluster
+	}
+	j := JoinedPage{
+		Page:   p.restrict(criteria, nil),
+		Status: &Join{DbName: pconst.DbStatus, TbName: pconst.TbSwitchs},
+		Config: &Join{DbName: pconst.DbConfig, TbName: pconst.TbSwitchs},
+		Keys:   switchKeys,
+	}
+	records, total := dumpPage(db, pconst.SWITCH, j)
+	for _, rec := range records {
+		dump := dserver.DumpSwitch{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			sw, err := dserver.ToSwitchDump(rec.status)
+			if err == nil && sw != nil {
+				dump.Status = *sw
+			}
+		}
+		if rec.config != nil {
+			sw, err := dserver.ToSwitchConfig(rec.config)
+			if err == nil && sw != nil {
+				dump.Config = *sw
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
+
 //GetCluster get cluster Config list
 func GetCluster(db Database, cluster int) map[string]dserver.SwitchConfig {
 	res := make(map[string]dserver.SwitchConfig)
@@ -331,3 +382,49 @@ func GetSwitchStatusCluster(db Database, cluster int) map[string]dserver.SwitchD
 	}
 	return res
 }
+
+//SaveOfflineSwitch mark the switch offline in database
+func SaveOfflineSwitch(db Database, sw core.OfflineSwitch) error {
+	criteria := make(map[string]interface{})
+	criteria["Mac"] = sw.Mac
+	return SaveOnUpdateObject(db, sw, pconst.DbStatus, TbSwitchsOffline, criteria)
+}
+
+//RemoveOfflineSwitch remove the switch offline mark in database
+func RemoveOfflineSwitch(db Database, mac string) error {
+	criteria := make(map[string]interface{})
+	criteria["Mac"] = mac
+	return db.DeleteRecord(pconst.DbStatus, TbSwitchsOffline, criteria)
+}
+
+//GetOfflineSwitch return the offline mark of a switch, nil when it is online
+func GetOfflineSwitch(db Database, mac string) *core.OfflineSwitch {
+	criteria := make(map[string]interface{})
+	criteria["Mac"] = mac
+	stored, err := db.GetRecord(pconst.DbStatus, TbSwitchsOffline, criteria)
+	if err != nil || stored == nil {
+		return nil
+	}
+	sw, err := core.ToOfflineSwitch(stored)
+	if err != nil {
+		return nil
+	}
+	return sw
+}
+
+//GetOfflineSwitchs return the offline switchs
+func GetOfflineSwitchs(db Database) map[string]core.OfflineSwitch {
+	switchs := map[string]core.OfflineSwitch{}
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbSwitchsOffline)
+	if err != nil || stored == nil {
+		return switchs
+	}
+	for _, l := range stored {
+		sw, err := core.ToOfflineSwitch(l)
+		if err != nil || sw == nil {
+			continue
+		}
+		switchs[sw.Mac] = *sw
+	}
+	return switchs
+}
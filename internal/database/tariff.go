@@ -0,0 +1,65 @@
+package database
+
+import (
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbTariffs = "tariffs"
+)
+
+//SaveTariff dump tariff in database
+func SaveTariff(db Database, cfg core.Tariff) error {
+	tariff, dbID := GetTariff(db, cfg.Label)
+	if tariff == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbTariffs, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbTariffs, dbID, cfg)
+}
+
+//RemoveTariff remove tariff entry in database
+func RemoveTariff(db Database, label string) error {
+	criteria := make(map[string]interface{})
+	criteria["Label"] = label
+	return db.DeleteRecord(pconst.DbConfig, TbTariffs, criteria)
+}
+
+//GetTariff return the tariff configuration
+func GetTariff(db Database, label string) (*core.Tariff, string) {
+	criteria := make(map[string]interface{})
+	criteria["Label"] = label
+	stored, err := db.GetRecord(pconst.DbConfig, TbTariffs, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	tariff, err := core.ToTariff(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return tariff, dbID
+}
+
+//GetTariffs return the tariffs configuration
+func GetTariffs(db Database) []core.Tariff {
+	var tariffs []core.Tariff
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbTariffs)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		tariff, err := core.ToTariff(st)
+		if err != nil || tariff == nil {
+			continue
+		}
+		tariffs = append(tariffs, *tariff)
+	}
+	return tariffs
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/energieip/common-components-go/pkg/dwago"
 	"github.com/energieip/common-components-go/pkg/pconst"
 )
@@ -299,3 +300,54 @@ func GetWagoStatus(db Database, mac string) *dwago.Wago {
 	}
 	return w
 }
+
+//wago tables read by the status and dump requests
+var (
+	wagosStatusTable = driverTable{dbName: pconst.DbStatus, tbName: pconst.TbWagos, cluster: true, configured: true}
+	wagosConfigTable = driverTable{dbName: pconst.DbConfig, tbName: pconst.TbWagos, cluster: true}
+)
+
+//wagoKeys keys of the dumped wagos, they are not attached to a group or a switch
+var wagoKeys = map[string][]string{
+	"Error": {"status.Error"},
+}
+
+//GetWagosStatusPage return the page of the wago status matching the filter and the number of matching wagos
+func GetWagosStatusPage(db Database, f DriverFilter, p Page) ([]dwago.Wago, int) {
+	var drivers []dwago.Wago
+	stored, total := driverPage(db, wagosStatusTable, f, p)
+	for _, elt := range stored {
+		driver, err := dwago.ToWago(elt)
+		if err != nil || driver == nil {
+			continue
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, total
+}
+
+//GetWagosDumpPage return the page of the project wagos with their status and config matching the filter
+//and the number of matching wagos
+func GetWagosDumpPage(db Database, f DriverFilter, p Page) ([]dserver.DumpWago, int) {
+	var dumps []dserver.DumpWago
+	records, total := driverDumpPage(db, pconst.WAGO, wagosStatusTable, wagosConfigTable, f, p, wagoKeys)
+	for _, rec := range records {
+		dump := dserver.DumpWago{
+			Ifc: rec.ifc,
+		}
+		if rec.status != nil {
+			driver, err := dwago.ToWago(rec.status)
+			if err == nil && driver != nil {
+				dump.Status = *driver
+			}
+		}
+		if rec.config != nil {
+			driver, err := dwago.ToWagoSetup(rec.config)
+			if err == nil && driver != nil {
+				dump.Config = *driver
+			}
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps, total
+}
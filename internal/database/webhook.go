@@ -0,0 +1,125 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/energieip/common-components-go/pkg/pconst"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	TbWebhooks          = "webhooks"
+	TbWebhookDeliveries = "webhookdeliveries"
+)
+
+//SaveWebhook dump webhook in database
+func SaveWebhook(db Database, cfg core.Webhook) error {
+	webhook, dbID := GetWebhook(db, cfg.Name)
+	if webhook == nil || dbID == "" {
+		_, err := db.InsertRecord(pconst.DbConfig, TbWebhooks, cfg)
+		return err
+	}
+	return db.UpdateRecord(pconst.DbConfig, TbWebhooks, dbID, cfg)
+}
+
+//RemoveWebhook remove webhook entry in database
+func RemoveWebhook(db Database, name string) error {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	return db.DeleteRecord(pconst.DbConfig, TbWebhooks, criteria)
+}
+
+//GetWebhook return the webhook configuration
+func GetWebhook(db Database, name string) (*core.Webhook, string) {
+	criteria := make(map[string]interface{})
+	criteria["Name"] = name
+	stored, err := db.GetRecord(pconst.DbConfig, TbWebhooks, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	webhook, err := core.ToWebhook(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return webhook, dbID
+}
+
+//GetWebhooks return the webhooks configuration
+func GetWebhooks(db Database) []core.Webhook {
+	var webhooks []core.Webhook
+	stored, err := db.FetchAllRecords(pconst.DbConfig, TbWebhooks)
+	if err != nil || stored == nil {
+		return nil
+	}
+	for _, st := range stored {
+		webhook, err := core.ToWebhook(st)
+		if err != nil || webhook == nil {
+			continue
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks
+}
+
+//SaveWebhookDelivery dump delivery log entry in database and return its ID
+func SaveWebhookDelivery(db Database, delivery core.WebhookDelivery) (string, error) {
+	if delivery.ID == "" {
+		return db.InsertRecord(pconst.DbStatus, TbWebhookDeliveries, delivery)
+	}
+	return delivery.ID, db.UpdateRecord(pconst.DbStatus, TbWebhookDeliveries, delivery.ID, delivery)
+}
+
+//GetWebhookDeliveries return the delivery log of a webhook, the most recent first
+func GetWebhookDeliveries(db Database, name string) []core.WebhookDelivery {
+	deliveries := []core.WebhookDelivery{}
+	criteria := make(map[string]interface{})
+	criteria["Webhook"] = name
+	stored, err := db.GetRecords(pconst.DbStatus, TbWebhookDeliveries, criteria)
+	if err != nil || stored == nil {
+		return deliveries
+	}
+	for _, st := range stored {
+		delivery, err := core.ToWebhookDelivery(st)
+		if err != nil || delivery == nil {
+			continue
+		}
+		m := st.(map[string]interface{})
+		id, ok := m["id"]
+		if ok {
+			delivery.ID = id.(string)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].Date > deliveries[j].Date
+	})
+	return deliveries
+}
+
+//RemoveWebhookDeliveries remove the delivery log entries older than the date
+func RemoveWebhookDeliveries(db Database, before string) {
+	stored, err := db.FetchAllRecords(pconst.DbStatus, TbWebhookDeliveries)
+	if err != nil || stored == nil {
+		return
+	}
+	for _, st := range stored {
+		delivery, err := core.ToWebhookDelivery(st)
+		if err != nil || delivery == nil || delivery.Date >= before {
+			continue
+		}
+		m := st.(map[string]interface{})
+		id, ok := m["id"]
+		if !ok {
+			continue
+		}
+		criteria := make(map[string]interface{})
+		criteria["id"] = id
+		db.DeleteRecord(pconst.DbStatus, TbWebhookDeliveries, criteria)
+	}
+}
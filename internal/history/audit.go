@@ -0,0 +1,160 @@
+package history
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/duser"
+)
+
+//AuditEntry configuration change or command sent through the API
+type AuditEntry struct {
+	Date    string           `json:"date"` //RFC3339 UTC
+	User    duser.UserAccess `json:"user"`
+	Request string           `json:"request"` //HTTP method and path
+	Action  string           `json:"action"`  //backend event type
+	Target  string           `json:"target"`  //mac, label, group or name of the changed object
+	Payload interface{}      `json:"payload"`
+	Before  interface{}      `json:"before"` //stored configuration before the change, null for commands
+	Changes []AuditChange    `json:"changes"`
+}
+
+//AuditChange field modified by a configuration change
+type AuditChange struct {
+	Field  string      `json:"field"` //dotted path of the field
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+//AuditFilter audit log query, the empty fields are not filtered
+type AuditFilter struct {
+	From   *time.Time
+	To     *time.Time
+	User   string //user hash
+	Action string
+	Target string
+}
+
+//ToAuditEntry convert map interface to AuditEntry object
+func ToAuditEntry(val interface{}) (*AuditEntry, error) {
+	var entry AuditEntry
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &entry)
+	return &entry, err
+}
+
+//toJSONValue convert an object to its generic JSON representation
+func toJSONValue(obj interface{}) interface{} {
+	if obj == nil {
+		return nil
+	}
+	inrec, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	var res interface{}
+	json.Unmarshal(inrec, &res)
+	return res
+}
+
+func flatten(prefix string, value interface{}, res map[string]interface{}) {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		res[prefix] = value
+		return
+	}
+	for key, val := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		flatten(key, val, res)
+	}
+}
+
+//NewAuditEntry prepare the audit entry of an event, the diff lists the payload fields
+//which differ from the stored configuration
+func NewAuditEntry(user duser.UserAccess, request, action, target string, payload, before interface{}) AuditEntry {
+	entry := AuditEntry{
+		Date:    time.Now().UTC().Format(time.RFC3339),
+		User:    user,
+		Request: request,
+		Action:  action,
+		Target:  target,
+		Payload: toJSONValue(payload),
+		Before:  toJSONValue(before),
+		Changes: []AuditChange{},
+	}
+	if entry.Before == nil {
+		return entry
+	}
+	after := make(map[string]interface{})
+	flatten("", entry.Payload, after)
+	previous := make(map[string]interface{})
+	flatten("", entry.Before, previous)
+	for field, val := range after {
+		if val == nil || reflect.DeepEqual(val, previous[field]) {
+			continue
+		}
+		entry.Changes = append(entry.Changes, AuditChange{
+			Field:  field,
+			Before: previous[field],
+			After:  val,
+		})
+	}
+	sort.Slice(entry.Changes, func(i, j int) bool {
+		return entry.Changes[i].Field < entry.Changes[j].Field
+	})
+	return entry
+}
+
+//SaveAuditEntry append an entry to the audit log
+func SaveAuditEntry(db HistoryDb, entry AuditEntry) error {
+	_, err := db.InsertRecord(HistoryDB, AuditTable, entry)
+	return err
+}
+
+//GetAuditEntries return the audit log entries, the oldest first
+func GetAuditEntries(db HistoryDb, f AuditFilter) []AuditEntry {
+	res := []AuditEntry{}
+	criteria := make(map[string]interface{})
+	if f.Action != "" {
+		criteria["Action"] = f.Action
+	}
+	if f.Target != "" {
+		criteria["Target"] = f.Target
+	}
+	var stored []interface{}
+	var err error
+	if len(criteria) == 0 {
+		stored, err = db.FetchAllRecords(HistoryDB, AuditTable)
+	} else {
+		stored, err = db.GetRecords(HistoryDB, AuditTable, criteria)
+	}
+	if err != nil {
+		return res
+	}
+	period := Filter{From: f.From, To: f.To}
+	for _, st := range stored {
+		elt, err := ToAuditEntry(st)
+		if err != nil || elt == nil {
+			continue
+		}
+		if f.User != "" && elt.User.UserHash != f.User {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, elt.Date)
+		if err != nil || !period.inRange(date) {
+			continue
+		}
+		res = append(res, *elt)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Date < res[j].Date
+	})
+	return res
+}
@@ -0,0 +1,371 @@
+package history
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/romana/rlog"
+)
+
+const (
+	CompactionTable = "compaction"
+
+	//CompactionDelay wait after the end of an hour before compacting it, the recorder flushes its last samples meanwhile
+	CompactionDelay = 5 * time.Minute
+)
+
+//AggregateHistory consumption of one driver over one hour or one day
+type AggregateHistory struct {
+	Mac       string  `json:"mac"`
+	SwitchMac string  `json:"switchMac"`
+	Label     string  `json:"label"`
+	Group     int     `json:"group"`
+	Cluster   int     `json:"cluster"`
+	Energy    float64 `json:"energy"`   //summed energy (Wh)
+	Power     int     `json:"power"`    //average power (W)
+	MinPower  int     `json:"minPower"` //W
+	MaxPower  int     `json:"maxPower"` //W
+	Samples   int     `json:"samples"`  //number of raw samples
+	Date      string  `json:"date"`     //period start date (RFC3339 UTC)
+}
+
+//CompactionStatus compaction and retention state of one history table
+type CompactionStatus struct {
+	Table      string `json:"table"`
+	Resolution string `json:"resolution"`
+	Retention  int    `json:"retention"` //days, 0 to keep the entries forever
+	Compacted  string `json:"compacted"` //the periods before this date are aggregated in this table
+	Purged     string `json:"purged"`    //the entries before this date are removed
+	LastRun    string `json:"lastRun"`
+	Duration   int    `json:"duration"` //last run duration (ms)
+	Created    int    `json:"created"`  //entries created during the last run
+	Removed    int    `json:"removed"`  //periods removed during the last run
+	Error      string `json:"error"`
+}
+
+//Compactor rolls the raw history up into hourly and daily tables and
+//removes the entries older than the configured retention
+type Compactor struct {
+	db   HistoryDb
+	conf core.HistoryConfig
+}
+
+//ToAggregateHistory convert map interface to AggregateHistory object, raw entries are seen as a one sample aggregate
+func ToAggregateHistory(val interface{}) (*AggregateHistory, error) {
+	var elt AggregateHistory
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &elt)
+	if elt.Samples == 0 {
+		elt.Samples = 1
+		elt.MinPower = elt.Power
+		elt.MaxPower = elt.Power
+	}
+	return &elt, err
+}
+
+//ToCompactionStatus convert map interface to CompactionStatus object
+func ToCompactionStatus(val interface{}) (*CompactionStatus, error) {
+	var status CompactionStatus
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &status)
+	return &status, err
+}
+
+//TierTable return the table name of the aggregated entries
+func TierTable(tbName, resolution string) string {
+	if resolution == ResolutionMinute {
+		return tbName
+	}
+	return tbName + "_" + resolution
+}
+
+//getCompactionStatus return the stored status and its database ID
+func getCompactionStatus(db HistoryDb, tbName string) (*CompactionStatus, string) {
+	criteria := make(map[string]interface{})
+	criteria["Table"] = tbName
+	stored, err := db.GetRecord(HistoryDB, CompactionTable, criteria)
+	if err != nil || stored == nil {
+		return nil, ""
+	}
+	var dbID string
+	m := stored.(map[string]interface{})
+	id, ok := m["id"]
+	if ok {
+		dbID = id.(string)
+	}
+	status, err := ToCompactionStatus(stored)
+	if err != nil {
+		return nil, dbID
+	}
+	return status, dbID
+}
+
+func saveCompactionStatus(db HistoryDb, status CompactionStatus) error {
+	_, dbID := getCompactionStatus(db, status.Table)
+	if dbID == "" {
+		_, err := db.InsertRecord(HistoryDB, CompactionTable, status)
+		return err
+	}
+	return db.UpdateRecord(HistoryDB, CompactionTable, dbID, status)
+}
+
+//GetCompactionStatus return the compaction status of the history tables
+func GetCompactionStatus(db HistoryDb) []CompactionStatus {
+	var res []CompactionStatus
+	stored, err := db.FetchAllRecords(HistoryDB, CompactionTable)
+	if err != nil || stored == nil {
+		return res
+	}
+	for _, st := range stored {
+		status, err := ToCompactionStatus(st)
+		if err != nil || status == nil {
+			continue
+		}
+		res = append(res, *status)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Table < res[j].Table
+	})
+	return res
+}
+
+//getCompacted return the end date of the aggregated periods of a table
+func getCompacted(db HistoryDb, tbName string) *time.Time {
+	status, _ := getCompactionStatus(db, tbName)
+	if status == nil {
+		return nil
+	}
+	date, err := time.Parse(time.RFC3339, status.Compacted)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+//saveBatch insert the entries by chunks of MaxBatchSize
+func saveBatch(db HistoryDb, tbName string, rows []interface{}) error {
+	for start := 0; start < len(rows); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		err := SaveHistory(db, HistoryDB, tbName, rows[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//NewCompactor create a history compactor
+func NewCompactor(db HistoryDb, conf core.HistoryConfig) *Compactor {
+	return &Compactor{
+		db:   db,
+		conf: conf,
+	}
+}
+
+func (c *Compactor) status(tbName, resolution string, retention int) *CompactionStatus {
+	status, _ := getCompactionStatus(c.db, tbName)
+	if status == nil {
+		status = &CompactionStatus{
+			Table:      tbName,
+			Resolution: resolution,
+		}
+	}
+	status.Retention = retention
+	status.Created = 0
+	status.Removed = 0
+	status.Error = ""
+	return status
+}
+
+//periodDates return the period dates between from and until, the first run
+//starts from the oldest entry of the table
+func (c *Compactor) periodDates(tbName, from string, until time.Time, step time.Duration) []string {
+	var dates []string
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		first, err := c.db.FirstDate(tbName)
+		if err != nil || first == "" {
+			return dates
+		}
+		start, err = time.Parse(time.RFC3339, first)
+		if err != nil {
+			return dates
+		}
+		start = start.UTC().Truncate(step)
+	}
+	for date := start; date.Before(until); date = date.Add(step) {
+		dates = append(dates, date.Format(time.RFC3339))
+	}
+	return dates
+}
+
+//rollup aggregate the source entries stored before until in the status table,
+//the aggregated periods are rewritten so that a failed run can be replayed
+func (c *Compactor) rollup(source string, status *CompactionStatus, step time.Duration, until time.Time) {
+	aggregates := make(map[string]*AggregateHistory)
+	powers := make(map[string]float64)
+	for _, date := range c.periodDates(source, status.Compacted, until, step) {
+		criteria := make(map[string]interface{})
+		criteria["Date"] = date
+		stored, err := c.db.GetRecords(HistoryDB, source, criteria)
+		if err != nil {
+			status.Error = err.Error()
+			return
+		}
+		for _, st := range stored {
+			elt, err := ToAggregateHistory(st)
+			if err != nil || elt == nil {
+				continue
+			}
+			start, err := time.Parse(time.RFC3339, elt.Date)
+			if err != nil {
+				continue
+			}
+			period := PeriodStart(start, status.Resolution).Format(time.RFC3339)
+			key := elt.Mac + period
+			powers[key] += float64(elt.Power * elt.Samples)
+			agg, ok := aggregates[key]
+			if !ok {
+				elt.Date = period
+				aggregates[key] = elt
+				continue
+			}
+			agg.SwitchMac = elt.SwitchMac
+			agg.Label = elt.Label
+			agg.Group = elt.Group
+			agg.Cluster = elt.Cluster
+			agg.Energy += elt.Energy
+			agg.Samples += elt.Samples
+			if elt.MinPower < agg.MinPower {
+				agg.MinPower = elt.MinPower
+			}
+			if elt.MaxPower > agg.MaxPower {
+				agg.MaxPower = elt.MaxPower
+			}
+		}
+	}
+
+	var rows []interface{}
+	periods := make(map[string]bool)
+	for key, agg := range aggregates {
+		agg.Power = int(powers[key] / float64(agg.Samples))
+		rows = append(rows, *agg)
+		periods[agg.Date] = true
+	}
+
+	//remove the entries left by a previous run which failed in the middle of the batch
+	for period := range periods {
+		criteria := make(map[string]interface{})
+		criteria["Date"] = period
+		err := c.db.DeleteRecord(HistoryDB, status.Table, criteria)
+		if err != nil {
+			status.Error = err.Error()
+			return
+		}
+	}
+	err := saveBatch(c.db, status.Table, rows)
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	status.Created = len(rows)
+	status.Compacted = until.Format(time.RFC3339)
+}
+
+//purge remove the status table entries stored before the retention limit
+//and already aggregated in the next table
+func (c *Compactor) purge(status *CompactionStatus, step time.Duration, now time.Time, next *CompactionStatus) {
+	if status.Retention <= 0 {
+		return
+	}
+	until := now.Add(-time.Duration(status.Retention) * 24 * time.Hour).Truncate(step)
+	if next != nil {
+		compacted, err := time.Parse(time.RFC3339, next.Compacted)
+		if err != nil {
+			return
+		}
+		if compacted.Before(until) {
+			until = compacted
+		}
+	}
+	for _, date := range c.periodDates(status.Table, status.Purged, until, step) {
+		criteria := make(map[string]interface{})
+		criteria["Date"] = date
+		err := c.db.DeleteRecord(HistoryDB, status.Table, criteria)
+		if err != nil {
+			status.Error = err.Error()
+			return
+		}
+		status.Removed++
+	}
+	status.Purged = until.Format(time.RFC3339)
+}
+
+//Compact aggregate the completed hours and days and apply the retention policy
+func (c *Compactor) Compact() {
+	now := time.Now().UTC()
+	for _, tbName := range []string{LedsTable, BlindsTable, HvacsTable, SwitchsTable} {
+		begin := time.Now()
+		raw := c.status(tbName, ResolutionMinute, c.conf.RawRetention)
+		hour := c.status(TierTable(tbName, ResolutionHour), ResolutionHour, c.conf.HourlyRetention)
+		day := c.status(TierTable(tbName, ResolutionDay), ResolutionDay, c.conf.DailyRetention)
+
+		c.rollup(tbName, hour, DefaultSamplePeriod, PeriodStart(now, ResolutionHour))
+		c.rollup(hour.Table, day, time.Hour, PeriodStart(now, ResolutionDay))
+		c.purge(raw, DefaultSamplePeriod, now, hour)
+		c.purge(hour, time.Hour, now, day)
+		c.purge(day, 24*time.Hour, now, nil)
+
+		c.saveStatus(now, begin, raw, hour, day)
+	}
+
+	//environment measures are not aggregated, only the retention applies
+	for _, tbName := range []string{SensorsTable, NanosensesTable} {
+		begin := time.Now()
+		raw := c.status(tbName, ResolutionMinute, c.conf.EnvironmentRetention)
+		c.purge(raw, DefaultSamplePeriod, now, nil)
+		c.saveStatus(now, begin, raw)
+	}
+}
+
+func (c *Compactor) saveStatus(now, begin time.Time, status ...*CompactionStatus) {
+	duration := int(time.Since(begin) / time.Millisecond)
+	for _, elt := range status {
+		elt.LastRun = now.Format(time.RFC3339)
+		elt.Duration = duration
+		if elt.Error != "" {
+			rlog.Error("History compaction of " + elt.Table + " failed: " + elt.Error)
+		}
+		err := saveCompactionStatus(c.db, *elt)
+		if err != nil {
+			rlog.Error("Cannot save " + elt.Table + " compaction status: " + err.Error())
+		}
+	}
+}
+
+//Run compact the history every hour
+func (c *Compactor) Run() {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(time.Hour).Add(CompactionDelay)
+		if !next.After(now) {
+			next = next.Add(time.Hour)
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+			c.Compact()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+func TestRollupReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		failChunk int //chunk of the first run rejected by the database, -1 for none
+	}{
+		{"no failure", -1},
+		{"first chunk fails", 0},
+		{"second chunk fails", 1},
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	until := start.Add(time.Hour)
+	drivers := MaxBatchSize + 1
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMemDb()
+			for i := 0; i < drivers; i++ {
+				for minute := 0; minute < 2; minute++ {
+					SaveHistory(db, HistoryDB, LedsTable, LedHistory{
+						Mac:    "LED" + string(rune('A'+i%26)) + string(rune('A'+i/26)),
+						Energy: 1,
+						Power:  60,
+						Date:   start.Add(time.Duration(minute) * time.Minute).Format(time.RFC3339),
+					})
+				}
+			}
+			c := NewCompactor(db, core.HistoryConfig{})
+
+			chunk := 0
+			db.failInsert = func(tbName string, count int) error {
+				chunk++
+				if chunk-1 == tt.failChunk {
+					return NewError("insert failed")
+				}
+				return nil
+			}
+			status := c.status(TierTable(LedsTable, ResolutionHour), ResolutionHour, 0)
+			c.rollup(LedsTable, status, DefaultSamplePeriod, until)
+			if (status.Error != "") != (tt.failChunk >= 0) {
+				t.Fatalf("unexpected error %q", status.Error)
+			}
+
+			db.failInsert = nil
+			status.Error = ""
+			c.rollup(LedsTable, status, DefaultSamplePeriod, until)
+			if status.Error != "" {
+				t.Fatalf("replay error %q", status.Error)
+			}
+			if status.Compacted != until.Format(time.RFC3339) {
+				t.Errorf("compacted %q, want %q", status.Compacted, until.Format(time.RFC3339))
+			}
+
+			stored, _ := db.FetchAllRecords(HistoryDB, status.Table)
+			if len(stored) != drivers {
+				t.Errorf("%v aggregates, want %v", len(stored), drivers)
+			}
+			energy := 0.0
+			for _, st := range stored {
+				elt, _ := ToAggregateHistory(st)
+				energy += elt.Energy
+			}
+			if energy != float64(2*drivers) {
+				t.Errorf("energy %v, want %v", energy, 2*drivers)
+			}
+		})
+	}
+}
+
+func TestPeriodDatesFirstRun(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	db := newMemDb()
+	for _, minute := range []int{5, 3, 8} {
+		SaveHistory(db, HistoryDB, LedsTable, LedHistory{
+			Mac:  "LED",
+			Date: start.Add(time.Duration(minute) * time.Minute).Format(time.RFC3339),
+		})
+	}
+	c := NewCompactor(db, core.HistoryConfig{})
+	dates := c.periodDates(LedsTable, "", start.Add(6*time.Minute), DefaultSamplePeriod)
+	want := []string{"2024-01-01T10:03:00Z", "2024-01-01T10:04:00Z", "2024-01-01T10:05:00Z"}
+	if !reflect.DeepEqual(dates, want) {
+		t.Errorf("dates %v, want %v", dates, want)
+	}
+	if dates := c.periodDates(BlindsTable, "", start, DefaultSamplePeriod); len(dates) != 0 {
+		t.Errorf("dates %v of an empty table", dates)
+	}
+}
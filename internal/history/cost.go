@@ -0,0 +1,224 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+//Cost energy cost and carbon footprint
+type Cost struct {
+	Energy float64 `json:"energy"` //Wh
+	Cost   float64 `json:"cost"`   //tariff currency
+	Carbon float64 `json:"carbon"` //gCO2e
+}
+
+//PeriodCost cost over one period
+type PeriodCost struct {
+	Date string `json:"date"` //period start date (RFC3339 UTC)
+	Cost
+}
+
+//GroupCost cost of one group
+type GroupCost struct {
+	Group int `json:"group"`
+	Cost
+	Periods []PeriodCost `json:"periods"`
+}
+
+//TypeCost cost of one device type
+type TypeCost struct {
+	Type string `json:"type"`
+	Cost
+	Periods []PeriodCost `json:"periods"`
+}
+
+//CostReport energy cost and carbon footprint per period, group and device type
+type CostReport struct {
+	Currency string `json:"currency"`
+	Cost
+	Periods []PeriodCost `json:"periods"`
+	Groups  []GroupCost  `json:"groups"`
+	Types   []TypeCost   `json:"types"`
+}
+
+type costBuckets map[string]*Cost //period -> cost
+
+func (c *Cost) add(energy, price, factor float64) {
+	c.Energy += energy
+	c.Cost += energy / 1000 * price
+	c.Carbon += energy / 1000 * factor
+}
+
+func (b costBuckets) add(period string, energy, price, factor float64) {
+	val, ok := b[period]
+	if !ok {
+		val = &Cost{}
+		b[period] = val
+	}
+	val.add(energy, price, factor)
+}
+
+func (b costBuckets) periods() []PeriodCost {
+	res := []PeriodCost{}
+	for date, val := range b {
+		res = append(res, PeriodCost{
+			Date: date,
+			Cost: *val,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Date < res[j].Date
+	})
+	return res
+}
+
+func (b costBuckets) total() Cost {
+	res := Cost{}
+	for _, val := range b {
+		res.Energy += val.Energy
+		res.Cost += val.Cost
+		res.Carbon += val.Carbon
+	}
+	return res
+}
+
+//getTariff return the tariff applied at date
+func getTariff(tariffs []core.Tariff, date time.Time) *core.Tariff {
+	var res *core.Tariff
+	var start *time.Time
+	for i, t := range tariffs {
+		tStart := t.StartDate()
+		if tStart != nil && tStart.After(date) {
+			continue
+		}
+		if res == nil || (tStart != nil && (start == nil || tStart.After(*start))) {
+			res = &tariffs[i]
+			start = tStart
+		}
+	}
+	return res
+}
+
+//getCostSources return the tables to read with the finest time resolution:
+//the hourly entries as long as they are kept, the daily ones before. The
+//day partly purged from the hourly table is read from the daily table
+func getCostSources(db HistoryDb, tbName string) []source {
+	var res []source
+	var from *time.Time
+	hour, _ := getCompactionStatus(db, TierTable(tbName, ResolutionHour))
+	if hour != nil {
+		purged, err := time.Parse(time.RFC3339, hour.Purged)
+		if err == nil {
+			day := PeriodStart(purged, ResolutionDay)
+			if day.Before(purged) {
+				day = day.Add(24 * time.Hour)
+			}
+			res = append(res, source{
+				table:  TierTable(tbName, ResolutionDay),
+				period: 24 * time.Hour,
+				until:  &day,
+			})
+			from = &day
+		}
+		compacted, err := time.Parse(time.RFC3339, hour.Compacted)
+		if err == nil {
+			res = append(res, source{
+				table:  hour.Table,
+				period: time.Hour,
+				from:   from,
+				until:  &compacted,
+			})
+			from = &compacted
+		}
+	}
+	res = append(res, source{
+		table:  tbName,
+		period: DefaultSamplePeriod,
+		from:   from,
+	})
+	return res
+}
+
+//GetCost return the energy cost and carbon footprint of the history tables, the tariff periods are in
+//the site location. Daily entries are charged at the tariff base price as their time of use is unknown
+func GetCost(db HistoryDb, tables map[string]string, tariffs []core.Tariff, loc *time.Location, f Filter) CostReport {
+	res := CostReport{
+		Periods: []PeriodCost{},
+		Groups:  []GroupCost{},
+		Types:   []TypeCost{},
+	}
+	total := make(costBuckets)
+	groups := make(map[int]costBuckets)
+	types := make(map[string]costBuckets)
+
+	for driverType, tbName := range tables {
+		for _, src := range getCostSources(db, tbName) {
+			for _, st := range getSourceRecords(db, src, f) {
+				elt, err := ToAggregateHistory(st)
+				if err != nil || elt == nil {
+					continue
+				}
+				date, err := time.Parse(time.RFC3339, elt.Date)
+				if err != nil || !f.inRange(date) || !src.inRange(date) {
+					continue
+				}
+				price := 0.0
+				factor := 0.0
+				tariff := getTariff(tariffs, date)
+				if tariff != nil {
+					factor = tariff.EmissionFactor
+					price = tariff.Price
+					if src.period < 24*time.Hour {
+						price = tariff.PriceAt(date, loc)
+					}
+					if res.Currency == "" {
+						res.Currency = tariff.Currency
+					}
+				}
+
+				key := PeriodStart(date, f.Resolution).Format(time.RFC3339)
+				total.add(key, elt.Energy, price, factor)
+
+				gr, ok := groups[elt.Group]
+				if !ok {
+					gr = make(costBuckets)
+					groups[elt.Group] = gr
+				}
+				gr.add(key, elt.Energy, price, factor)
+
+				tp, ok := types[driverType]
+				if !ok {
+					tp = make(costBuckets)
+					types[driverType] = tp
+				}
+				tp.add(key, elt.Energy, price, factor)
+			}
+		}
+	}
+
+	res.Cost = total.total()
+	res.Periods = total.periods()
+	for group, buckets := range groups {
+		res.Groups = append(res.Groups, GroupCost{
+			Group:   group,
+			Cost:    buckets.total(),
+			Periods: buckets.periods(),
+		})
+	}
+	sort.Slice(res.Groups, func(i, j int) bool {
+		return res.Groups[i].Group < res.Groups[j].Group
+	})
+	for driverType, buckets := range types {
+		res.Types = append(res.Types, TypeCost{
+			Type:    driverType,
+			Cost:    buckets.total(),
+			Periods: buckets.periods(),
+		})
+	}
+	sort.Slice(res.Types, func(i, j int) bool {
+		return res.Types[i].Type < res.Types[j].Type
+	})
+	return res
+}
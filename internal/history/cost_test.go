@@ -0,0 +1,58 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCostPurgeBoundary(t *testing.T) {
+	tests := []struct {
+		name      string
+		purgeHour int //hour of the day the hourly entries are purged until
+	}{
+		{"purged at midnight", 0},
+		{"purged in the middle of the day", 14},
+		{"purged at the end of the day", 23},
+	}
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	next := day.Add(24 * time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMemDb()
+			for _, date := range []time.Time{day.Add(-24 * time.Hour), day} {
+				SaveHistory(db, HistoryDB, TierTable(LedsTable, ResolutionDay), AggregateHistory{
+					Mac:    "LED",
+					Energy: 24,
+					Date:   date.Format(time.RFC3339),
+				})
+			}
+			for hour := tt.purgeHour; hour < 24; hour++ {
+				SaveHistory(db, HistoryDB, TierTable(LedsTable, ResolutionHour), AggregateHistory{
+					Mac:    "LED",
+					Energy: 1,
+					Date:   day.Add(time.Duration(hour) * time.Hour).Format(time.RFC3339),
+				})
+			}
+			SaveHistory(db, HistoryDB, LedsTable, LedHistory{
+				Mac:    "LED",
+				Energy: 1,
+				Date:   next.Format(time.RFC3339),
+			})
+			saveCompactionStatus(db, CompactionStatus{
+				Table:     TierTable(LedsTable, ResolutionHour),
+				Compacted: next.Format(time.RFC3339),
+				Purged:    day.Add(time.Duration(tt.purgeHour) * time.Hour).Format(time.RFC3339),
+			})
+
+			report := GetCost(db, map[string]string{"led": LedsTable}, nil, time.UTC, Filter{Resolution: ResolutionDay})
+			if report.Energy != 49 {
+				t.Errorf("energy %v, want 49", report.Energy)
+			}
+			for _, period := range report.Periods {
+				if period.Date == day.Format(time.RFC3339) && period.Energy != 24 {
+					t.Errorf("energy of the purged day %v, want 24", period.Energy)
+				}
+			}
+		})
+	}
+}
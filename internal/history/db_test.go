@@ -0,0 +1,160 @@
+package history
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/energieip/common-components-go/pkg/database"
+)
+
+//memDb in memory history database, the records are stored by field name as in rethinkdb
+type memDb struct {
+	database.DatabaseInterface
+	tables     map[string][]map[string]interface{}
+	lastID     int
+	failInsert func(tbName string, count int) error
+}
+
+func newMemDb() *memDb {
+	return &memDb{
+		tables: make(map[string][]map[string]interface{}),
+	}
+}
+
+func toRecord(obj interface{}) map[string]interface{} {
+	if m, ok := obj.(map[string]interface{}); ok {
+		return m
+	}
+	rec := make(map[string]interface{})
+	val := reflect.ValueOf(obj)
+	for i := 0; i < val.NumField(); i++ {
+		rec[val.Type().Field(i).Name] = val.Field(i).Interface()
+	}
+	return rec
+}
+
+func match(rec map[string]interface{}, criteria map[string]interface{}) bool {
+	for key, value := range criteria {
+		if !reflect.DeepEqual(rec[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *memDb) InsertRecord(dbName, tbName string, obj interface{}) (string, error) {
+	objs := []interface{}{obj}
+	if rows, ok := obj.([]interface{}); ok {
+		objs = rows
+	}
+	if db.failInsert != nil {
+		if err := db.failInsert(tbName, len(objs)); err != nil {
+			return "", err
+		}
+	}
+	id := ""
+	for _, elt := range objs {
+		db.lastID++
+		id = strconv.Itoa(db.lastID)
+		rec := toRecord(elt)
+		rec["id"] = id
+		db.tables[tbName] = append(db.tables[tbName], rec)
+	}
+	return id, nil
+}
+
+func (db *memDb) UpdateRecord(dbName, tbName, id string, obj interface{}) error {
+	for i, rec := range db.tables[tbName] {
+		if rec["id"] == id {
+			rec = toRecord(obj)
+			rec["id"] = id
+			db.tables[tbName][i] = rec
+		}
+	}
+	return nil
+}
+
+func (db *memDb) GetRecord(dbName, tbName string, criteria map[string]interface{}) (interface{}, error) {
+	for _, rec := range db.tables[tbName] {
+		if match(rec, criteria) {
+			return rec, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *memDb) GetRecords(dbName, tbName string, criteria map[string]interface{}) ([]interface{}, error) {
+	var res []interface{}
+	for _, rec := range db.tables[tbName] {
+		if match(rec, criteria) {
+			res = append(res, rec)
+		}
+	}
+	return res, nil
+}
+
+func (db *memDb) FetchAllRecords(dbName, tbName string) ([]interface{}, error) {
+	return db.GetRecords(dbName, tbName, nil)
+}
+
+func (db *memDb) DeleteRecord(dbName, tbName string, obj interface{}) error {
+	criteria, _ := obj.(map[string]interface{})
+	var kept []map[string]interface{}
+	for _, rec := range db.tables[tbName] {
+		if !match(rec, criteria) {
+			kept = append(kept, rec)
+		}
+	}
+	db.tables[tbName] = kept
+	return nil
+}
+
+func (db *memDb) GetRange(tbName string, criteria map[string]interface{}, from, until string) ([]interface{}, error) {
+	var res []interface{}
+	for _, rec := range db.tables[tbName] {
+		date, _ := rec["Date"].(string)
+		if match(rec, criteria) && (from == "" || date >= from) && (until == "" || date < until) {
+			res = append(res, rec)
+		}
+	}
+	return res, nil
+}
+
+func (db *memDb) SumPeriods(tbName string, criteria map[string]interface{}, from, until, resolution string) ([]PeriodSum, error) {
+	stored, _ := db.GetRange(tbName, criteria, from, until)
+	sums := make(map[string]*PeriodSum)
+	dates := make(map[string]bool)
+	for _, st := range stored {
+		elt, _ := ToAggregateHistory(st)
+		key := periodDate(elt.Date[:periodKeys[resolution]])
+		sum, ok := sums[key]
+		if !ok {
+			sum = &PeriodSum{Date: key}
+			sums[key] = sum
+		}
+		sum.Energy += elt.Energy
+		sum.Power += float64(elt.Power)
+		if !dates[elt.Date] {
+			dates[elt.Date] = true
+			sum.Dates++
+		}
+	}
+	var res []PeriodSum
+	for _, sum := range sums {
+		res = append(res, *sum)
+	}
+	return res, nil
+}
+
+func (db *memDb) FirstDate(tbName string) (string, error) {
+	var dates []string
+	for _, rec := range db.tables[tbName] {
+		dates = append(dates, rec["Date"].(string))
+	}
+	sort.Strings(dates)
+	if len(dates) == 0 {
+		return "", nil
+	}
+	return dates[0], nil
+}
@@ -4,21 +4,28 @@ import (
 	"encoding/json"
 
 	"github.com/energieip/common-components-go/pkg/database"
-	"github.com/energieip/common-components-go/pkg/dblind"
-	"github.com/energieip/common-components-go/pkg/dhvac"
-	dl "github.com/energieip/common-components-go/pkg/dled"
-	"github.com/energieip/common-components-go/pkg/dserver"
 	"github.com/romana/rlog"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v5"
 )
 
 const (
 	HistoryDB = "history"
 
-	LedsTable    = "leds"
-	BlindsTable  = "blinds"
-	SwitchsTable = "switchs"
-	HvacsTable   = "hvacs"
-	TdTable      = "tds"
+	LedsTable       = "leds"
+	BlindsTable     = "blinds"
+	SwitchsTable    = "switchs"
+	HvacsTable      = "hvacs"
+	SensorsTable    = "sensors"
+	NanosensesTable = "nanosenses"
+	TdTable         = "tds"
+
+	ConnectivityTable = "connectivity"
+	AuditTable        = "audit"
+
+	//DateIndex secondary index of the history tables on the entry date
+	DateIndex = "Date"
+	//MaxArraySize maximum number of entry dates summed in one query
+	MaxArraySize = 1000000
 )
 
 type databaseError struct {
@@ -34,11 +41,51 @@ func NewError(text string) error {
 	return &databaseError{text}
 }
 
-type HistoryDb = database.DatabaseInterface
+//HistoryDb history database: the common database completed with the date range queries
+type HistoryDb interface {
+	database.DatabaseInterface
+	//GetRange return the entries matching the criteria dated within [from, until), an empty bound is open
+	GetRange(tbName string, criteria map[string]interface{}, from, until string) ([]interface{}, error)
+	//SumPeriods return the energy and power of the entries matching the criteria within [from, until) summed per period
+	SumPeriods(tbName string, criteria map[string]interface{}, from, until, resolution string) ([]PeriodSum, error)
+	//FirstDate return the date of the oldest entry, empty when the table is empty
+	FirstDate(tbName string) (string, error)
+}
+
+//PeriodSum history entries summed over one period
+type PeriodSum struct {
+	Date   string  //period start date (RFC3339 UTC)
+	Energy float64 //Wh
+	Power  float64 //sum of the entries power (W)
+	Dates  int     //number of distinct entry dates
+}
+
+//historyDb rethinkdb history database, the range queries go through the Date index
+type historyDb struct {
+	database.DatabaseInterface
+	session *r.Session
+}
+
+//periodKeys length of the RFC3339 UTC date prefix identifying a period
+var periodKeys = map[string]int{
+	ResolutionMinute: len("2006-01-02T15:04"),
+	ResolutionHour:   len("2006-01-02T15"),
+	ResolutionDay:    len("2006-01-02"),
+	ResolutionMonth:  len("2006-01"),
+}
+
+//periodDate complete a period key into the period start date
+func periodDate(key string) string {
+	const zero = "0000-01-01T00:00:00Z"
+	if len(key) >= len(zero) {
+		return key
+	}
+	return key + zero[len(key):]
+}
 
 //ConnectDatabase plug datbase
 func ConnectDatabase(ip, port string) (*HistoryDb, error) {
-	db, err := database.NewDatabase(database.RETHINKDB)
+	common, err := database.NewDatabase(database.RETHINKDB)
 	if err != nil {
 		rlog.Error("database err " + err.Error())
 		return nil, err
@@ -48,61 +95,231 @@ func ConnectDatabase(ip, port string) (*HistoryDb, error) {
 		IP:   ip,
 		Port: port,
 	}
-	err = db.Initialize(confDb)
+	err = common.Initialize(confDb)
+	if err != nil {
+		rlog.Error("Cannot connect to database " + err.Error())
+		return nil, err
+	}
+
+	session, err := r.Connect(r.ConnectOpts{
+		Address: ip + ":" + port,
+	})
 	if err != nil {
 		rlog.Error("Cannot connect to database " + err.Error())
 		return nil, err
 	}
+	hdb := &historyDb{
+		DatabaseInterface: common,
+		session:           session,
+	}
 
 	for _, dbName := range []string{HistoryDB} {
-		err = db.CreateDB(dbName)
+		err = common.CreateDB(dbName)
 		if err != nil {
 			rlog.Warn("Create DB ", err.Error())
 		}
 
 		tableCfg := make(map[string]interface{})
-		tableCfg[LedsTable] = dl.Led{}
-		tableCfg[SwitchsTable] = dserver.SwitchDump{}
-		tableCfg[BlindsTable] = dblind.Blind{}
-		tableCfg[HvacsTable] = dhvac.Hvac{}
+		tableCfg[LedsTable] = LedHistory{}
+		tableCfg[SwitchsTable] = SwitchHistory{}
+		tableCfg[BlindsTable] = BlindHistory{}
+		tableCfg[HvacsTable] = HvacHistory{}
+		tableCfg[SensorsTable] = SensorHistory{}
+		tableCfg[NanosensesTable] = NanosenseHistory{}
+		tableCfg[CompactionTable] = CompactionStatus{}
+		tableCfg[ConnectivityTable] = ConnectivityHistory{}
+		tableCfg[AuditTable] = AuditEntry{}
+		for _, tbName := range []string{LedsTable, BlindsTable, HvacsTable, SwitchsTable} {
+			tableCfg[TierTable(tbName, ResolutionHour)] = AggregateHistory{}
+			tableCfg[TierTable(tbName, ResolutionDay)] = AggregateHistory{}
+		}
 
 		for tableName, objs := range tableCfg {
-			err = db.CreateTable(dbName, tableName, &objs)
+			err = common.CreateTable(dbName, tableName, &objs)
 			if err != nil {
 				rlog.Warn("Create table ", err.Error())
 			}
+			if tableName == CompactionTable {
+				continue
+			}
+			err = hdb.createDateIndex(tableName)
+			if err != nil {
+				rlog.Warn("Create index ", err.Error())
+			}
 		}
 	}
+	var db HistoryDb = hdb
 	return &db, nil
 }
 
+//createDateIndex add the Date index to a table
+func (db *historyDb) createDateIndex(tbName string) error {
+	cursor, err := r.DB(HistoryDB).Table(tbName).IndexList().Run(db.session)
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	err = cursor.All(&indexes)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index == DateIndex {
+			return nil
+		}
+	}
+	_, err = r.DB(HistoryDB).Table(tbName).IndexCreate(DateIndex).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB(HistoryDB).Table(tbName).IndexWait(DateIndex).Run(db.session)
+	return err
+}
+
+//between select the table entries matching the criteria within [from, until)
+func between(tbName string, criteria map[string]interface{}, from, until string) r.Term {
+	lower := interface{}(r.MinVal)
+	if from != "" {
+		lower = from
+	}
+	upper := interface{}(r.MaxVal)
+	if until != "" {
+		upper = until
+	}
+	term := r.DB(HistoryDB).Table(tbName).Between(lower, upper, r.BetweenOpts{Index: DateIndex})
+	if len(criteria) != 0 {
+		term = term.Filter(criteria)
+	}
+	return term
+}
+
+func (db *historyDb) GetRange(tbName string, criteria map[string]interface{}, from, until string) ([]interface{}, error) {
+	var res []interface{}
+	cursor, err := between(tbName, criteria, from, until).Run(db.session)
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(&res)
+	return res, err
+}
+
+func (db *historyDb) SumPeriods(tbName string, criteria map[string]interface{}, from, until, resolution string) ([]PeriodSum, error) {
+	var res []PeriodSum
+	size, ok := periodKeys[resolution]
+	if !ok {
+		return res, NewError("Invalid resolution " + resolution)
+	}
+	sum := func(left, right r.Term) interface{} {
+		return map[string]interface{}{
+			"Energy": left.Field("Energy").Add(right.Field("Energy")),
+			"Power":  left.Field("Power").Add(right.Field("Power")),
+			"Dates":  left.Field("Dates").Add(right.Field("Dates")),
+		}
+	}
+	//sum the drivers of each entry date first to count the dates of each period
+	cursor, err := between(tbName, criteria, from, until).
+		Group("Date").
+		Map(func(row r.Term) interface{} {
+			return map[string]interface{}{
+				"Energy": row.Field("Energy"),
+				"Power":  row.Field("Power"),
+				"Dates":  0,
+			}
+		}).
+		Reduce(sum).
+		Ungroup().
+		Group(func(row r.Term) interface{} {
+			return row.Field("group").Slice(0, size)
+		}).
+		Map(func(row r.Term) interface{} {
+			return map[string]interface{}{
+				"Energy": row.Field("reduction").Field("Energy"),
+				"Power":  row.Field("reduction").Field("Power"),
+				"Dates":  1,
+			}
+		}).
+		Reduce(sum).
+		Ungroup().
+		Map(func(row r.Term) interface{} {
+			return row.Field("reduction").Merge(map[string]interface{}{
+				"Date": row.Field("group"),
+			})
+		}).
+		Run(db.session, r.RunOpts{ArrayLimit: MaxArraySize})
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(&res)
+	for i := range res {
+		res[i].Date = periodDate(res[i].Date)
+	}
+	return res, err
+}
+
+func (db *historyDb) FirstDate(tbName string) (string, error) {
+	cursor, err := r.DB(HistoryDB).Table(tbName).OrderBy(r.OrderByOpts{Index: DateIndex}).
+		Limit(1).Field("Date").Run(db.session)
+	if err != nil {
+		return "", err
+	}
+	var res []string
+	err = cursor.All(&res)
+	if err != nil || len(res) == 0 {
+		return "", err
+	}
+	return res[0], nil
+}
+
+func (db *historyDb) Close() error {
+	db.session.Close()
+	return db.DatabaseInterface.Close()
+}
+
+//Ping check the database connectivity with a request on a small table
+func Ping(db HistoryDb) error {
+	_, err := db.FetchAllRecords(HistoryDB, CompactionTable)
+	return err
+}
+
+//LedHistory led consumption over one sampling period
 type LedHistory struct {
-	Mac    string  `json:"mac"`
-	Energy float64 `json:"energy"`
-	Power  int     `json:"power"`
-	Date   string  `json:"date"`
-	Group  int     `json:"group"`
+	Mac       string  `json:"mac"`
+	SwitchMac string  `json:"switchMac"`
+	Label     string  `json:"label"`
+	Energy    float64 `json:"energy"` //consumed energy during the period (Wh)
+	Power     int     `json:"power"`  //average line power during the period (W)
+	Date      string  `json:"date"`   //period start date (RFC3339 UTC)
+	Group     int     `json:"group"`
 }
 
+//BlindHistory blind consumption over one sampling period
 type BlindHistory struct {
-	Mac    string  `json:"mac"`
-	Energy float64 `json:"energy"`
-	Power  int     `json:"power"`
-	Date   string  `json:"date"`
-	Group  int     `json:"group"`
+	Mac       string  `json:"mac"`
+	SwitchMac string  `json:"switchMac"`
+	Label     string  `json:"label"`
+	Energy    float64 `json:"energy"`
+	Power     int     `json:"power"`
+	Date      string  `json:"date"`
+	Group     int     `json:"group"`
 }
 
+//HvacHistory hvac consumption over one sampling period
 type HvacHistory struct {
-	Mac   string `json:"mac"`
-	Power int    `json:"power"`
-	Date  string `json:"date"`
-	Group int    `json:"group"`
+	Mac       string  `json:"mac"`
+	SwitchMac string  `json:"switchMac"`
+	Label     string  `json:"label"`
+	Energy    float64 `json:"energy"`
+	Power     int     `json:"power"`
+	Date      string  `json:"date"`
+	Group     int     `json:"group"`
 }
 
+//SwitchHistory power and energy reported by a switch over one sampling period
 type SwitchHistory struct {
 	Mac     string  `json:"mac"`
-	Energy  float64 `json:"energy"`
-	Power   int     `json:"power"`
+	Label   string  `json:"label"`
+	Energy  float64 `json:"energy"` //total energy consumed during the period (Wh)
+	Power   int     `json:"power"`  //average total power during the period (W)
 	Date    string  `json:"date"`
 	Cluster int     `json:"cluster"`
 }
@@ -140,46 +357,12 @@ func ToBlindHistory(val interface{}) (*BlindHistory, error) {
 	return &driver, err
 }
 
+//SaveHistory insert one history entry or a batch of entries
 func SaveHistory(db HistoryDb, dbName, tbName string, obj interface{}) error {
 	_, err := db.InsertRecord(dbName, tbName, obj)
 	return err
 }
 
-func SaveLedHistory(db HistoryDb, driver dl.Led) error {
-	// led := LedHistory{
-	// 	Mac:    driver.Mac,
-	// 	Energy: driver.Energy,
-	// 	Power:  driver.LinePower,
-	// 	Group:  driver.Group,
-	// 	Date:   time.Now().Format(time.RFC850),
-	// }
-	// return SaveHistory(db, HistoryDB, LedsTable, led)
-	return nil
-}
-
-func SaveBlindHistory(db HistoryDb, driver dblind.Blind) error {
-	// blind := BlindHistory{
-	// 	Mac: driver.Mac,
-	// 	// Energy: driver.Energy,
-	// 	Power: driver.LinePower,
-	// 	Group: driver.Group,
-	// 	Date:  time.Now().Format(time.RFC850),
-	// }
-	// return SaveHistory(db, HistoryDB, BlindsTable, blind)
-	return nil
-}
-
-func SaveHvacHistory(db HistoryDb, driver dhvac.Hvac) error {
-	// blind := HvacHistory{
-	// 	Mac:   driver.Mac,
-	// 	Power: driver.LinePower,
-	// 	Group: driver.Group,
-	// 	Date:  time.Now().Format(time.RFC850),
-	// }
-	// return SaveHistory(db, HistoryDB, BlindsTable, blind)
-	return nil
-}
-
 func GetBlindsHistory(db HistoryDb) []BlindHistory {
 	var history []BlindHistory
 	stored, err := db.FetchAllRecords(HistoryDB, BlindsTable)
@@ -0,0 +1,165 @@
+package history
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+//NanosenseHistory air quality measures over one sampling period
+type NanosenseHistory struct {
+	Mac         string  `json:"mac"`
+	Label       string  `json:"label"`
+	Group       int     `json:"group"`
+	Temperature float64 `json:"temperature"` //average temperature (°C)
+	CO2         float64 `json:"co2"`         //average CO2 (ppm)
+	COV         float64 `json:"cov"`         //average COV (ppm)
+	Hygrometry  float64 `json:"hygrometry"`  //average hygrometry (%)
+	MaxCO2      float64 `json:"maxCo2"`      //ppm
+	MaxCOV      float64 `json:"maxCov"`      //ppm
+	Samples     int     `json:"samples"`     //number of sensor samples
+	Date        string  `json:"date"`        //period start date (RFC3339 UTC)
+}
+
+//NanosensePoint air quality measures aggregated over one period
+type NanosensePoint struct {
+	Date        string  `json:"date"`
+	Temperature float64 `json:"temperature"`
+	CO2         float64 `json:"co2"`
+	COV         float64 `json:"cov"`
+	Hygrometry  float64 `json:"hygrometry"`
+	MaxCO2      float64 `json:"maxCo2"`
+	MaxCOV      float64 `json:"maxCov"`
+}
+
+//Exceedance number of sampling periods above the thresholds during one day
+type Exceedance struct {
+	Date string `json:"date"`
+	CO2  int    `json:"co2"`
+	COV  int    `json:"cov"`
+}
+
+//NanosenseSeries air quality history of one nanosense
+type NanosenseSeries struct {
+	Mac         string           `json:"mac"`
+	Label       string           `json:"label"`
+	Group       int              `json:"group"`
+	Values      []NanosensePoint `json:"values"`
+	Exceedances []Exceedance     `json:"exceedances"`
+}
+
+//AirQualityThresholds exceedance limits
+type AirQualityThresholds struct {
+	CO2 float64 `json:"co2"` //ppm
+	COV float64 `json:"cov"` //ppm
+}
+
+type nanosenseBucket struct {
+	point   NanosensePoint
+	samples int
+}
+
+//ToNanosenseHistory convert map interface to NanosenseHistory object
+func ToNanosenseHistory(val interface{}) (*NanosenseHistory, error) {
+	var driver NanosenseHistory
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &driver)
+	if driver.Samples == 0 {
+		driver.Samples = 1
+	}
+	return &driver, err
+}
+
+//GetNanosensesHistory return the air quality of each nanosense aggregated per period
+//with the daily count of sampling periods above the thresholds
+func GetNanosensesHistory(db HistoryDb, f Filter, thresholds AirQualityThresholds) []NanosenseSeries {
+	res := []NanosenseSeries{}
+	series := make(map[string]*NanosenseSeries)
+	buckets := make(map[string]map[string]*nanosenseBucket) //mac -> period -> values
+	exceedances := make(map[string]map[string]*Exceedance)  //mac -> day -> counters
+	for _, st := range getSourceRecords(db, source{table: NanosensesTable, period: DefaultSamplePeriod}, f) {
+		elt, err := ToNanosenseHistory(st)
+		if err != nil || elt == nil {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, elt.Date)
+		if err != nil || !f.inRange(date) {
+			continue
+		}
+		serie, ok := series[elt.Mac]
+		if !ok {
+			serie = &NanosenseSeries{
+				Mac: elt.Mac,
+			}
+			series[elt.Mac] = serie
+			buckets[elt.Mac] = make(map[string]*nanosenseBucket)
+			exceedances[elt.Mac] = make(map[string]*Exceedance)
+		}
+		serie.Label = elt.Label
+		serie.Group = elt.Group
+
+		key := PeriodStart(date, f.Resolution).Format(time.RFC3339)
+		val, ok := buckets[elt.Mac][key]
+		if !ok {
+			val = &nanosenseBucket{}
+			buckets[elt.Mac][key] = val
+		}
+		weight := float64(elt.Samples)
+		val.point.Temperature += elt.Temperature * weight
+		val.point.CO2 += elt.CO2 * weight
+		val.point.COV += elt.COV * weight
+		val.point.Hygrometry += elt.Hygrometry * weight
+		if elt.MaxCO2 > val.point.MaxCO2 {
+			val.point.MaxCO2 = elt.MaxCO2
+		}
+		if elt.MaxCOV > val.point.MaxCOV {
+			val.point.MaxCOV = elt.MaxCOV
+		}
+		val.samples += elt.Samples
+
+		day := PeriodStart(date, ResolutionDay).Format(time.RFC3339)
+		exceed, ok := exceedances[elt.Mac][day]
+		if !ok {
+			exceed = &Exceedance{
+				Date: day,
+			}
+			exceedances[elt.Mac][day] = exceed
+		}
+		if thresholds.CO2 > 0 && elt.CO2 > thresholds.CO2 {
+			exceed.CO2++
+		}
+		if thresholds.COV > 0 && elt.COV > thresholds.COV {
+			exceed.COV++
+		}
+	}
+
+	for mac, serie := range series {
+		for date, val := range buckets[mac] {
+			weight := float64(val.samples)
+			point := val.point
+			point.Date = date
+			point.Temperature /= weight
+			point.CO2 /= weight
+			point.COV /= weight
+			point.Hygrometry /= weight
+			serie.Values = append(serie.Values, point)
+		}
+		sort.Slice(serie.Values, func(i, j int) bool {
+			return serie.Values[i].Date < serie.Values[j].Date
+		})
+		for _, exceed := range exceedances[mac] {
+			serie.Exceedances = append(serie.Exceedances, *exceed)
+		}
+		sort.Slice(serie.Exceedances, func(i, j int) bool {
+			return serie.Exceedances[i].Date < serie.Exceedances[j].Date
+		})
+		res = append(res, *serie)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Mac < res[j].Mac
+	})
+	return res
+}
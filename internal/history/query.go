@@ -0,0 +1,256 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	ResolutionMinute = "minute"
+	ResolutionHour   = "hour"
+	ResolutionDay    = "day"
+	ResolutionMonth  = "month"
+)
+
+//DefaultRanges time range read per resolution when the request does not give the start date
+var DefaultRanges = map[string]time.Duration{
+	ResolutionMinute: 24 * time.Hour,
+	ResolutionHour:   7 * 24 * time.Hour,
+	ResolutionDay:    31 * 24 * time.Hour,
+	ResolutionMonth:  366 * 24 * time.Hour,
+}
+
+//Filter history request parameters
+type Filter struct {
+	From       *time.Time
+	To         *time.Time
+	Resolution string
+	Group      *int
+	Mac        string
+	Label      string
+	SwitchMac  string
+}
+
+//Point aggregated consumption over one period
+type Point struct {
+	Date   string  `json:"date"`   //period start date (RFC3339 UTC)
+	Energy float64 `json:"energy"` //consumed energy (Wh)
+	Power  int     `json:"power"`  //average power (W)
+}
+
+type bucket struct {
+	energy   float64
+	power    float64 //power weighted by the entries period
+	duration float64 //hours
+}
+
+//source table read for a part of the requested time range
+type source struct {
+	table  string
+	period time.Duration
+	from   *time.Time
+	until  *time.Time
+}
+
+//IsValidResolution check the resolution name
+func IsValidResolution(resolution string) bool {
+	switch resolution {
+	case ResolutionMinute, ResolutionHour, ResolutionDay, ResolutionMonth:
+		return true
+	}
+	return false
+}
+
+//PeriodStart return the beginning of the period containing date
+func PeriodStart(date time.Time, resolution string) time.Time {
+	date = date.UTC()
+	switch resolution {
+	case ResolutionHour:
+		return date.Truncate(time.Hour)
+	case ResolutionDay:
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	case ResolutionMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return date.Truncate(time.Minute)
+}
+
+//criteria translate the filter into database equality criteria
+func (f Filter) criteria(tbName string) map[string]interface{} {
+	criteria := make(map[string]interface{})
+	if f.Mac != "" {
+		criteria["Mac"] = f.Mac
+	}
+	if tbName == SwitchsTable {
+		return criteria
+	}
+	if f.SwitchMac != "" {
+		criteria["SwitchMac"] = f.SwitchMac
+	}
+	if f.Label != "" {
+		criteria["Label"] = f.Label
+	}
+	if f.Group != nil {
+		criteria["Group"] = *f.Group
+	}
+	return criteria
+}
+
+//Bound set the missing range dates: the request ends at now and covers the default range of the resolution
+func (f *Filter) Bound(now time.Time) {
+	if f.To == nil {
+		f.To = &now
+	}
+	if f.From == nil {
+		duration, ok := DefaultRanges[f.Resolution]
+		if !ok {
+			duration = DefaultRanges[ResolutionMinute]
+		}
+		from := f.To.Add(-duration)
+		f.From = &from
+	}
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.UTC().Format(time.RFC3339)
+}
+
+func (f Filter) inRange(date time.Time) bool {
+	if f.From != nil && date.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !date.Before(*f.To) {
+		return false
+	}
+	return true
+}
+
+func (src source) inRange(date time.Time) bool {
+	if src.from != nil && date.Before(*src.from) {
+		return false
+	}
+	if src.until != nil && !date.Before(*src.until) {
+		return false
+	}
+	return true
+}
+
+//getSources return the tables to read: the compacted periods come from the
+//coarsest aggregated table and the most recent ones from the raw table
+func getSources(db HistoryDb, tbName, resolution string) []source {
+	var res []source
+	var tiers []string
+	switch resolution {
+	case ResolutionHour:
+		tiers = []string{ResolutionHour}
+	case ResolutionDay, ResolutionMonth:
+		tiers = []string{ResolutionDay, ResolutionHour}
+	}
+	periods := map[string]time.Duration{
+		ResolutionHour: time.Hour,
+		ResolutionDay:  24 * time.Hour,
+	}
+
+	var from *time.Time
+	for _, tier := range tiers {
+		table := TierTable(tbName, tier)
+		until := getCompacted(db, table)
+		if until == nil {
+			continue
+		}
+		res = append(res, source{
+			table:  table,
+			period: periods[tier],
+			from:   from,
+			until:  until,
+		})
+		from = until
+	}
+	res = append(res, source{
+		table:  tbName,
+		period: DefaultSamplePeriod,
+		from:   from,
+	})
+	return res
+}
+
+//getRecords return the table entries matching the filter
+func getRecords(db HistoryDb, tbName string, f Filter) []interface{} {
+	stored, err := db.GetRange(tbName, f.criteria(tbName), formatDate(f.From), formatDate(f.To))
+	if err != nil {
+		return nil
+	}
+	return stored
+}
+
+//sourceRange return the part of the filter range read from the source
+func sourceRange(src source, f Filter) (string, string) {
+	from, until := f.From, f.To
+	if src.from != nil && (from == nil || src.from.After(*from)) {
+		from = src.from
+	}
+	if src.until != nil && (until == nil || src.until.Before(*until)) {
+		until = src.until
+	}
+	return formatDate(from), formatDate(until)
+}
+
+//getSourceRecords return the source entries matching the filter
+func getSourceRecords(db HistoryDb, src source, f Filter) []interface{} {
+	from, until := sourceRange(src, f)
+	if from != "" && until != "" && from >= until {
+		return nil
+	}
+	stored, err := db.GetRange(src.table, f.criteria(src.table), from, until)
+	if err != nil {
+		return nil
+	}
+	return stored
+}
+
+//GetHistory return the consumption of a history table aggregated per period,
+//the entries are summed per period by the database
+func GetHistory(db HistoryDb, tbName string, f Filter) []Point {
+	res := []Point{}
+	f.Bound(time.Now().UTC())
+	buckets := make(map[string]*bucket)
+	for _, src := range getSources(db, tbName, f.Resolution) {
+		from, until := sourceRange(src, f)
+		if from >= until {
+			continue
+		}
+		sums, err := db.SumPeriods(src.table, f.criteria(src.table), from, until, f.Resolution)
+		if err != nil {
+			continue
+		}
+		for _, sum := range sums {
+			val, ok := buckets[sum.Date]
+			if !ok {
+				val = &bucket{}
+				buckets[sum.Date] = val
+			}
+			val.energy += sum.Energy
+			val.power += sum.Power * src.period.Hours()
+			val.duration += float64(sum.Dates) * src.period.Hours()
+		}
+	}
+
+	for date, val := range buckets {
+		power := 0
+		if val.duration > 0 {
+			power = int(val.power / val.duration)
+		}
+		res = append(res, Point{
+			Date:   date,
+			Energy: val.energy,
+			Power:  power,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Date < res[j].Date
+	})
+	return res
+}
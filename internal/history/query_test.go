@@ -0,0 +1,68 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetHistoryRange(t *testing.T) {
+	start := PeriodStart(time.Now().UTC(), ResolutionHour).Add(-2 * time.Hour)
+	at := func(minutes float64) *time.Time {
+		date := start.Add(time.Duration(minutes * float64(time.Minute)))
+		return &date
+	}
+	tests := []struct {
+		name   string
+		from   *time.Time
+		to     *time.Time
+		energy float64
+	}{
+		{"all", nil, nil, 10},
+		{"from", at(4), nil, 6},
+		{"to", nil, at(4), 4},
+		{"bounded", at(2), at(5), 3},
+		{"unaligned bounds", at(1.5), at(3.5), 2},
+		{"default range", nil, at(7*24*60 + 3), 7},
+		{"empty", at(20), at(30), 0},
+	}
+
+	db := newMemDb()
+	for i := 0; i < 10; i++ {
+		SaveHistory(db, HistoryDB, LedsTable, LedHistory{
+			Mac:    "LED",
+			Energy: 1,
+			Power:  60,
+			Date:   at(float64(i)).Format(time.RFC3339),
+		})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filter{
+				From:       tt.from,
+				To:         tt.to,
+				Resolution: ResolutionHour,
+				Mac:        "LED",
+			}
+			energy := sumEnergy(GetHistory(db, LedsTable, f))
+			if energy != tt.energy {
+				t.Errorf("energy %v, want %v", energy, tt.energy)
+			}
+		})
+	}
+}
+
+func TestGetHistoryPower(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	db := newMemDb()
+	for i := 0; i < 10; i++ {
+		date := start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+		SaveHistory(db, HistoryDB, LedsTable, LedHistory{Mac: "LED1", Energy: 1, Power: 60, Date: date})
+		SaveHistory(db, HistoryDB, LedsTable, LedHistory{Mac: "LED2", Energy: 0.5, Power: 30 + 10*(i%2), Date: date})
+	}
+	to := start.Add(time.Hour)
+	f := Filter{From: &start, To: &to, Resolution: ResolutionHour}
+	res := GetHistory(db, LedsTable, f)
+	if len(res) != 1 || res[0].Date != start.Format(time.RFC3339) || res[0].Energy != 15 || res[0].Power != 95 {
+		t.Errorf("got %+v, want one hour of 15Wh at 95W", res)
+	}
+}
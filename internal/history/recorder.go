@@ -0,0 +1,354 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dblind"
+	"github.com/energieip/common-components-go/pkg/dhvac"
+	dl "github.com/energieip/common-components-go/pkg/dled"
+	"github.com/energieip/common-components-go/pkg/dnanosense"
+	ds "github.com/energieip/common-components-go/pkg/dsensor"
+	sd "github.com/energieip/common-components-go/pkg/dswitch"
+	"github.com/romana/rlog"
+)
+
+const (
+	//DefaultSamplePeriod duration of one history sample
+	DefaultSamplePeriod = 60 * time.Second
+
+	//MaxBatchSize maximum number of entries inserted in one database request
+	MaxBatchSize = 500
+)
+
+//sample accumulate the values received from one driver during a sampling period
+type sample struct {
+	mac       string
+	switchMac string
+	label     string
+	group     int //cluster for the switchs
+	powerSum  int
+	count     int
+	energy    float64
+}
+
+//sensorSample accumulate the measures received from one sensor during a sampling period
+type sensorSample struct {
+	mac         string
+	label       string
+	group       int
+	temperature int
+	humidity    int
+	brightness  int
+	presence    int
+	count       int
+}
+
+//nanosenseSample accumulate the measures received from one nanosense during a sampling period
+type nanosenseSample struct {
+	mac         string
+	label       string
+	group       int
+	temperature int
+	co2         int
+	cov         int
+	hygrometry  int
+	maxCO2      int
+	maxCOV      int
+	count       int
+}
+
+//Recorder buffers the drivers values received in the switch dumps and
+//periodically writes one aggregated entry per driver in the history database
+type Recorder struct {
+	db       HistoryDb
+	period   time.Duration
+	mutex    sync.Mutex
+	start    time.Time
+	samples  map[string]map[string]*sample //table -> mac -> sample
+	sensors  map[string]*sensorSample      //mac -> sample
+	nanos    map[string]*nanosenseSample   //label -> sample
+	counters map[string]float64            //last energy counter per led and switch
+	lastSeen map[string]time.Time          //last sample date per driver
+}
+
+//NewRecorder create a history recorder
+func NewRecorder(db HistoryDb, period time.Duration) *Recorder {
+	if period <= 0 {
+		period = DefaultSamplePeriod
+	}
+	r := Recorder{
+		db:       db,
+		period:   period,
+		counters: make(map[string]float64),
+		lastSeen: make(map[string]time.Time),
+	}
+	r.reset(time.Now().UTC())
+	return &r
+}
+
+func (r *Recorder) reset(now time.Time) {
+	r.start = now.Truncate(r.period)
+	r.samples = make(map[string]map[string]*sample)
+	for _, tbName := range []string{LedsTable, BlindsTable, HvacsTable, SwitchsTable} {
+		r.samples[tbName] = make(map[string]*sample)
+	}
+	r.sensors = make(map[string]*sensorSample)
+	r.nanos = make(map[string]*nanosenseSample)
+}
+
+//elapsed return the time since the previous sample of the driver, bounded by the sampling period
+func (r *Recorder) elapsed(key string, now time.Time) time.Duration {
+	last, ok := r.lastSeen[key]
+	r.lastSeen[key] = now
+	if !ok {
+		return 0
+	}
+	duration := now.Sub(last)
+	if duration > r.period {
+		duration = r.period
+	}
+	return duration
+}
+
+//counter return the energy consumed since the previous value of the counter
+func (r *Recorder) counter(key string, value float64) float64 {
+	energy := 0.0
+	last, ok := r.counters[key]
+	if ok {
+		energy = value - last
+		if energy < 0 {
+			//counter reset on the driver side
+			energy = value
+		}
+	}
+	r.counters[key] = value
+	return energy
+}
+
+func (r *Recorder) add(tbName, mac, switchMac string, label *string, group, power int, energy float64) {
+	elt, ok := r.samples[tbName][mac]
+	if !ok {
+		elt = &sample{
+			mac: mac,
+		}
+		r.samples[tbName][mac] = elt
+	}
+	elt.switchMac = switchMac
+	if label != nil {
+		elt.label = *label
+	}
+	elt.group = group
+	elt.powerSum += power
+	elt.count++
+	elt.energy += energy
+}
+
+//AddLed register a led sample
+func (r *Recorder) AddLed(driver dl.Led) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.elapsed(LedsTable+driver.Mac, time.Now().UTC())
+	energy := r.counter(LedsTable+driver.Mac, driver.Energy)
+	r.add(LedsTable, driver.Mac, driver.SwitchMac, driver.Label, driver.Group, driver.LinePower, energy)
+}
+
+//AddBlind register a blind sample, the energy is integrated from the line power
+func (r *Recorder) AddBlind(driver dblind.Blind) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	duration := r.elapsed(BlindsTable+driver.Mac, time.Now().UTC())
+	energy := float64(driver.LinePower) * duration.Hours()
+	r.add(BlindsTable, driver.Mac, driver.SwitchMac, driver.Label, driver.Group, driver.LinePower, energy)
+}
+
+//AddHvac register a hvac sample, the energy is integrated from the line power
+func (r *Recorder) AddHvac(driver dhvac.Hvac) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	duration := r.elapsed(HvacsTable+driver.Mac, time.Now().UTC())
+	energy := float64(driver.LinePower) * duration.Hours()
+	r.add(HvacsTable, driver.Mac, driver.SwitchMac, driver.Label, driver.Group, driver.LinePower, energy)
+}
+
+//AddSwitch register the total power and energy reported by a switch
+func (r *Recorder) AddSwitch(status sd.SwitchStatus) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	energy := r.counter(SwitchsTable+status.Mac, float64(status.TotalEnergy))
+	r.add(SwitchsTable, status.Mac, status.Mac, status.Label, status.Cluster, int(status.TotalPower), energy)
+}
+
+//AddSensor register a sensor sample
+func (r *Recorder) AddSensor(driver ds.Sensor) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	elt, ok := r.sensors[driver.Mac]
+	if !ok {
+		elt = &sensorSample{
+			mac: driver.Mac,
+		}
+		r.sensors[driver.Mac] = elt
+	}
+	if driver.Label != nil {
+		elt.label = *driver.Label
+	}
+	elt.group = driver.Group
+	elt.temperature += driver.Temperature
+	elt.humidity += driver.Humidity
+	elt.brightness += driver.Brightness
+	if driver.Presence {
+		elt.presence++
+	}
+	elt.count++
+}
+
+//AddNanosense register a nanosense sample
+func (r *Recorder) AddNanosense(driver dnanosense.Nanosense) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	elt, ok := r.nanos[driver.Label]
+	if !ok {
+		elt = &nanosenseSample{
+			label: driver.Label,
+		}
+		r.nanos[driver.Label] = elt
+	}
+	elt.mac = driver.Mac
+	elt.group = driver.Group
+	elt.temperature += driver.Temperature
+	elt.co2 += driver.CO2
+	elt.cov += driver.COV
+	elt.hygrometry += driver.Hygrometry
+	if driver.CO2 > elt.maxCO2 {
+		elt.maxCO2 = driver.CO2
+	}
+	if driver.COV > elt.maxCOV {
+		elt.maxCOV = driver.COV
+	}
+	elt.count++
+}
+
+//Flush write the pending samples in the history database
+func (r *Recorder) Flush() {
+	r.mutex.Lock()
+	samples := r.samples
+	sensors := r.sensors
+	nanos := r.nanos
+	date := r.start.Format(time.RFC3339)
+	r.reset(time.Now().UTC())
+	r.mutex.Unlock()
+
+	for tbName, drivers := range samples {
+		var rows []interface{}
+		for _, elt := range drivers {
+			if elt.count == 0 {
+				continue
+			}
+			power := elt.powerSum / elt.count
+			switch tbName {
+			case LedsTable:
+				rows = append(rows, LedHistory{
+					Mac:       elt.mac,
+					SwitchMac: elt.switchMac,
+					Label:     elt.label,
+					Energy:    elt.energy,
+					Power:     power,
+					Date:      date,
+					Group:     elt.group,
+				})
+			case BlindsTable:
+				rows = append(rows, BlindHistory{
+					Mac:       elt.mac,
+					SwitchMac: elt.switchMac,
+					Label:     elt.label,
+					Energy:    elt.energy,
+					Power:     power,
+					Date:      date,
+					Group:     elt.group,
+				})
+			case SwitchsTable:
+				rows = append(rows, SwitchHistory{
+					Mac:     elt.mac,
+					Label:   elt.label,
+					Energy:  elt.energy,
+					Power:   power,
+					Date:    date,
+					Cluster: elt.group,
+				})
+			case HvacsTable:
+				rows = append(rows, HvacHistory{
+					Mac:       elt.mac,
+					SwitchMac: elt.switchMac,
+					Label:     elt.label,
+					Energy:    elt.energy,
+					Power:     power,
+					Date:      date,
+					Group:     elt.group,
+				})
+			}
+		}
+		err := saveBatch(r.db, tbName, rows)
+		if err != nil {
+			rlog.Error("Cannot save " + tbName + " history: " + err.Error())
+		}
+	}
+
+	var rows []interface{}
+	for _, elt := range sensors {
+		//sensors values are given in tenth of unit
+		count := float64(elt.count * 10)
+		rows = append(rows, SensorHistory{
+			Mac:         elt.mac,
+			Label:       elt.label,
+			Group:       elt.group,
+			Temperature: float64(elt.temperature) / count,
+			Humidity:    float64(elt.humidity) / count,
+			Brightness:  float64(elt.brightness) / count,
+			Presence:    elt.presence > 0,
+			Occupancy:   elt.presence * 100 / elt.count,
+			Samples:     elt.count,
+			Date:        date,
+		})
+	}
+	err := saveBatch(r.db, SensorsTable, rows)
+	if err != nil {
+		rlog.Error("Cannot save " + SensorsTable + " history: " + err.Error())
+	}
+
+	rows = []interface{}{}
+	for _, elt := range nanos {
+		//nanosenses values are given in tenth of unit
+		count := float64(elt.count * 10)
+		rows = append(rows, NanosenseHistory{
+			Mac:         elt.mac,
+			Label:       elt.label,
+			Group:       elt.group,
+			Temperature: float64(elt.temperature) / count,
+			CO2:         float64(elt.co2) / count,
+			COV:         float64(elt.cov) / count,
+			Hygrometry:  float64(elt.hygrometry) / count,
+			MaxCO2:      float64(elt.maxCO2) / 10,
+			MaxCOV:      float64(elt.maxCOV) / 10,
+			Samples:     elt.count,
+			Date:        date,
+		})
+	}
+	err = saveBatch(r.db, NanosensesTable, rows)
+	if err != nil {
+		rlog.Error("Cannot save " + NanosensesTable + " history: " + err.Error())
+	}
+}
+
+//Run flush the samples at the end of each sampling period
+func (r *Recorder) Run() {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(r.period).Add(r.period)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+			r.Flush()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package history
+
+import (
+	"testing"
+
+	dl "github.com/energieip/common-components-go/pkg/dled"
+	sd "github.com/energieip/common-components-go/pkg/dswitch"
+)
+
+func TestFlushSwitchReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		switchMac string
+		switchWh  int64
+		ledsWh    []float64
+		deviation float64
+	}{
+		{"matching drivers", "SW1", 100, []float64{60, 40}, 0},
+		{"missing driver", "SW2", 200, []float64{50}, 75},
+		{"no driver", "SW3", 80, nil, 100},
+	}
+
+	db := newMemDb()
+	r := NewRecorder(db, 0)
+	//the first values of the counters are the reference of the next samples
+	for _, tt := range tests {
+		r.AddSwitch(sd.SwitchStatus{Mac: tt.switchMac})
+		for i := range tt.ledsWh {
+			r.AddLed(dl.Led{Mac: tt.switchMac + "-" + string(rune('A'+i)), SwitchMac: tt.switchMac})
+		}
+	}
+	for _, tt := range tests {
+		r.AddSwitch(sd.SwitchStatus{Mac: tt.switchMac, TotalEnergy: tt.switchWh})
+		for i, energy := range tt.ledsWh {
+			r.AddLed(dl.Led{Mac: tt.switchMac + "-" + string(rune('A'+i)), SwitchMac: tt.switchMac, Energy: energy})
+		}
+	}
+	r.Flush()
+
+	switchs := make(map[string]string)
+	for _, tt := range tests {
+		switchs[tt.switchMac] = ""
+	}
+	reports := make(map[string]SwitchReport)
+	for _, report := range GetSwitchsReport(db, switchs, nil, nil, 10) {
+		reports[report.Mac] = report
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := reports[tt.switchMac]
+			if report.SwitchEnergy != float64(tt.switchWh) {
+				t.Errorf("switch energy %v, want %v", report.SwitchEnergy, tt.switchWh)
+			}
+			if report.Deviation != tt.deviation {
+				t.Errorf("deviation %v, want %v", report.Deviation, tt.deviation)
+			}
+			if report.Discrepancy != (tt.deviation > 10) {
+				t.Errorf("discrepancy %v for a deviation of %v", report.Discrepancy, tt.deviation)
+			}
+		})
+	}
+}
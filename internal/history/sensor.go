@@ -0,0 +1,125 @@
+package history
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+//SensorHistory sensor measures over one sampling period
+type SensorHistory struct {
+	Mac         string  `json:"mac"`
+	Label       string  `json:"label"`
+	Group       int     `json:"group"`
+	Temperature float64 `json:"temperature"` //average temperature (°C)
+	Humidity    float64 `json:"humidity"`    //average humidity (%)
+	Brightness  float64 `json:"brightness"`  //average brightness (Lux)
+	Presence    bool    `json:"presence"`    //presence detected during the period
+	Occupancy   int     `json:"occupancy"`   //percentage of the samples with presence
+	Samples     int     `json:"samples"`     //number of sensor samples
+	Date        string  `json:"date"`        //period start date (RFC3339 UTC)
+}
+
+//SensorPoint sensor measures aggregated over one period
+type SensorPoint struct {
+	Date        string  `json:"date"`
+	Temperature float64 `json:"temperature"`
+	Humidity    float64 `json:"humidity"`
+	Brightness  float64 `json:"brightness"`
+	Presence    bool    `json:"presence"`
+	Occupancy   int     `json:"occupancy"`
+}
+
+//SensorSeries measures history of one sensor
+type SensorSeries struct {
+	Mac    string        `json:"mac"`
+	Label  string        `json:"label"`
+	Group  int           `json:"group"`
+	Values []SensorPoint `json:"values"`
+}
+
+type sensorBucket struct {
+	temperature float64
+	humidity    float64
+	brightness  float64
+	occupancy   int
+	presence    bool
+	samples     int
+}
+
+//ToSensorHistory convert map interface to SensorHistory object
+func ToSensorHistory(val interface{}) (*SensorHistory, error) {
+	var driver SensorHistory
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &driver)
+	if driver.Samples == 0 {
+		driver.Samples = 1
+	}
+	return &driver, err
+}
+
+//GetSensorsHistory return the measures of each sensor aggregated per period
+func GetSensorsHistory(db HistoryDb, f Filter) []SensorSeries {
+	res := []SensorSeries{}
+	series := make(map[string]*SensorSeries)
+	buckets := make(map[string]map[string]*sensorBucket) //mac -> period -> values
+	for _, st := range getSourceRecords(db, source{table: SensorsTable, period: DefaultSamplePeriod}, f) {
+		elt, err := ToSensorHistory(st)
+		if err != nil || elt == nil {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, elt.Date)
+		if err != nil || !f.inRange(date) {
+			continue
+		}
+		serie, ok := series[elt.Mac]
+		if !ok {
+			serie = &SensorSeries{
+				Mac: elt.Mac,
+			}
+			series[elt.Mac] = serie
+			buckets[elt.Mac] = make(map[string]*sensorBucket)
+		}
+		serie.Label = elt.Label
+		serie.Group = elt.Group
+
+		key := PeriodStart(date, f.Resolution).Format(time.RFC3339)
+		val, ok := buckets[elt.Mac][key]
+		if !ok {
+			val = &sensorBucket{}
+			buckets[elt.Mac][key] = val
+		}
+		weight := float64(elt.Samples)
+		val.temperature += elt.Temperature * weight
+		val.humidity += elt.Humidity * weight
+		val.brightness += elt.Brightness * weight
+		val.occupancy += elt.Occupancy * elt.Samples
+		val.presence = val.presence || elt.Presence
+		val.samples += elt.Samples
+	}
+
+	for mac, serie := range series {
+		for date, val := range buckets[mac] {
+			weight := float64(val.samples)
+			serie.Values = append(serie.Values, SensorPoint{
+				Date:        date,
+				Temperature: val.temperature / weight,
+				Humidity:    val.humidity / weight,
+				Brightness:  val.brightness / weight,
+				Presence:    val.presence,
+				Occupancy:   val.occupancy / val.samples,
+			})
+		}
+		sort.Slice(serie.Values, func(i, j int) bool {
+			return serie.Values[i].Date < serie.Values[j].Date
+		})
+		res = append(res, *serie)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Mac < res[j].Mac
+	})
+	return res
+}
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+//SwitchReport energy reported by a switch compared to the energy of its drivers
+type SwitchReport struct {
+	Mac           string  `json:"mac"`
+	Label         string  `json:"label"`
+	SwitchEnergy  float64 `json:"switchEnergy"` //Wh
+	LedsEnergy    float64 `json:"ledsEnergy"`   //Wh
+	BlindsEnergy  float64 `json:"blindsEnergy"` //Wh
+	HvacsEnergy   float64 `json:"hvacsEnergy"`  //Wh
+	DriversEnergy float64 `json:"driversEnergy"`
+	Difference    float64 `json:"difference"`  //switch energy not measured by the drivers (Wh)
+	Deviation     float64 `json:"deviation"`   //difference in percentage of the switch energy
+	Discrepancy   bool    `json:"discrepancy"` //deviation above the threshold
+}
+
+//ConnectivityHistory switch going offline or back online
+type ConnectivityHistory struct {
+	Mac      string `json:"mac"`
+	Label    string `json:"label"`
+	Event    string `json:"event"`    //switchOffline or switchOnline
+	Date     string `json:"date"`     //event date (RFC3339 UTC)
+	LastSeen string `json:"lastSeen"` //last dump before going offline
+}
+
+//ToConnectivityHistory convert map interface to ConnectivityHistory object
+func ToConnectivityHistory(val interface{}) (*ConnectivityHistory, error) {
+	var evt ConnectivityHistory
+	inrec, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(inrec, &evt)
+	return &evt, err
+}
+
+//GetConnectivityHistory return the switchs offline and online events, the oldest first
+func GetConnectivityHistory(db HistoryDb, f Filter) []ConnectivityHistory {
+	res := []ConnectivityHistory{}
+	f.SwitchMac = ""
+	f.Group = nil
+	for _, st := range getRecords(db, ConnectivityTable, f) {
+		elt, err := ToConnectivityHistory(st)
+		if err != nil || elt == nil {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, elt.Date)
+		if err != nil || !f.inRange(date) {
+			continue
+		}
+		res = append(res, *elt)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Date < res[j].Date
+	})
+	return res
+}
+
+func sumEnergy(points []Point) float64 {
+	energy := 0.0
+	for _, p := range points {
+		energy += p.Energy
+	}
+	return energy
+}
+
+//energyBySwitch return the energy of a history table per switch over the filter range
+func energyBySwitch(db HistoryDb, tbName string, f Filter) map[string]float64 {
+	res := make(map[string]float64)
+	for _, src := range getSources(db, tbName, f.Resolution) {
+		for _, st := range getSourceRecords(db, src, f) {
+			elt, err := ToAggregateHistory(st)
+			if err != nil || elt == nil {
+				continue
+			}
+			if tbName == SwitchsTable {
+				res[elt.Mac] += elt.Energy
+			} else {
+				res[elt.SwitchMac] += elt.Energy
+			}
+		}
+	}
+	return res
+}
+
+//GetSwitchsReport compare the energy reported by each switch (mac -> label) with the sum of its drivers energy,
+//each history table is read once for all the switchs
+func GetSwitchsReport(db HistoryDb, switchs map[string]string, from, to *time.Time, threshold float64) []SwitchReport {
+	res := []SwitchReport{}
+	f := Filter{
+		From:       from,
+		To:         to,
+		Resolution: ResolutionDay,
+	}
+	f.Bound(time.Now().UTC())
+	switchsEnergy := energyBySwitch(db, SwitchsTable, f)
+	ledsEnergy := energyBySwitch(db, LedsTable, f)
+	blindsEnergy := energyBySwitch(db, BlindsTable, f)
+	hvacsEnergy := energyBySwitch(db, HvacsTable, f)
+
+	for mac, label := range switchs {
+		report := SwitchReport{
+			Mac:          mac,
+			Label:        label,
+			SwitchEnergy: switchsEnergy[mac],
+			LedsEnergy:   ledsEnergy[mac],
+			BlindsEnergy: blindsEnergy[mac],
+			HvacsEnergy:  hvacsEnergy[mac],
+		}
+		report.DriversEnergy = report.LedsEnergy + report.BlindsEnergy + report.HvacsEnergy
+		report.Difference = report.SwitchEnergy - report.DriversEnergy
+		if report.SwitchEnergy > 0 {
+			report.Deviation = report.Difference * 100 / report.SwitchEnergy
+		}
+		report.Discrepancy = math.Abs(report.Deviation) > threshold
+		res = append(res, report)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Mac < res[j].Mac
+	})
+	return res
+}
+
+//GetSwitchsEnergy return the energy reported by all the switchs over a period
+func GetSwitchsEnergy(db HistoryDb, from, to time.Time) float64 {
+	f := Filter{
+		From:       &from,
+		To:         &to,
+		Resolution: ResolutionHour,
+	}
+	return sumEnergy(GetHistory(db, SwitchsTable, f))
+}
@@ -20,6 +20,7 @@ type AuthNetwork struct {
 	Iface     genericNetwork.NetworkInterface
 	Events    chan map[string]duser.UserAccess
 	EventDump chan map[string]duser.UserAccess
+	State     *BrokerState
 }
 
 //CreateAuthNetwork create network server object
@@ -32,6 +33,7 @@ func CreateAuthNetwork() (*AuthNetwork, error) {
 		Iface:     serverBroker,
 		Events:    make(chan map[string]duser.UserAccess),
 		EventDump: make(chan map[string]duser.UserAccess),
+		State:     newBrokerState(),
 	}
 	return &serverNet, nil
 }
@@ -57,6 +59,7 @@ func (net AuthNetwork) LocalConnection(conf pkg.ServiceConfig) error {
 	for {
 		rlog.Info("Try to connect to " + conf.AuthBroker.IP)
 		err := net.Iface.Initialize(confServer)
+		net.State.setConnected(err == nil, err)
 		if err == nil {
 			rlog.Info("Connected to server broker " + conf.AuthBroker.IP)
 			return err
@@ -73,6 +76,7 @@ func (net AuthNetwork) LocalConnection(conf pkg.ServiceConfig) error {
 }
 
 func (net AuthNetwork) onNewUser(client genericNetwork.Client, msg genericNetwork.Message) {
+	net.State.received()
 	payload := msg.Payload()
 	rlog.Info(msg.Topic() + " : " + string(payload))
 	var user duser.UserAccess
@@ -88,6 +92,7 @@ func (net AuthNetwork) onNewUser(client genericNetwork.Client, msg genericNetwor
 }
 
 func (net AuthNetwork) onDumpUser(client genericNetwork.Client, msg genericNetwork.Message) {
+	net.State.received()
 	payload := msg.Payload()
 	rlog.Info(msg.Topic() + " : " + string(payload))
 	var users map[string]duser.UserAccess
@@ -100,6 +105,7 @@ func (net AuthNetwork) onDumpUser(client genericNetwork.Client, msg genericNetwo
 }
 
 func (net AuthNetwork) onRemoveUser(client genericNetwork.Client, msg genericNetwork.Message) {
+	net.State.received()
 	payload := msg.Payload()
 	rlog.Info(msg.Topic() + " : " + string(payload))
 	var user duser.UserAccess
@@ -122,6 +128,7 @@ func (net AuthNetwork) Disconnect() {
 //SendCommand to server
 func (net AuthNetwork) SendCommand(topic, content string) error {
 	err := net.Iface.SendCommand(topic, content)
+	net.State.setConnected(err == nil, err)
 	if err != nil {
 		rlog.Error("Cannot send : " + content + " on: " + topic + " Error: " + err.Error())
 	} else {
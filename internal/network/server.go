@@ -21,6 +21,7 @@ const (
 type ServerNetwork struct {
 	Iface  genericNetwork.NetworkInterface
 	Events chan map[string]sd.SwitchStatus
+	State  *BrokerState
 }
 
 //CreateServerNetwork create network server object
@@ -32,6 +33,7 @@ func CreateServerNetwork() (*ServerNetwork, error) {
 	serverNet := ServerNetwork{
 		Iface:  serverBroker,
 		Events: make(chan map[string]sd.SwitchStatus),
+		State:  newBrokerState(),
 	}
 	return &serverNet, nil
 
@@ -57,6 +59,7 @@ func (net ServerNetwork) LocalConnection(conf pkg.ServiceConfig) error {
 	for {
 		rlog.Info("Try to connect to " + conf.NetworkBroker.IP)
 		err := net.Iface.Initialize(confServer)
+		net.State.setConnected(err == nil, err)
 		if err == nil {
 			rlog.Info("Connected to server broker " + conf.NetworkBroker.IP)
 			return err
@@ -73,6 +76,7 @@ func (net ServerNetwork) LocalConnection(conf pkg.ServiceConfig) error {
 }
 
 func (net ServerNetwork) onHello(client genericNetwork.Client, msg genericNetwork.Message) {
+	net.State.received()
 	payload := msg.Payload()
 	rlog.Info(msg.Topic() + " : " + string(payload))
 	var switchStatus sd.SwitchStatus
@@ -88,6 +92,7 @@ func (net ServerNetwork) onHello(client genericNetwork.Client, msg genericNetwor
 }
 
 func (net ServerNetwork) onDump(client genericNetwork.Client, msg genericNetwork.Message) {
+	net.State.received()
 	payload := msg.Payload()
 	rlog.Debug(msg.Topic() + " : " + string(payload))
 	var switchStatus sd.SwitchStatus
@@ -110,6 +115,7 @@ func (net ServerNetwork) Disconnect() {
 //SendCommand to server
 func (net ServerNetwork) SendCommand(topic, content string) error {
 	err := net.Iface.SendCommand(topic, content)
+	net.State.setConnected(err == nil, err)
 	if err != nil {
 		rlog.Error("Cannot send : " + content + " on: " + topic + " Error: " + err.Error())
 	} else {
@@ -0,0 +1,70 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+//BrokerState connection state shared by the copies of a network object
+type BrokerState struct {
+	mutex       sync.Mutex
+	connected   bool
+	attempts    int
+	since       time.Time
+	lastMessage time.Time
+	err         string
+}
+
+func newBrokerState() *BrokerState {
+	return &BrokerState{
+		since: time.Now(),
+	}
+}
+
+func (b *BrokerState) setConnected(connected bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.update(connected, err)
+}
+
+func (b *BrokerState) update(connected bool, err error) {
+	if connected != b.connected {
+		b.since = time.Now()
+	}
+	b.connected = connected
+	if connected {
+		b.attempts = 0
+		b.err = ""
+		return
+	}
+	b.attempts++
+	if err != nil {
+		b.err = err.Error()
+	}
+}
+
+//received a message comes from the broker, so the connection is up
+func (b *BrokerState) received() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.lastMessage = time.Now()
+	b.update(true, nil)
+}
+
+//Health return the broker connection state
+func (b *BrokerState) Health() core.BrokerHealth {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	health := core.BrokerHealth{
+		Connected: b.connected,
+		Attempts:  b.attempts,
+		Since:     b.since.UTC().Format(time.RFC3339),
+		Error:     b.err,
+	}
+	if !b.lastMessage.IsZero() {
+		health.LastMessage = b.lastMessage.UTC().Format(time.RFC3339)
+	}
+	return health
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dhvac"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+func labelValue(label *string) string {
+	if label == nil {
+		return ""
+	}
+	return *label
+}
+
+//pushAlarm send an alarm change to the API websocket and to the webhooks
+func (s *CoreService) pushAlarm(alarm core.Alarm) {
+	s.notifyWebhooks(core.WebhookEventAlarm, alarm)
+	select {
+	case s.eventsAlarmAPI <- alarm:
+	default:
+		rlog.Debug("Alarm event Dropped", alarm)
+	}
+}
+
+//saveAlarm store the alarm and keep it in the open alarms until it is closed
+func (s *CoreService) saveAlarm(alarm core.Alarm) {
+	id, err := database.SaveAlarm(s.db, alarm)
+	if err != nil {
+		rlog.Error("Cannot save alarm " + alarm.Key() + " " + err.Error())
+		return
+	}
+	alarm.ID = id
+	if alarm.Closed() {
+		delete(s.alarms, alarm.Key())
+	} else {
+		s.alarms[alarm.Key()] = alarm
+	}
+	s.pushAlarm(alarm)
+}
+
+//openAlarm return the stored state of an open alarm, it may have been acknowledged meanwhile
+func (s *CoreService) openAlarm(key string) *core.Alarm {
+	cached, ok := s.alarms[key]
+	if !ok {
+		return nil
+	}
+	alarm := database.GetAlarm(s.db, cached.ID)
+	if alarm == nil || alarm.Closed() {
+		delete(s.alarms, key)
+		return nil
+	}
+	return alarm
+}
+
+//raiseAlarm raise an alarm, an alarm cleared but not yet acknowledged is raised again
+func (s *CoreService) raiseAlarm(alarm core.Alarm) {
+	s.alarmMutex.Lock()
+	defer s.alarmMutex.Unlock()
+	cached, ok := s.alarms[alarm.Key()]
+	if ok && cached.Active && cached.Message == alarm.Message {
+		return
+	}
+	stored := s.openAlarm(alarm.Key())
+	now := time.Now().UTC().Format(time.RFC3339)
+	if stored == nil {
+		alarm.Active = true
+		alarm.Raised = now
+		alarm.Occurrences = 1
+		rlog.Warn("Alarm " + alarm.Key() + " raised: " + alarm.Message)
+		s.saveAlarm(alarm)
+		go s.mailAlarm(alarm)
+		return
+	}
+	raised := !stored.Active
+	if raised {
+		stored.Occurrences++
+		rlog.Warn("Alarm " + alarm.Key() + " raised again: " + alarm.Message)
+	}
+	stored.Active = true
+	stored.Cleared = ""
+	stored.Message = alarm.Message
+	stored.Severity = alarm.Severity
+	stored.Label = alarm.Label
+	stored.Group = alarm.Group
+	s.saveAlarm(*stored)
+	if raised {
+		go s.mailAlarm(*stored)
+	}
+}
+
+//clearAlarm clear an active alarm
+func (s *CoreService) clearAlarm(alarmType, mac string) {
+	s.alarmMutex.Lock()
+	defer s.alarmMutex.Unlock()
+	key := core.AlarmKey(alarmType, mac)
+	cached, ok := s.alarms[key]
+	if !ok || !cached.Active {
+		return
+	}
+	stored := s.openAlarm(key)
+	if stored == nil {
+		return
+	}
+	stored.Active = false
+	stored.Cleared = time.Now().UTC().Format(time.RFC3339)
+	rlog.Info("Alarm " + key + " cleared")
+	s.saveAlarm(*stored)
+}
+
+//checkDriverError raise or clear the driver error alarm
+func (s *CoreService) checkDriverError(mac string, label *string, group int, errorCode int) {
+	if errorCode == 0 {
+		s.clearAlarm(core.AlarmDriverError, mac)
+		return
+	}
+	s.raiseAlarm(core.Alarm{
+		Type:     core.AlarmDriverError,
+		Severity: core.AlarmSeverityMinor,
+		Mac:      mac,
+		Label:    labelValue(label),
+		Group:    group,
+		Message:  "Error " + strconv.Itoa(errorCode),
+	})
+}
+
+//raiseDriverLost raise the alarm of a driver missing from its switch dump
+func (s *CoreService) raiseDriverLost(mac string, label *string, group int, switchMac string) {
+	s.raiseAlarm(core.Alarm{
+		Type:     core.AlarmDriverLost,
+		Severity: core.AlarmSeverityMajor,
+		Mac:      mac,
+		Label:    labelValue(label),
+		Group:    group,
+		Message:  "Not reported by switch " + switchMac,
+	})
+}
+
+//checkHvacError raise or clear the hvac error alarm
+func (s *CoreService) checkHvacError(hvac dhvac.Hvac) {
+	if hvac.Error == 0 && hvac.ErrorCode == 0 {
+		s.clearAlarm(core.AlarmHvacError, hvac.Mac)
+		return
+	}
+	s.raiseAlarm(core.Alarm{
+		Type:     core.AlarmHvacError,
+		Severity: core.AlarmSeverityMajor,
+		Mac:      hvac.Mac,
+		Label:    labelValue(hvac.Label),
+		Group:    hvac.Group,
+		Message:  "Error " + strconv.Itoa(hvac.Error) + " code " + strconv.Itoa(hvac.ErrorCode),
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/energieip/common-components-go/pkg/dblind"
@@ -139,51 +140,49 @@ func (s *CoreService) updateBlindCfg(config interface{}) {
 	}
 }
 
-func (s *CoreService) sendBlindCmd(cmdBlind interface{}) {
-	cmd, _ := dserver.ToBlindCmd(cmdBlind)
-	if cmd == nil {
-		rlog.Error("Cannot parse cmd")
-		return
-	}
+//addBlindCmd add a blind command to the settings message of its switch and return the switch mac
+func (s *CoreService) addBlindCmd(setups map[string]*sd.SwitchConfig, cmd dserver.BlindCmd) (string, error) {
 	//Get correspnding switchMac
 	driver, _ := database.GetBlindConfig(s.db, cmd.Mac)
 	if driver == nil {
-		rlog.Error("Cannot find config for " + cmd.Mac)
-		return
+		return "", errors.New("Cannot find config for " + cmd.Mac)
 	}
 	if driver.SwitchMac == "" {
-		rlog.Error("No corresponding switch present for " + cmd.Mac)
-		return
+		return "", errors.New("No corresponding switch present for " + cmd.Mac)
 	}
-	sw, _ := database.GetSwitchConfig(s.db, driver.SwitchMac)
-	if sw != nil {
-		ip := "0"
-		if sw.IP != nil {
-			ip = *sw.IP
-		}
-		dumpFreq := 1000
-		if sw.DumpFrequency != nil {
-			dumpFreq = *sw.DumpFrequency
-		}
-		url := "/write/switch/" + driver.SwitchMac + "/update/settings"
-		switchSetup := sd.SwitchConfig{}
-		switchSetup.Mac = driver.SwitchMac
-		switchSetup.IP = ip
-		switchSetup.DumpFrequency = dumpFreq
+	switchSetup, err := s.switchCommand(setups, driver.SwitchMac)
+	if err != nil {
+		return "", err
+	}
+	if switchSetup.BlindsConfig == nil {
 		switchSetup.BlindsConfig = make(map[string]dblind.BlindConf)
+	}
 
-		cfg := dblind.BlindConf{
-			Mac:    cmd.Mac,
-			Blind1: cmd.Blind1,
-			Blind2: cmd.Blind2,
-			Slat1:  cmd.Slat1,
-			Slat2:  cmd.Slat2,
-		}
-		switchSetup.BlindsConfig[cmd.Mac] = cfg
+	cfg := dblind.BlindConf{
+		Mac:    cmd.Mac,
+		Blind1: cmd.Blind1,
+		Blind2: cmd.Blind2,
+		Slat1:  cmd.Slat1,
+		Slat2:  cmd.Slat2,
+	}
+	switchSetup.BlindsConfig[cmd.Mac] = cfg
+	return driver.SwitchMac, nil
+}
 
-		dump, _ := switchSetup.ToJSON()
-		s.server.SendCommand(url, dump)
+func (s *CoreService) sendBlindCmd(cmdBlind interface{}) error {
+	cmd, _ := dserver.ToBlindCmd(cmdBlind)
+	if cmd == nil {
+		err := errors.New("Cannot parse cmd")
+		rlog.Error(err.Error())
+		return err
+	}
+	setups := make(map[string]*sd.SwitchConfig)
+	switchMac, err := s.addBlindCmd(setups, *cmd)
+	if err != nil {
+		rlog.Error(err.Error())
+		return err
 	}
+	return s.sendSwitchCommand(*setups[switchMac])
 }
 
 func (s *CoreService) updateBlindSetup(config interface{}) {
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"time"
+
+	sd "github.com/energieip/common-components-go/pkg/dswitch"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+const (
+	commandAckPeriod    = 5 * time.Second //timeout check period
+	commandAckRetention = 24 * time.Hour
+)
+
+//resolveCommandAck save the final status of a command still pending, the caller holds commandMutex
+//so that a dump and the timeout check cannot both resolve it
+func (s *CoreService) resolveCommandAck(ack core.CommandAck, status, msg string) {
+	current := database.GetCommandAck(s.db, ack.ID)
+	if current != nil {
+		if !current.IsPending() {
+			return
+		}
+		ack = *current
+	}
+	ack.Status = status
+	ack.Error = msg
+	ack.Deadline = ""
+	ack.Updated = time.Now().UTC().Format(time.RFC3339)
+	_, err := database.SaveCommandAck(s.db, ack)
+	if err != nil {
+		rlog.Error("Cannot save command " + ack.ID + " acknowledgement " + err.Error())
+	}
+	rlog.Info("Command " + ack.ID + " on " + ack.Point + " " + status + " " + msg)
+}
+
+//trackCommand wait for the switch dump to report a command once the priority array is resolved
+func (s *CoreService) trackCommand(cmd core.PriorityCommand, array core.PriorityArray, err error) {
+	if cmd.Ack == "" {
+		return
+	}
+	s.commandMutex.Lock()
+	defer s.commandMutex.Unlock()
+	ack := database.GetCommandAck(s.db, cmd.Ack)
+	if ack == nil {
+		rlog.Error("Unknown command acknowledgement " + cmd.Ack)
+		return
+	}
+	if err != nil {
+		s.resolveCommandAck(*ack, core.CommandFailed, err.Error())
+		return
+	}
+	if array.Applied == nil || core.PriorityRank(array.Controller) < core.PriorityRank(cmd.Level) {
+		s.resolveCommandAck(*ack, core.CommandFailed, "Overridden by "+array.Controller+" command from "+array.Source)
+		return
+	}
+
+	now := time.Now().UTC()
+	expected := *array.Applied
+	ack.Expected = &expected
+	ack.Deadline = now.Add(core.CommandAckTimeout).Format(time.RFC3339)
+	ack.Updated = now.Format(time.RFC3339)
+	_, err = database.SaveCommandAck(s.db, *ack)
+	if err != nil {
+		rlog.Error("Cannot save command " + ack.ID + " acknowledgement " + err.Error())
+	}
+
+	previous, ok := s.commandAcks[ack.Point]
+	s.commandAcks[ack.Point] = *ack
+	if ok && previous.ID != ack.ID {
+		s.resolveCommandAck(previous, core.CommandFailed, "Superseded by "+ack.ID)
+	}
+}
+
+//acknowledgeCommands resolve the pending commands reported by a switch dump
+func (s *CoreService) acknowledgeCommands(switchStatus sd.SwitchStatus) {
+	s.commandMutex.Lock()
+	defer s.commandMutex.Unlock()
+	for point, ack := range s.commandAcks {
+		match := false
+		switch {
+		case ack.Led != nil:
+			led, ok := switchStatus.Leds[ack.Led.Mac]
+			match = ok && ack.MatchLed(led)
+		case ack.Blind != nil:
+			blind, ok := switchStatus.Blinds[ack.Blind.Mac]
+			match = ok && ack.MatchBlind(blind)
+		case ack.Hvac != nil:
+			hvac, ok := switchStatus.Hvacs[ack.Hvac.Mac]
+			match = ok && ack.MatchHvac(hvac)
+		}
+		if match {
+			delete(s.commandAcks, point)
+			s.resolveCommandAck(ack, core.CommandApplied, "")
+		}
+	}
+}
+
+//expireCommands set the commands not reported before their deadline in timeout
+func (s *CoreService) expireCommands(now time.Time) {
+	for _, ack := range database.GetPendingCommandAcks(s.db) {
+		deadline, err := time.Parse(time.RFC3339, ack.Deadline)
+		if err == nil && now.Before(deadline) {
+			continue
+		}
+		s.commandMutex.Lock()
+		tracked, ok := s.commandAcks[ack.Point]
+		if ok && tracked.ID == ack.ID {
+			delete(s.commandAcks, ack.Point)
+		}
+		//a dump may have reported it in the meantime
+		s.resolveCommandAck(ack, core.CommandTimeout, "Not reported by the switch")
+		s.commandMutex.Unlock()
+	}
+}
+
+//runCommandAcks check the command timeouts and purge the old acknowledgements
+func (s *CoreService) runCommandAcks() {
+	ticker := time.NewTicker(commandAckPeriod)
+	purge := time.NewTicker(time.Hour)
+	for {
+		select {
+		case <-ticker.C:
+			s.expireCommands(time.Now().UTC())
+		case <-purge.C:
+			before := time.Now().UTC().Add(-commandAckRetention)
+			database.RemoveCommandAcks(s.db, before.Format(time.RFC3339))
+		}
+	}
+}
@@ -1,18 +1,109 @@
 package service
 
 import (
+	"sort"
 	"time"
 
 	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
 	cmap "github.com/orcaman/concurrent-map"
 
 	"github.com/romana/rlog"
 )
 
-func (s *CoreService) prepareAPIConsumption(evtObj string, power int) {
+func (s *CoreService) prepareAPIConsumption(evtObj string, group, cluster, power int) {
 	old, _ := s.bufConsumption.Get(evtObj)
 	new := old.(int) + power
 	s.bufConsumption.Set(evtObj, new)
+
+	s.consoMutex.Lock()
+	defer s.consoMutex.Unlock()
+	gr, ok := s.bufGroupConso[group]
+	if !ok {
+		gr = &core.GroupConsumption{
+			Group: group,
+		}
+		s.bufGroupConso[group] = gr
+	}
+	zone, ok := s.bufZoneConso[cluster]
+	if !ok {
+		zone = &core.ZoneConsumption{
+			Cluster: cluster,
+		}
+		s.bufZoneConso[cluster] = zone
+	}
+	switch evtObj {
+	case LedElt:
+		gr.Leds += power
+		zone.Leds += power
+	case BlindElt:
+		gr.Blinds += power
+		zone.Blinds += power
+	case HvacElt:
+		gr.Hvac += power
+		zone.Hvac += power
+	}
+}
+
+//loadClusterFrames read the frames of each cluster, kept until the frames configuration changes
+func (s *CoreService) loadClusterFrames() map[int][]string {
+	clusterFrames := make(map[int][]string)
+	for label, frame := range database.GetFramesConfigByLabel(s.db) {
+		clusterFrames[frame.Cluster] = append(clusterFrames[frame.Cluster], label)
+	}
+	for _, labels := range clusterFrames {
+		sort.Strings(labels)
+	}
+	s.consoMutex.Lock()
+	s.clusterFrames = clusterFrames
+	s.consoMutex.Unlock()
+	return clusterFrames
+}
+
+//resetClusterFrames reload the frames configuration on the next consumption event
+func (s *CoreService) resetClusterFrames() {
+	s.consoMutex.Lock()
+	s.clusterFrames = nil
+	s.consoMutex.Unlock()
+}
+
+//popDetailedConsumption return the consumption per group and per zone and reset the buffers
+func (s *CoreService) popDetailedConsumption() ([]core.GroupConsumption, []core.ZoneConsumption) {
+	s.consoMutex.Lock()
+	groupConso := s.bufGroupConso
+	zoneConso := s.bufZoneConso
+	clusterFrames := s.clusterFrames
+	s.bufGroupConso = make(map[int]*core.GroupConsumption)
+	s.bufZoneConso = make(map[int]*core.ZoneConsumption)
+	s.consoMutex.Unlock()
+
+	groups := []core.GroupConsumption{}
+	for _, gr := range groupConso {
+		groups = append(groups, *gr)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Group < groups[j].Group
+	})
+
+	zones := []core.ZoneConsumption{}
+	if len(zoneConso) == 0 {
+		return groups, zones
+	}
+	if clusterFrames == nil {
+		clusterFrames = s.loadClusterFrames()
+	}
+	for cluster, zone := range zoneConso {
+		elt := *zone
+		elt.Frames = clusterFrames[cluster]
+		if elt.Frames == nil {
+			elt.Frames = []string{}
+		}
+		zones = append(zones, elt)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].Cluster < zones[j].Cluster
+	})
+	return groups, zones
 }
 
 func (s *CoreService) pushConsumptionEvent() {
@@ -31,6 +122,7 @@ func (s *CoreService) pushConsumptionEvent() {
 			hvac, _ := s.bufConsumption.Get(HvacElt)
 			conso.Hvac = hvac.(int)
 			conso.Date = time.Now().Format(time.RFC3339)
+			conso.Groups, conso.Zones = s.popDetailedConsumption()
 			select {
 			case s.eventsConsumptionAPI <- conso:
 				rlog.Debug("Consumption API event Sent", s.bufConsumption)
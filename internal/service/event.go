@@ -20,6 +20,9 @@ const (
 	EventUpdate = "update"
 	EventAdd    = "add"
 
+	EventSwitchOffline = "switchOffline"
+	EventSwitchOnline  = "switchOnline"
+
 	LedElt    = "led"
 	SensorElt = "sensor"
 	BlindElt  = "blind"
@@ -269,6 +272,9 @@ func (s *CoreService) prepareAPIEvent(evtType, evtObj string, event interface{})
 		bufAPI.Set(evtType, val)
 	}
 	if len(bufAPI) != 0 {
+		for evtType, evt := range bufAPI.Items() {
+			s.notifyWebhooks(evtType, evt)
+		}
 		select {
 		case s.eventsAPI <- bufAPI.Items():
 			rlog.Debug("API event Sent", bufAPI)
@@ -277,3 +283,44 @@ func (s *CoreService) prepareAPIEvent(evtType, evtObj string, event interface{})
 		}
 	}
 }
+
+//prepareSwitchEvent push a switch connectivity change with the last status of its drivers
+func (s *CoreService) prepareSwitchEvent(evtType string, sw dswitch.SwitchStatus) {
+	label := func(mac string) string {
+		project := database.GetProjectByMac(s.db, mac)
+		if project != nil {
+			return project.Label
+		}
+		return ""
+	}
+	evt := core.EventStatus{
+		Leds:    []core.EventLed{},
+		Sensors: []core.EventSensor{},
+		Groups:  []gm.GroupStatus{},
+		Blinds:  []core.EventBlind{},
+		Hvacs:   []core.EventHvac{},
+		Wagos:   []core.EventWago{},
+		Nanos:   []core.EventNano{},
+		Switchs: []core.EventSwitch{{Switch: sw, Label: label(sw.Mac)}},
+	}
+	for _, led := range database.GetLedSwitchStatus(s.db, sw.Mac) {
+		evt.Leds = append(evt.Leds, core.EventLed{Led: led, Label: label(led.Mac)})
+	}
+	for _, sensor := range database.GetSensorSwitchStatus(s.db, sw.Mac) {
+		evt.Sensors = append(evt.Sensors, core.EventSensor{Sensor: sensor, Label: label(sensor.Mac)})
+	}
+	for _, blind := range database.GetBlindSwitchStatus(s.db, sw.Mac) {
+		evt.Blinds = append(evt.Blinds, core.EventBlind{Blind: blind, Label: label(blind.Mac)})
+	}
+	for _, hvac := range database.GetHvacSwitchStatus(s.db, sw.Mac) {
+		evt.Hvacs = append(evt.Hvacs, core.EventHvac{Hvac: hvac, Label: label(hvac.Mac)})
+	}
+	s.notifyWebhooks(evtType, evt)
+	event := map[string]interface{}{evtType: evt}
+	select {
+	case s.eventsAPI <- event:
+		rlog.Debug("API event Sent", event)
+	default:
+		rlog.Debug("API event Dropped", event)
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+)
+
+const (
+	loopMQTT = "mqtt" //brokers events
+	loopAPI  = "api"  //API events to backend
+)
+
+//health return the brokers connection state and the service loops lag
+func (s *CoreService) health() core.Health {
+	health := core.Health{
+		Started:    s.started.UTC().Format(time.RFC3339),
+		Server:     s.server.State.Health(),
+		AuthServer: s.authServer.State.Health(),
+		Loops:      make(map[string]core.LoopHealth),
+	}
+	for name, probe := range s.loops {
+		health.Loops[name] = probe.Health()
+	}
+	return health
+}
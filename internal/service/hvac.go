@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/energieip/common-components-go/pkg/dhvac"
@@ -136,46 +137,46 @@ func (s *CoreService) updateHvacCfg(config interface{}) {
 	}
 }
 
-func (s *CoreService) sendHvacCmd(cmdHvac interface{}) {
-	cmd, _ := dserver.ToHvacCmd(cmdHvac)
-	if cmd == nil {
-		rlog.Error("Cannot parse cmd")
-		return
-	}
+//addHvacCmd add a hvac command to the settings message of its switch and return the switch mac
+func (s *CoreService) addHvacCmd(setups map[string]*sd.SwitchConfig, cmd dserver.HvacCmd) (string, error) {
 	//Get correspnding switchMac
 	driver, _ := database.GetHvacConfig(s.db, cmd.Mac)
 	if driver == nil {
-		rlog.Error("Cannot find config for " + cmd.Mac)
-		return
+		return "", errors.New("Cannot find config for " + cmd.Mac)
 	}
 	if driver.SwitchMac == "" {
-		rlog.Error("No corresponding switch found for " + cmd.Mac)
-		return
+		return "", errors.New("No corresponding switch found for " + cmd.Mac)
 	}
-	sw, _ := database.GetSwitchConfig(s.db, driver.SwitchMac)
-	if sw != nil {
-		ip := "0"
-		if sw.IP != nil {
-			ip = *sw.IP
-		}
-		dumpFreq := 1000
-		if sw.DumpFrequency != nil {
-			dumpFreq = *sw.DumpFrequency
-		}
-		url := "/write/switch/" + driver.SwitchMac + "/update/settings"
-		switchSetup := sd.SwitchConfig{}
-		switchSetup.Mac = driver.SwitchMac
-		switchSetup.IP = ip
-		switchSetup.DumpFrequency = dumpFreq
+	switchSetup, err := s.switchCommand(setups, driver.SwitchMac)
+	if err != nil {
+		return "", err
+	}
+	if switchSetup.HvacsConfig == nil {
 		switchSetup.HvacsConfig = make(map[string]dhvac.HvacConf)
-		cfg := dhvac.HvacConf{
-			Mac:   cmd.Mac,
-			Shift: &cmd.ShiftTemp,
-		}
-		switchSetup.HvacsConfig[cmd.Mac] = cfg
-		dump, _ := switchSetup.ToJSON()
-		s.server.SendCommand(url, dump)
 	}
+	shift := cmd.ShiftTemp
+	cfg := dhvac.HvacConf{
+		Mac:   cmd.Mac,
+		Shift: &shift,
+	}
+	switchSetup.HvacsConfig[cmd.Mac] = cfg
+	return driver.SwitchMac, nil
+}
+
+func (s *CoreService) sendHvacCmd(cmdHvac interface{}) error {
+	cmd, _ := dserver.ToHvacCmd(cmdHvac)
+	if cmd == nil {
+		err := errors.New("Cannot parse cmd")
+		rlog.Error(err.Error())
+		return err
+	}
+	setups := make(map[string]*sd.SwitchConfig)
+	switchMac, err := s.addHvacCmd(setups, *cmd)
+	if err != nil {
+		rlog.Error(err.Error())
+		return err
+	}
+	return s.sendSwitchCommand(*setups[switchMac])
 }
 
 func (s *CoreService) createHvacLabelSetup(config interface{}) {
@@ -64,6 +64,7 @@ func (s *CoreService) installDriver(dr interface{}) {
 
 	//update project
 	database.SaveProject(s.db, *proj)
+	s.notifyWebhooks(core.WebhookEventCommissioning, *driver)
 
 	dType := driver.Device
 	switch dType {
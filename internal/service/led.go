@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"strings"
 
 	gm "github.com/energieip/common-components-go/pkg/dgroup"
@@ -333,50 +334,48 @@ func (s *CoreService) updateLedLabelSetup(config interface{}) {
 	s.sendSwitchLedSetup(*cfg)
 }
 
-func (s *CoreService) sendLedCmd(cmd interface{}) {
-	cmdLed, _ := dserver.ToLedCmd(cmd)
-	if cmdLed == nil {
-		rlog.Error("Cannot parse cmd")
-		return
-	}
+//addLedCmd add a led command to the settings message of its switch and return the switch mac
+func (s *CoreService) addLedCmd(setups map[string]*sd.SwitchConfig, cmdLed dserver.LedCmd) (string, error) {
 	//Get correspnding switchMac
 	led, _ := database.GetLedConfig(s.db, cmdLed.Mac)
 	if led == nil {
-		rlog.Error("Cannot find config for " + cmdLed.Mac)
-		return
+		return "", errors.New("Cannot find config for " + cmdLed.Mac)
 	}
 	if led.SwitchMac == "" {
-		rlog.Error("Corresponding switch not found " + cmdLed.Mac)
-		return
+		return "", errors.New("Corresponding switch not found " + cmdLed.Mac)
 	}
-	sw, _ := database.GetSwitchConfig(s.db, led.SwitchMac)
-	if sw != nil {
-		ip := "0"
-		if sw.IP != nil {
-			ip = *sw.IP
-		}
-		dumpFreq := 1000
-		if sw.DumpFrequency != nil {
-			dumpFreq = *sw.DumpFrequency
-		}
-		url := "/write/switch/" + led.SwitchMac + "/update/settings"
-		switchSetup := sd.SwitchConfig{}
-		switchSetup.Mac = led.SwitchMac
-		switchSetup.IP = ip
-		switchSetup.DumpFrequency = dumpFreq
+	switchSetup, err := s.switchCommand(setups, led.SwitchMac)
+	if err != nil {
+		return "", err
+	}
+	if switchSetup.LedsConfig == nil {
 		switchSetup.LedsConfig = make(map[string]dl.LedConf)
+	}
 
-		auto := cmdLed.Auto
-		setpoint := cmdLed.Setpoint
+	auto := cmdLed.Auto
+	setpoint := cmdLed.Setpoint
 
-		ledCfg := dl.LedConf{
-			Mac:            led.Mac,
-			Auto:           &auto,
-			SetpointManual: &setpoint,
-		}
-		switchSetup.LedsConfig[led.Mac] = ledCfg
+	ledCfg := dl.LedConf{
+		Mac:            led.Mac,
+		Auto:           &auto,
+		SetpointManual: &setpoint,
+	}
+	switchSetup.LedsConfig[led.Mac] = ledCfg
+	return led.SwitchMac, nil
+}
 
-		dump, _ := switchSetup.ToJSON()
-		s.server.SendCommand(url, dump)
+func (s *CoreService) sendLedCmd(cmd interface{}) error {
+	cmdLed, _ := dserver.ToLedCmd(cmd)
+	if cmdLed == nil {
+		err := errors.New("Cannot parse cmd")
+		rlog.Error(err.Error())
+		return err
+	}
+	setups := make(map[string]*sd.SwitchConfig)
+	switchMac, err := s.addLedCmd(setups, *cmdLed)
+	if err != nil {
+		rlog.Error(err.Error())
+		return err
 	}
+	return s.sendSwitchCommand(*setups[switchMac])
 }
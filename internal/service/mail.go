@@ -0,0 +1,60 @@
+package service
+
+import (
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/energieip/srv200-coreservice-go/internal/history"
+	"github.com/romana/rlog"
+)
+
+//mailAlarm send a raised alarm to the recipients of its severity
+func (s *CoreService) mailAlarm(alarm core.Alarm) {
+	recipients := s.mail.Recipients[alarm.Severity]
+	if !s.mail.IsEnabled() || len(recipients) == 0 {
+		return
+	}
+	subject, body, err := s.mail.Render(core.MailTemplateAlarm, alarm)
+	if err != nil {
+		rlog.Error("Cannot prepare alarm " + alarm.Key() + " email " + err.Error())
+		return
+	}
+	err = s.mail.Send(recipients, subject, body)
+	if err != nil {
+		rlog.Error("Cannot send alarm " + alarm.Key() + " email " + err.Error())
+		return
+	}
+	rlog.Info("Alarm " + alarm.Key() + " email sent")
+}
+
+//mailSummary send the summary of a period to the summary recipients
+func (s *CoreService) mailSummary(from, to time.Time) {
+	summary := core.NewMailSummary(from, to, history.GetSwitchsEnergy(s.historyDb, from, to),
+		database.GetOfflineSwitchs(s.db), database.GetProjects(s.db))
+	subject, body, err := s.mail.Render(core.MailTemplateSummary, summary)
+	if err != nil {
+		rlog.Error("Cannot prepare summary email " + err.Error())
+		return
+	}
+	err = s.mail.Send(s.mail.Summary, subject, body)
+	if err != nil {
+		rlog.Error("Cannot send summary email " + err.Error())
+		return
+	}
+	rlog.Info("Summary email sent")
+}
+
+//runMailSummary send the daily summary at the configured time
+func (s *CoreService) runMailSummary() {
+	if !s.mail.IsEnabled() || len(s.mail.Summary) == 0 {
+		return
+	}
+	loc := s.site.Location()
+	for {
+		now := time.Now()
+		next := s.mail.NextSummary(now, loc)
+		time.Sleep(next.Sub(now))
+		s.mailSummary(next.AddDate(0, 0, -1), next)
+	}
+}
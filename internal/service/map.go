@@ -24,6 +24,13 @@ func (s *CoreService) updateMapInfo(config interface{}) {
 
 	//clean old configuration except the project table (already associate qrcode)
 	database.PrepareDB(s.db, true)
+	//the commands and the offline switchs of the previous map are dropped with it
+	s.commandMutex.Lock()
+	s.commandAcks = make(map[string]core.CommandAck)
+	s.commandMutex.Unlock()
+	s.offlineMutex.Lock()
+	s.offlineSwitchs = make(map[string]core.OfflineSwitch)
+	s.offlineMutex.Unlock()
 
 	for _, proj := range cfg.Project {
 		proj.CommissioningDate = nil
@@ -49,6 +56,10 @@ func (s *CoreService) updateMapInfo(config interface{}) {
 		database.SaveModel(s.db, md)
 	}
 
+	for _, facade := range cfg.Facades {
+		database.SaveFacade(s.db, facade)
+	}
+
 	for _, sw := range cfg.Switchs {
 		if sw.Label != nil {
 			mac, ok := association[*sw.Label]
@@ -62,6 +73,7 @@ func (s *CoreService) updateMapInfo(config interface{}) {
 	for _, md := range cfg.Frames {
 		database.SaveFrame(s.db, md)
 	}
+	s.resetClusterFrames()
 
 	for _, gr := range cfg.Groups {
 		s.createGroup(&gr)
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	sd "github.com/energieip/common-components-go/pkg/dswitch"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+const (
+	//PriorityExpiryPeriod delay between two checks of the expired commands
+	PriorityExpiryPeriod = time.Minute
+)
+
+//applyPriority send the resulting command of a priority array, the group drivers controlled
+//by a higher level get their command again, priorityMutex must not be held
+func (s *CoreService) applyPriority(cmd core.PriorityCommand) error {
+	if cmd.Led != nil {
+		return s.sendLedCmd(*cmd.Led)
+	}
+	if cmd.Blind != nil {
+		return s.sendBlindCmd(*cmd.Blind)
+	}
+	if cmd.Hvac != nil {
+		return s.sendHvacCmd(*cmd.Hvac)
+	}
+	if cmd.Group != nil {
+		overrides := s.groupOverrides(cmd)
+		s.sendGroupCmd(*cmd.Group)
+		for _, override := range overrides {
+			s.applyPriority(override)
+		}
+	}
+	return nil
+}
+
+//groupOverrides return the commands of the group drivers controlled by a level higher than the group command
+func (s *CoreService) groupOverrides(cmd core.PriorityCommand) []core.PriorityCommand {
+	config, _ := database.GetGroupConfig(s.db, cmd.Group.Group)
+	if config == nil {
+		return []core.PriorityCommand{}
+	}
+	points := []string{}
+	for _, mac := range config.Leds {
+		points = append(points, core.PointName(core.PointLed, mac))
+	}
+	for _, mac := range config.Blinds {
+		points = append(points, core.PointName(core.PointBlind, mac))
+	}
+	for _, mac := range config.Hvacs {
+		points = append(points, core.PointName(core.PointHvac, mac))
+	}
+
+	arrays := []core.PriorityArray{}
+	s.priorityMutex.Lock()
+	for _, point := range points {
+		array, _ := database.GetPriorityArray(s.db, point)
+		if array != nil {
+			arrays = append(arrays, *array)
+		}
+	}
+	s.priorityMutex.Unlock()
+	return core.Overrides(cmd, arrays, time.Now().UTC())
+}
+
+//setPriorityCmd store a command in the priority array of its driver or group, return the resolved array
+//and false when the command is held: a lower level command changing nothing, priorityMutex is held by the caller
+func (s *CoreService) setPriorityCmd(cmd core.PriorityCommand) (core.PriorityArray, bool) {
+	now := time.Now().UTC()
+	cmd.Date = now.Format(time.RFC3339)
+	timeout := s.priority.Timeout(cmd.Level)
+	if cmd.Timeout != nil {
+		timeout = *cmd.Timeout
+	}
+	cmd.Expires = ""
+	if timeout > 0 && cmd.Level != core.PriorityDefault {
+		cmd.Expires = now.Add(time.Duration(timeout) * time.Minute).Format(time.RFC3339)
+	}
+
+	kind, id := cmd.Point()
+	point := core.PointName(kind, id)
+	array, _ := database.GetPriorityArray(s.db, point)
+	if array == nil {
+		array = &core.PriorityArray{
+			Point: point,
+			Kind:  kind,
+			ID:    id,
+		}
+	}
+	array.Expire(now)
+	array.Resolve()
+	previous := array.Applied
+	array.Set(cmd)
+	array.Resolve()
+	err := database.SavePriorityArray(s.db, *array)
+	if err != nil {
+		rlog.Error("Cannot save " + point + " priority array " + err.Error())
+	}
+	if core.PriorityRank(cmd.Level) > core.PriorityRank(array.Controller) &&
+		previous != nil && previous.SameCommand(*array.Applied) {
+		rlog.Info("Command " + cmd.Level + " on " + point + " held by " + array.Controller + " from " + array.Source)
+		return *array, false
+	}
+	return *array, true
+}
+
+//sendPriorityCmd store a command in the priority array of its driver or group
+//and send the resulting command, a lower level command changing nothing is held
+func (s *CoreService) sendPriorityCmd(val interface{}) {
+	cmd, _ := core.ToPriorityCommand(val)
+	if cmd == nil {
+		rlog.Error("Cannot parse cmd")
+		return
+	}
+	err := cmd.Validate()
+	if err != nil {
+		rlog.Error("Cannot parse cmd")
+		s.trackCommand(*cmd, core.PriorityArray{}, err)
+		return
+	}
+
+	s.priorityMutex.Lock()
+	array, apply := s.setPriorityCmd(*cmd)
+	s.priorityMutex.Unlock()
+	if !apply {
+		s.trackCommand(*cmd, array, nil)
+		return
+	}
+	err = s.applyPriority(*array.Applied)
+	s.trackCommand(*cmd, array, err)
+}
+
+//addPriorityCmd add the resulting driver command of a priority array to the settings message of its switch
+func (s *CoreService) addPriorityCmd(setups map[string]*sd.SwitchConfig, cmd core.PriorityCommand) (string, error) {
+	switch {
+	case cmd.Led != nil:
+		return s.addLedCmd(setups, *cmd.Led)
+	case cmd.Blind != nil:
+		return s.addBlindCmd(setups, *cmd.Blind)
+	case cmd.Hvac != nil:
+		return s.addHvacCmd(setups, *cmd.Hvac)
+	}
+	return "", errors.New("No driver command")
+}
+
+//sendBulkCmd store driver commands in their priority arrays and send the resulting commands
+//gathered in a single settings message per switch
+func (s *CoreService) sendBulkCmd(val interface{}) {
+	cmds, err := core.ToPriorityCommands(val)
+	if err != nil {
+		rlog.Error("Cannot parse cmd")
+		return
+	}
+	type switchCmd struct {
+		cmd       core.PriorityCommand
+		array     core.PriorityArray
+		apply     bool
+		switchMac string
+	}
+
+	valid := []core.PriorityCommand{}
+	for _, cmd := range cmds {
+		err := cmd.Validate()
+		if err == nil && cmd.Group != nil {
+			err = errors.New("Group commands are not supported")
+		}
+		if err != nil {
+			s.trackCommand(cmd, core.PriorityArray{}, err)
+			continue
+		}
+		valid = append(valid, cmd)
+	}
+
+	resolved := []switchCmd{}
+	s.priorityMutex.Lock()
+	for _, cmd := range valid {
+		array, apply := s.setPriorityCmd(cmd)
+		resolved = append(resolved, switchCmd{cmd: cmd, array: array, apply: apply})
+	}
+	s.priorityMutex.Unlock()
+
+	sent := []switchCmd{}
+	setups := make(map[string]*sd.SwitchConfig)
+	for _, c := range resolved {
+		if !c.apply {
+			s.trackCommand(c.cmd, c.array, nil)
+			continue
+		}
+		switchMac, err := s.addPriorityCmd(setups, *c.array.Applied)
+		if err != nil {
+			rlog.Error(err.Error())
+			s.trackCommand(c.cmd, c.array, err)
+			continue
+		}
+		c.switchMac = switchMac
+		sent = append(sent, c)
+	}
+
+	errs := make(map[string]error)
+	for mac, switchSetup := range setups {
+		errs[mac] = s.sendSwitchCommand(*switchSetup)
+	}
+	for _, c := range sent {
+		s.trackCommand(c.cmd, c.array, errs[c.switchMac])
+	}
+}
+
+//relinquishPriority remove a level of a priority array and send the command of the next active level
+func (s *CoreService) relinquishPriority(point, level, source string) {
+	s.priorityMutex.Lock()
+	array, _ := database.GetPriorityArray(s.db, point)
+	if array == nil || !array.Relinquish(level, source) {
+		s.priorityMutex.Unlock()
+		return
+	}
+	rlog.Info("Priority " + level + " on " + point + " relinquished")
+	cmd := s.savePriority(*array)
+	s.priorityMutex.Unlock()
+	if cmd != nil {
+		s.applyPriority(*cmd)
+	}
+}
+
+//savePriority save a changed priority array and return its resulting command, nil when no level is left,
+//priorityMutex is held by the caller which sends the command once released
+func (s *CoreService) savePriority(array core.PriorityArray) *core.PriorityCommand {
+	array.Resolve()
+	if array.Applied == nil {
+		database.RemovePriorityArray(s.db, array.Point)
+		return nil
+	}
+	err := database.SavePriorityArray(s.db, array)
+	if err != nil {
+		rlog.Error("Cannot save " + array.Point + " priority array " + err.Error())
+	}
+	return array.Applied
+}
+
+//expirePriorities fall back to the next active level when a command expires
+func (s *CoreService) expirePriorities(now time.Time) {
+	cmds := []core.PriorityCommand{}
+	s.priorityMutex.Lock()
+	for _, array := range database.GetPriorityArrays(s.db) {
+		if !array.Expire(now) {
+			continue
+		}
+		rlog.Info("Priority command expired on " + array.Point)
+		cmd := s.savePriority(array)
+		if cmd != nil {
+			cmds = append(cmds, *cmd)
+		}
+	}
+	s.priorityMutex.Unlock()
+
+	for _, cmd := range cmds {
+		s.applyPriority(cmd)
+	}
+}
+
+//runPriorityExpiry release the expired commands every minute
+func (s *CoreService) runPriorityExpiry() {
+	ticker := time.NewTicker(PriorityExpiryPeriod)
+	for {
+		select {
+		case <-ticker.C:
+			s.expirePriorities(time.Now().UTC())
+		}
+	}
+}
+
+//relinquishAPIPriority remove a level of a priority array on API request
+func (s *CoreService) relinquishAPIPriority(val interface{}) {
+	release, _ := core.ToPriorityRelease(val)
+	if release == nil {
+		rlog.Error("Cannot parse cmd")
+		return
+	}
+	s.relinquishPriority(release.Point, release.Level, "")
+}
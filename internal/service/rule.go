@@ -0,0 +1,98 @@
+package service
+
+import (
+	"time"
+
+	sd "github.com/energieip/common-components-go/pkg/dswitch"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+//sendRuleActions send the rule commands at the rule priority
+func (s *CoreService) sendRuleActions(rule core.Rule, actions []core.RuleAction) {
+	for _, action := range actions {
+		s.sendPriorityCmd(action.Command(core.PriorityRule, "rule "+rule.Name))
+	}
+}
+
+//releaseRule send the release actions or give the control back to the lower priorities
+func (s *CoreService) releaseRule(rule core.Rule) {
+	if len(rule.ReleaseActions) > 0 {
+		s.sendRuleActions(rule, rule.ReleaseActions)
+		return
+	}
+	s.relinquishRule(rule)
+}
+
+//relinquishRule give the control of the rule points back to the lower priorities
+func (s *CoreService) relinquishRule(rule core.Rule) {
+	for _, point := range rule.Points() {
+		s.relinquishPriority(point, core.PriorityRule, "rule "+rule.Name)
+	}
+}
+
+//reloadRules read the rules again once one of them is saved or removed, the commands
+//of the removed, disabled or changed rules are relinquished
+func (s *CoreService) reloadRules() {
+	rules := database.GetRules(s.db)
+	enabled := make(map[string]string)
+	for _, rule := range rules {
+		if rule.Enabled {
+			dump, _ := rule.ToJSON()
+			enabled[rule.Name] = dump
+		}
+	}
+
+	released := []core.Rule{}
+	s.ruleMutex.Lock()
+	for _, rule := range s.rules {
+		dump, _ := rule.ToJSON()
+		if !rule.Enabled || enabled[rule.Name] == dump {
+			continue
+		}
+		released = append(released, rule)
+		delete(s.ruleStates, rule.Name)
+	}
+	s.rules = rules
+	s.ruleMutex.Unlock()
+
+	for _, rule := range released {
+		rlog.Info("Rule " + rule.Name + " removed, disabled or changed")
+		s.relinquishRule(rule)
+	}
+}
+
+//evaluateRules update the rule values with a switch dump and send the
+//actions of the rules switching on or off
+func (s *CoreService) evaluateRules(switchStatus sd.SwitchStatus) {
+	s.ruleMutex.Lock()
+	defer s.ruleMutex.Unlock()
+	s.ruleValues.AddDrivers(switchStatus.Sensors, switchStatus.Nanos, switchStatus.Hvacs, switchStatus.Wagos, switchStatus.Groups)
+	s.ruleValues.Add(core.RuleSourceSwitch, core.DeviceNames(switchStatus.Mac, switchStatus.Label), switchStatus)
+
+	now := time.Now().UTC()
+	states := make(map[string]*core.RuleState)
+	for _, rule := range s.rules {
+		if !rule.Enabled {
+			continue
+		}
+		state, ok := s.ruleStates[rule.Name]
+		if !ok {
+			state = &core.RuleState{}
+		}
+		states[rule.Name] = state
+		if !rule.Evaluate(s.ruleValues, state, now) {
+			continue
+		}
+		if state.Active {
+			rlog.Info("Rule " + rule.Name + " triggered")
+			go s.sendRuleActions(rule, rule.Actions)
+		} else {
+			rlog.Info("Rule " + rule.Name + " released")
+			go s.releaseRule(rule)
+		}
+	}
+	//the removed and disabled rules restart from the inactive state
+	s.ruleStates = states
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+const (
+	//MaxScheduleCatchUp oldest missed actions applied after a restart
+	MaxScheduleCatchUp = 7 * 24 * time.Hour
+)
+
+func (s *CoreService) getPlanner() core.Planner {
+	return core.Planner{
+		Calendars: database.GetCalendars(s.db),
+		Site:      s.site,
+	}
+}
+
+//sendScheduleCmd send a group command at the schedule priority
+func (s *CoreService) sendScheduleCmd(cmd dserver.GroupCmd) {
+	s.sendPriorityCmd(core.PriorityCommand{
+		Level:  core.PrioritySchedule,
+		Source: "schedule",
+		Group:  &cmd,
+	})
+}
+
+//applySchedules send the group commands scheduled after from and until to
+func (s *CoreService) applySchedules(from, to time.Time) {
+	planner := s.getPlanner()
+	for _, schedule := range core.ScheduledGroups(database.GetSchedules(s.db), planner.Calendars) {
+		for _, evt := range planner.Events(schedule, from, to) {
+			rlog.Info("Apply schedule " + evt.Action.Label + " on group " + strconv.Itoa(evt.Group))
+			s.sendScheduleCmd(evt.Action.GroupCmd(evt.Group))
+		}
+	}
+	status := core.SchedulerStatus{
+		LastRun: to.UTC().Format(time.RFC3339),
+	}
+	err := database.SaveSchedulerStatus(s.db, status)
+	if err != nil {
+		rlog.Error("Cannot save scheduler status " + err.Error())
+	}
+}
+
+//catchUpSchedules send for each group the resulting command of the actions missed while the service was stopped
+func (s *CoreService) catchUpSchedules(now time.Time) {
+	status, _ := database.GetSchedulerStatus(s.db)
+	if status == nil {
+		return
+	}
+	from, err := time.Parse(time.RFC3339, status.LastRun)
+	if err != nil {
+		return
+	}
+	if now.Sub(from) > MaxScheduleCatchUp {
+		from = now.Add(-MaxScheduleCatchUp)
+	}
+	planner := s.getPlanner()
+	for _, schedule := range core.ScheduledGroups(database.GetSchedules(s.db), planner.Calendars) {
+		events := planner.Events(schedule, from, now)
+		if len(events) == 0 {
+			continue
+		}
+		cmd := dserver.GroupCmd{
+			Group: schedule.Group,
+		}
+		for _, evt := range events {
+			evt.Action.Merge(&cmd)
+		}
+		rlog.Info("Catch up schedule of group " + strconv.Itoa(schedule.Group))
+		s.sendScheduleCmd(cmd)
+	}
+}
+
+//runSchedules apply the group schedules every minute
+func (s *CoreService) runSchedules() {
+	last := time.Now()
+	s.catchUpSchedules(last)
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+			now = time.Now()
+			s.applySchedules(last, now)
+			last = now
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"os"
+	"sync"
 	"time"
 
 	"github.com/energieip/common-components-go/pkg/dserver"
@@ -32,6 +33,12 @@ type CoreService struct {
 	authServer           network.AuthNetwork   //Authentication server
 	db                   database.Database
 	historyDb            history.HistoryDb
+	historyRecorder      *history.Recorder
+	historyCompactor     *history.Compactor
+	site                 core.SiteConfig
+	priority             core.PriorityConfig
+	mail                 core.MailConfig
+	priorityMutex        sync.Mutex
 	dataPath             string
 	mac                  string
 	ip                   string
@@ -40,10 +47,32 @@ type CoreService struct {
 	api                  *api.API
 	internalApi          *api.InternalAPI
 	bufConsumption       cmap.ConcurrentMap
+	bufGroupConso        map[int]*core.GroupConsumption //group -> power
+	bufZoneConso         map[int]*core.ZoneConsumption  //cluster -> power
+	clusterFrames        map[int][]string               //cluster -> frame labels, nil until loaded
+	consoMutex           sync.Mutex
+	ruleValues           core.RuleValues
+	ruleStates           map[string]*core.RuleState
+	rules                []core.Rule //reloaded when a rule is saved or removed
+	ruleMutex            sync.Mutex
+	alarms               map[string]core.Alarm //open alarms by key
+	alarmMutex           sync.Mutex
+	commandAcks          map[string]core.CommandAck //pending commands by point
+	commandMutex         sync.Mutex
+	offlineSwitchs       map[string]core.OfflineSwitch //offline switchs by mac
+	offlineMutex         sync.Mutex
+	eventsAlarmAPI       chan core.Alarm
+	webhookEvents        chan webhookEvent
+	webhookAlarms        chan webhookEvent //alarms and switchs connectivity, never dropped
+	webhooks             []core.Webhook
+	webhooksLoaded       time.Time
+	webhookMutex         sync.Mutex
 	eventsConsumptionAPI chan core.EventConsumption
 	uploadValue          string
 	timerDump            time.Duration
 	switchsSeen          cmap.ConcurrentMap
+	started              time.Time
+	loops                map[string]*core.LoopProbe //event loops lag
 }
 
 //Initialize service
@@ -53,16 +82,36 @@ func (s *CoreService) Initialize(confFile string) error {
 	s.events = make(chan string)
 	s.eventsAPI = make(chan map[string]interface{})
 	s.bufConsumption = cmap.New()
+	s.bufGroupConso = make(map[int]*core.GroupConsumption)
+	s.bufZoneConso = make(map[int]*core.ZoneConsumption)
 	s.switchsSeen = cmap.New()
+	s.ruleValues = make(core.RuleValues)
+	s.ruleStates = make(map[string]*core.RuleState)
 	s.eventsConsumptionAPI = make(chan core.EventConsumption)
+	s.eventsAlarmAPI = make(chan core.Alarm)
+	s.webhookEvents = make(chan webhookEvent, webhookQueueSize)
+	s.webhookAlarms = make(chan webhookEvent, webhookQueueSize)
 	s.uploadValue = "none"
+	s.started = time.Now()
+	s.loops = map[string]*core.LoopProbe{
+		loopMQTT: core.NewLoopProbe(),
+		loopAPI:  core.NewLoopProbe(),
+	}
 
 	conf, err := pkg.ReadServiceConfig(confFile)
 	if err != nil {
 		rlog.Error("Cannot parse configuration file " + err.Error())
 		return err
 	}
+	coreConf, err := core.ReadCoreConfig(confFile)
+	if err != nil {
+		rlog.Error("Cannot parse configuration file " + err.Error())
+		return err
+	}
 	s.dataPath = conf.DataPath
+	s.site = coreConf.Site
+	s.priority = coreConf.Priority
+	s.mail = coreConf.Mail
 	os.Setenv("RLOG_LOG_LEVEL", conf.LogLevel)
 	os.Setenv("RLOG_LOG_NOTIME", "yes")
 	rlog.UpdateEnv()
@@ -74,6 +123,13 @@ func (s *CoreService) Initialize(confFile string) error {
 		return err
 	}
 	s.db = *db
+	s.alarms = database.GetOpenAlarms(s.db)
+	s.offlineSwitchs = database.GetOfflineSwitchs(s.db)
+	s.rules = database.GetRules(s.db)
+	s.commandAcks = make(map[string]core.CommandAck)
+	for _, ack := range database.GetPendingCommandAcks(s.db) {
+		s.commandAcks[ack.Point] = ack
+	}
 
 	historydb, err := history.ConnectDatabase(conf.HistoryDB.ClientIP, conf.HistoryDB.ClientPort)
 	if err != nil {
@@ -81,6 +137,8 @@ func (s *CoreService) Initialize(confFile string) error {
 		return err
 	}
 	s.historyDb = *historydb
+	s.historyRecorder = history.NewRecorder(s.historyDb, history.DefaultSamplePeriod)
+	s.historyCompactor = history.NewCompactor(s.historyDb, coreConf.History)
 
 	serverNet, err := network.CreateServerNetwork()
 	if err != nil {
@@ -89,18 +147,25 @@ func (s *CoreService) Initialize(confFile string) error {
 	}
 	s.server = *serverNet
 
-	err = s.server.LocalConnection(*conf)
+	authNet, err := network.CreateAuthNetwork()
 	if err != nil {
-		rlog.Error("Cannot connect to drivers broker " + conf.NetworkBroker.IP + " error: " + err.Error())
+		rlog.Error("Cannot connect to broker " + conf.AuthBroker.IP + " error: " + err.Error())
 		return err
 	}
+	s.authServer = *authNet
 
-	authNet, err := network.CreateAuthNetwork()
+	//the API is started first to report the health while the brokers are not reachable
+	internal := api.InitInternalAPI(s.db, *conf)
+	s.internalApi = internal
+
+	web := api.InitAPI(s.db, s.historyDb, s.eventsAPI, s.eventsConsumptionAPI, s.eventsAlarmAPI, &s.uploadValue, *conf, *coreConf, s.health)
+	s.api = web
+
+	err = s.server.LocalConnection(*conf)
 	if err != nil {
-		rlog.Error("Cannot connect to broker " + conf.AuthBroker.IP + " error: " + err.Error())
+		rlog.Error("Cannot connect to drivers broker " + conf.NetworkBroker.IP + " error: " + err.Error())
 		return err
 	}
-	s.authServer = *authNet
 
 	err = s.authServer.LocalConnection(*conf)
 	if err != nil {
@@ -108,12 +173,6 @@ func (s *CoreService) Initialize(confFile string) error {
 		return err
 	}
 
-	internal := api.InitInternalAPI(s.db, *conf)
-	s.internalApi = internal
-
-	web := api.InitAPI(s.db, s.historyDb, s.eventsAPI, s.eventsConsumptionAPI, &s.uploadValue, *conf)
-	s.api = web
-
 	serv := dserver.ServerConfig{}
 	serv.Mac = s.mac
 	serv.IP = s.ip
@@ -131,11 +190,46 @@ func (s *CoreService) Stop() {
 	rlog.Info("Stopping ServerCore service")
 	s.server.Disconnect()
 	s.authServer.Disconnect()
+	s.historyRecorder.Flush()
 	s.db.Close()
 	s.historyDb.Close()
 	rlog.Info("ServerCore service stopped")
 }
 
+//updateConfig apply the items of a configuration batch one after the other,
+//the groups and switchs before the drivers
+func (s *CoreService) updateConfig(val interface{}) {
+	cfg, _ := core.ToConf(val)
+	if cfg == nil {
+		rlog.Error("Cannot parse ")
+		return
+	}
+	for _, gr := range cfg.Groups {
+		s.updateGroupCfg(gr)
+	}
+	for _, sw := range cfg.Switchs {
+		s.updateSwitchCfg(sw)
+	}
+	for _, led := range cfg.Leds {
+		s.updateLedCfg(led)
+	}
+	for _, sensor := range cfg.Sensors {
+		s.updateSensorCfg(sensor)
+	}
+	for _, blind := range cfg.Blinds {
+		s.updateBlindCfg(blind)
+	}
+	for _, hvac := range cfg.Hvacs {
+		s.updateHvacCfg(hvac)
+	}
+	for _, wago := range cfg.Wagos {
+		s.updateWagoCfg(wago)
+	}
+	for _, nano := range cfg.Nanosenses {
+		s.updateNanoCfg(nano)
+	}
+}
+
 func (s *CoreService) readAPIEvents() {
 	for {
 		select {
@@ -169,8 +263,16 @@ func (s *CoreService) readAPIEvents() {
 					go s.updateGroupCfg(event)
 				case "switch":
 					go s.updateSwitchCfg(event)
+				case "config":
+					go s.updateConfig(event)
 				case "groupCmd":
 					go s.sendGroupCmd(event)
+				case "priorityCmd":
+					go s.sendPriorityCmd(event)
+				case "bulkCmd":
+					go s.sendBulkCmd(event)
+				case "relinquishCmd":
+					go s.relinquishAPIPriority(event)
 				case "ledCmd":
 					go s.sendLedCmd(event)
 				case "blindCmd":
@@ -183,6 +285,10 @@ func (s *CoreService) readAPIEvents() {
 					go s.installDriver(event)
 				case "map":
 					go s.updateMapInfo(event)
+				case "webhookTest":
+					go s.testWebhook(event)
+				case "rules":
+					go s.reloadRules()
 				}
 			}
 			apiEvents = nil
@@ -217,14 +323,8 @@ func (s *CoreService) readAPIEvents() {
 					go s.updateGroupCfg(event)
 				case "switch":
 					go s.updateSwitchCfg(event)
-				case "groupCmd":
-					go s.sendGroupCmd(event)
-				case "ledCmd":
-					go s.sendLedCmd(event)
-				case "blindCmd":
-					go s.sendBlindCmd(event)
-				case "hvacCmd":
-					go s.sendHvacCmd(event)
+				case "priorityCmd":
+					go s.sendPriorityCmd(event)
 				case "replaceDriver":
 					go s.replaceDriver(event)
 				case "installDriver":
@@ -233,6 +333,7 @@ func (s *CoreService) readAPIEvents() {
 					go s.updateMapInfo(event)
 				}
 			}
+		case <-s.loops[loopAPI].Probe:
 		}
 	}
 }
@@ -246,6 +347,7 @@ func (s *CoreService) manageMQTTEvent(eventType string, event sd.SwitchStatus) {
 		case network.EventDump:
 			s.sendSwitchUpdateConfig(event)
 			s.registerSwitchStatus(event)
+			s.evaluateRules(event)
 		}
 	}
 }
@@ -265,28 +367,32 @@ func (s *CoreService) manageAuthMQTTDumpEvent(users map[string]duser.UserAccess)
 
 func (s *CoreService) cleanupOldStatus() {
 	timeNow := time.Now().UTC()
-	toRemove := make(map[string]bool)
 	switchs := database.GetSwitchsDump(s.db)
 	for _, driver := range switchs {
+		if s.isSwitchOffline(driver.Mac) {
+			continue
+		}
 		sw, _ := sd.ToSwitch(driver)
 		val, ok := s.switchsSeen.Get(sw.Mac)
-		if ok && val != nil {
-			maxDuration := time.Duration(5*sw.DumpFrequency) * time.Millisecond
-			if timeNow.Sub(val.(time.Time)) > maxDuration {
-				rlog.Info("Switch " + sw.Mac + " timeout")
-				s.switchsSeen.Remove(sw.Mac)
-				toRemove[sw.Mac] = true
-			}
-		} else {
-			toRemove[sw.Mac] = true
+		if !ok || val == nil {
+			//no dump received since the service started
+			s.switchsSeen.Set(sw.Mac, timeNow)
+			continue
+		}
+		lastSeen := val.(time.Time)
+		maxDuration := time.Duration(5*sw.DumpFrequency) * time.Millisecond
+		if timeNow.Sub(lastSeen) > maxDuration {
+			rlog.Info("Switch " + sw.Mac + " timeout")
+			s.switchsSeen.Remove(sw.Mac)
+			s.raiseAlarm(core.Alarm{
+				Type:     core.AlarmSwitchTimeout,
+				Severity: core.AlarmSeverityCritical,
+				Mac:      sw.Mac,
+				Label:    labelValue(driver.Label),
+				Message:  "No dump since " + lastSeen.Format(time.RFC3339),
+			})
+			s.setSwitchOffline(*sw, lastSeen)
 		}
-	}
-	for mac := range toRemove {
-		database.RemoveSwitchStatus(s.db, mac)
-		database.RemoveSwitchLedStatus(s.db, mac)
-		database.RemoveSwitchBlindStatus(s.db, mac)
-		database.RemoveSwitchSensorStatus(s.db, mac)
-		database.RemoveSwitchHvacStatus(s.db, mac)
 	}
 }
 
@@ -305,8 +411,19 @@ func (s *CoreService) cronCleanup() {
 //Run service mainloop
 func (s *CoreService) Run() error {
 	go s.cronCleanup()
+	go s.historyRecorder.Run()
+	go s.historyCompactor.Run()
+	go s.runSchedules()
+	go s.runSunTracking()
+	go s.runPriorityExpiry()
+	go s.runCommandAcks()
+	go s.runWebhooks()
+	go s.runMailSummary()
 	go s.pushConsumptionEvent()
 	go s.readAPIEvents()
+	for _, probe := range s.loops {
+		go probe.Run(core.LoopProbePeriod)
+	}
 	for {
 		select {
 		case serverEvents := <-s.server.Events:
@@ -319,6 +436,7 @@ func (s *CoreService) Run() error {
 			}
 		case authEvents := <-s.authServer.EventDump:
 			go s.manageAuthMQTTDumpEvent(authEvents)
+		case <-s.loops[loopMQTT].Probe:
 		}
 	}
 }
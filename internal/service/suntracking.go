@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/energieip/common-components-go/pkg/dserver"
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+const (
+	//SunTrackingPeriod delay between two blinds positions updates
+	SunTrackingPeriod = 5 * time.Minute
+)
+
+//trackSun send the blinds setpoints of the sun tracking groups when they change,
+//sent is updated with the last setpoints per group
+func (s *CoreService) trackSun(now time.Time, sent map[int]dserver.GroupCmd) {
+	sunAzimuth, sunElevation := s.site.SunPosition(now)
+	for _, facade := range database.GetGroupFacades(s.db) {
+		if !facade.SunTracking || facade.Azimuth == nil {
+			continue
+		}
+		status := facade.GetSunTracking(sunAzimuth, sunElevation)
+		if sunElevation <= 0 {
+			//the night positions are left to the schedules
+			delete(sent, status.Group)
+			continue
+		}
+		cmd := dserver.GroupCmd{
+			Group:          status.Group,
+			SetpointBlinds: &status.SetpointBlinds,
+			SetpointSlats:  &status.SetpointSlats,
+		}
+		last, ok := sent[status.Group]
+		if ok && *last.SetpointBlinds == *cmd.SetpointBlinds && *last.SetpointSlats == *cmd.SetpointSlats {
+			continue
+		}
+		rlog.Info("Sun tracking of group " + strconv.Itoa(status.Group) + ": blinds " + strconv.Itoa(status.SetpointBlinds) +
+			" slats " + strconv.Itoa(status.SetpointSlats))
+		s.sendPriorityCmd(core.PriorityCommand{
+			Level:  core.PrioritySchedule,
+			Source: "suntracking",
+			Group:  &cmd,
+		})
+		sent[status.Group] = cmd
+	}
+}
+
+//runSunTracking update the blinds of the sun tracking groups
+func (s *CoreService) runSunTracking() {
+	if !s.site.IsLocated() {
+		rlog.Warn("Site latitude and longitude are not configured, sun tracking disabled")
+		return
+	}
+	sent := make(map[int]dserver.GroupCmd)
+	ticker := time.NewTicker(SunTrackingPeriod)
+	for {
+		select {
+		case <-ticker.C:
+			s.trackSun(time.Now(), sent)
+		}
+	}
+}
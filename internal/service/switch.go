@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"strings"
 	"time"
 
@@ -21,6 +22,39 @@ import (
 	"github.com/romana/rlog"
 )
 
+//switchCommand return the settings message of a switch gathering driver commands, created on first use
+func (s *CoreService) switchCommand(setups map[string]*sd.SwitchConfig, mac string) (*sd.SwitchConfig, error) {
+	switchSetup, ok := setups[mac]
+	if ok {
+		return switchSetup, nil
+	}
+	sw, _ := database.GetSwitchConfig(s.db, mac)
+	if sw == nil {
+		return nil, errors.New("Cannot find switch " + mac)
+	}
+	ip := "0"
+	if sw.IP != nil {
+		ip = *sw.IP
+	}
+	dumpFreq := 1000
+	if sw.DumpFrequency != nil {
+		dumpFreq = *sw.DumpFrequency
+	}
+	switchSetup = &sd.SwitchConfig{}
+	switchSetup.Mac = mac
+	switchSetup.IP = ip
+	switchSetup.DumpFrequency = dumpFreq
+	setups[mac] = switchSetup
+	return switchSetup, nil
+}
+
+//sendSwitchCommand publish the driver commands of a switch settings message
+func (s *CoreService) sendSwitchCommand(switchSetup sd.SwitchConfig) error {
+	url := "/write/switch/" + switchSetup.Mac + "/update/settings"
+	dump, _ := switchSetup.ToJSON()
+	return s.server.SendCommand(url, dump)
+}
+
 func (s *CoreService) updateSwitchCfg(config interface{}) {
 	cfg, _ := dserver.ToSwitchConfig(config)
 	if cfg == nil || cfg.Mac == nil {
@@ -124,9 +158,13 @@ func (s *CoreService) updateSwitchLabelCfg(config interface{}) {
 
 func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	s.switchsSeen.Set(switchStatus.Mac, time.Now().UTC())
+	s.clearAlarm(core.AlarmSwitchTimeout, switchStatus.Mac)
+	s.acknowledgeCommands(switchStatus)
 	oldLeds := database.GetLedSwitchStatus(s.db, switchStatus.Mac)
 	for mac, led := range switchStatus.Leds {
 		database.SaveLedStatus(s.db, led)
+		s.clearAlarm(core.AlarmDriverLost, led.Mac)
+		s.checkDriverError(led.Mac, led.Label, led.Group, led.Error)
 		var oldCfg *dled.LedSetup
 		byLabel := false
 		if led.Label != nil {
@@ -174,12 +212,15 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, led := range oldLeds {
 		database.RemoveLedStatus(s.db, led.Mac)
+		s.raiseDriverLost(led.Mac, led.Label, led.Group, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, LedElt, led)
 	}
 
 	oldSensors := database.GetSensorSwitchStatus(s.db, switchStatus.Mac)
 	for mac, sensor := range switchStatus.Sensors {
 		database.SaveSensorStatus(s.db, sensor)
+		s.clearAlarm(core.AlarmDriverLost, sensor.Mac)
+		s.checkDriverError(sensor.Mac, sensor.Label, sensor.Group, sensor.Error)
 		var oldCfg *ds.SensorSetup
 		byLabel := false
 		if sensor.Label != nil {
@@ -227,12 +268,15 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, sensor := range oldSensors {
 		database.RemoveSensorStatus(s.db, sensor.Mac)
+		s.raiseDriverLost(sensor.Mac, sensor.Label, sensor.Group, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, SensorElt, sensor)
 	}
 
 	oldBlinds := database.GetBlindSwitchStatus(s.db, switchStatus.Mac)
 	for mac, blind := range switchStatus.Blinds {
 		database.SaveBlindStatus(s.db, blind)
+		s.clearAlarm(core.AlarmDriverLost, blind.Mac)
+		s.checkDriverError(blind.Mac, blind.Label, blind.Group, blind.Error)
 		var oldCfg *dblind.BlindSetup
 		byLabel := false
 		if blind.Label != nil {
@@ -280,12 +324,15 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, blind := range oldBlinds {
 		database.RemoveBlindStatus(s.db, blind.Mac)
+		s.raiseDriverLost(blind.Mac, blind.Label, blind.Group, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, BlindElt, blind)
 	}
 
 	oldNanos := database.GetNanoSwitchStatus(s.db, switchStatus.Cluster)
 	for _, driver := range switchStatus.Nanos {
 		database.SaveNanoStatus(s.db, driver)
+		s.clearAlarm(core.AlarmDriverLost, driver.Mac)
+		s.checkDriverError(driver.Mac, &driver.Label, driver.Group, driver.Error)
 		var oldCfg *dnanosense.NanosenseSetup
 		if driver.Label != "" {
 			oldCfg, _ = database.GetNanoLabelConfig(s.db, driver.Label)
@@ -321,12 +368,15 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, driver := range oldNanos {
 		database.RemoveNanoStatus(s.db, driver.Label)
+		s.raiseDriverLost(driver.Mac, &driver.Label, driver.Group, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, NanoElt, driver)
 	}
 
 	oldHvacs := database.GetHvacSwitchStatus(s.db, switchStatus.Mac)
 	for mac, hvac := range switchStatus.Hvacs {
 		database.SaveHvacStatus(s.db, hvac)
+		s.clearAlarm(core.AlarmDriverLost, hvac.Mac)
+		s.checkHvacError(hvac)
 		var oldCfg *dhvac.HvacSetup
 		byLabel := false
 		if hvac.Label != nil {
@@ -374,12 +424,15 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, hvac := range oldHvacs {
 		database.RemoveHvacStatus(s.db, hvac.Mac)
+		s.raiseDriverLost(hvac.Mac, hvac.Label, hvac.Group, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, HvacElt, hvac)
 	}
 
 	oldWagos := database.GetWagoClusterStatus(s.db, switchStatus.Cluster)
 	for mac, wago := range switchStatus.Wagos {
 		database.SaveWagoStatus(s.db, wago)
+		s.clearAlarm(core.AlarmDriverLost, wago.Mac)
+		s.checkDriverError(wago.Mac, wago.Label, 0, wago.Error)
 		_, ok := oldWagos[mac]
 		if ok {
 			delete(oldWagos, mac)
@@ -387,6 +440,7 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 	}
 	for _, wago := range oldWagos {
 		database.RemoveWagoStatus(s.db, wago.Mac)
+		s.raiseDriverLost(wago.Mac, wago.Label, 0, switchStatus.Mac)
 		s.prepareAPIEvent(EventRemove, WagoElt, wago)
 	}
 
@@ -413,6 +467,69 @@ func (s *CoreService) registerSwitchStatus(switchStatus sd.SwitchStatus) {
 		}
 	}
 	database.SaveSwitchStatus(s.db, switchStatus)
+	s.setSwitchOnline(switchStatus)
+}
+
+//recordConnectivity store a switch offline or online event in history
+func (s *CoreService) recordConnectivity(evtType string, sw core.OfflineSwitch, date time.Time) {
+	evt := history.ConnectivityHistory{
+		Mac:      sw.Mac,
+		Label:    sw.Label,
+		Event:    evtType,
+		Date:     date.Format(time.RFC3339),
+		LastSeen: sw.LastSeen,
+	}
+	err := history.SaveHistory(s.historyDb, history.HistoryDB, history.ConnectivityTable, evt)
+	if err != nil {
+		rlog.Error("Cannot save " + evtType + " history of " + sw.Mac + " " + err.Error())
+	}
+}
+
+//setSwitchOffline keep the last status of a switch without dump, its drivers are stale
+func (s *CoreService) setSwitchOffline(switchStatus sd.SwitchStatus, lastSeen time.Time) {
+	now := time.Now().UTC()
+	sw := core.OfflineSwitch{
+		Mac:      switchStatus.Mac,
+		Label:    labelValue(switchStatus.Label),
+		LastSeen: lastSeen.Format(time.RFC3339),
+		Since:    now.Format(time.RFC3339),
+	}
+	s.offlineMutex.Lock()
+	s.offlineSwitchs[sw.Mac] = sw
+	s.offlineMutex.Unlock()
+	err := database.SaveOfflineSwitch(s.db, sw)
+	if err != nil {
+		rlog.Error("Cannot mark switch " + sw.Mac + " offline " + err.Error())
+	}
+	rlog.Warn("Switch " + sw.Mac + " offline, last dump " + sw.LastSeen)
+	s.recordConnectivity(EventSwitchOffline, sw, now)
+	s.prepareSwitchEvent(EventSwitchOffline, switchStatus)
+}
+
+//isSwitchOffline check if a switch is marked offline
+func (s *CoreService) isSwitchOffline(mac string) bool {
+	s.offlineMutex.Lock()
+	defer s.offlineMutex.Unlock()
+	_, ok := s.offlineSwitchs[mac]
+	return ok
+}
+
+//setSwitchOnline remove the offline mark of a switch dumping again
+func (s *CoreService) setSwitchOnline(switchStatus sd.SwitchStatus) {
+	s.offlineMutex.Lock()
+	sw, ok := s.offlineSwitchs[switchStatus.Mac]
+	delete(s.offlineSwitchs, switchStatus.Mac)
+	s.offlineMutex.Unlock()
+	if !ok {
+		return
+	}
+	err := database.RemoveOfflineSwitch(s.db, sw.Mac)
+	if err != nil {
+		rlog.Error("Cannot mark switch " + sw.Mac + " online " + err.Error())
+	}
+	rlog.Info("Switch " + sw.Mac + " back online, offline since " + sw.LastSeen)
+	s.recordConnectivity(EventSwitchOnline, sw, time.Now().UTC())
+	s.prepareSwitchEvent(EventSwitchOnline, switchStatus)
 }
 
 func (s *CoreService) sendSwitchSetup(sw sd.SwitchStatus) {
@@ -548,6 +665,11 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 		config.IP = &switchStatus.IP
 		database.SaveSwitchConfig(s.db, *config)
 	}
+	s.historyRecorder.AddSwitch(switchStatus)
+	cluster := 0
+	if config.Cluster != nil {
+		cluster = *config.Cluster
+	}
 
 	isConfigured := true
 	setup := sd.SwitchConfig{}
@@ -616,8 +738,8 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 			s.prepareAPIEvent(EventAdd, LedElt, led)
 		} else {
 			s.prepareAPIEvent(EventUpdate, LedElt, led)
-			history.SaveLedHistory(s.historyDb, led)
-			s.prepareAPIConsumption(LedElt, led.LinePower)
+			s.historyRecorder.AddLed(led)
+			s.prepareAPIConsumption(LedElt, led.Group, cluster, led.LinePower)
 		}
 	}
 
@@ -630,8 +752,8 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 			s.prepareAPIEvent(EventAdd, BlindElt, blind)
 		} else {
 			s.prepareAPIEvent(EventUpdate, BlindElt, blind)
-			history.SaveBlindHistory(s.historyDb, blind)
-			s.prepareAPIConsumption(BlindElt, blind.LinePower)
+			s.historyRecorder.AddBlind(blind)
+			s.prepareAPIConsumption(BlindElt, blind.Group, cluster, blind.LinePower)
 		}
 	}
 
@@ -644,8 +766,8 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 			s.prepareAPIEvent(EventAdd, HvacElt, hvac)
 		} else {
 			s.prepareAPIEvent(EventUpdate, HvacElt, hvac)
-			history.SaveHvacHistory(s.historyDb, hvac)
-			s.prepareAPIConsumption(HvacElt, hvac.LinePower)
+			s.historyRecorder.AddHvac(hvac)
+			s.prepareAPIConsumption(HvacElt, hvac.Group, cluster, hvac.LinePower)
 		}
 	}
 
@@ -658,6 +780,7 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 			s.prepareAPIEvent(EventAdd, SensorElt, sensor)
 		} else {
 			s.prepareAPIEvent(EventUpdate, SensorElt, sensor)
+			s.historyRecorder.AddSensor(sensor)
 		}
 	}
 
@@ -686,6 +809,7 @@ func (s *CoreService) prepareSwitchConfig(switchStatus sd.SwitchStatus) *sd.Swit
 			s.prepareAPIEvent(EventAdd, NanoElt, driver)
 		} else {
 			s.prepareAPIEvent(EventUpdate, NanoElt, driver)
+			s.historyRecorder.AddNanosense(driver)
 		}
 		nanoSeen[driver.Label] = true
 	}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/energieip/srv200-coreservice-go/internal/core"
+	"github.com/energieip/srv200-coreservice-go/internal/database"
+	"github.com/romana/rlog"
+)
+
+const (
+	webhookQueueSize    = 100
+	webhookRefresh      = 10 * time.Second //webhooks configuration reload period
+	webhookTimeout      = 10 * time.Second
+	webhookMaxAttempts  = 5
+	webhookRetryDelay   = 5 * time.Second //doubled after each failed attempt
+	webhookLogRetention = 7 * 24 * time.Hour
+)
+
+//webhookEvent event waiting to be sent to the subscribed webhooks
+type webhookEvent struct {
+	evtType string
+	date    time.Time
+	data    interface{}
+}
+
+//getWebhooks return the webhooks configuration, reloaded every webhookRefresh
+func (s *CoreService) getWebhooks() []core.Webhook {
+	s.webhookMutex.Lock()
+	defer s.webhookMutex.Unlock()
+	if time.Since(s.webhooksLoaded) > webhookRefresh {
+		s.webhooks = database.GetWebhooks(s.db)
+		s.webhooksLoaded = time.Now()
+	}
+	return s.webhooks
+}
+
+//notifyWebhooks queue an event when a webhook subscribes to it. The critical events have
+//their own queue and wait for a free slot, the others are dropped when the queue is full
+func (s *CoreService) notifyWebhooks(evtType string, data interface{}) {
+	subscribed := false
+	for _, webhook := range s.getWebhooks() {
+		if webhook.Subscribed(evtType) {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		return
+	}
+	evt := webhookEvent{evtType: evtType, date: time.Now().UTC(), data: data}
+	if core.IsCriticalWebhookEvent(evtType) {
+		s.webhookAlarms <- evt
+		return
+	}
+	select {
+	case s.webhookEvents <- evt:
+	default:
+		rlog.Warn("Webhook event " + evtType + " dropped")
+	}
+}
+
+//dispatchWebhook send an event to each subscribed webhook
+func (s *CoreService) dispatchWebhook(evt webhookEvent) {
+	for _, webhook := range s.getWebhooks() {
+		if webhook.Subscribed(evt.evtType) {
+			go s.deliverWebhook(webhook, evt)
+		}
+	}
+}
+
+//runWebhooks send the queued events to the subscribed webhooks and purge the delivery log
+func (s *CoreService) runWebhooks() {
+	purge := time.NewTicker(time.Hour)
+	for {
+		select {
+		case evt := <-s.webhookAlarms:
+			s.dispatchWebhook(evt)
+		case evt := <-s.webhookEvents:
+			s.dispatchWebhook(evt)
+		case <-purge.C:
+			before := time.Now().UTC().Add(-webhookLogRetention)
+			database.RemoveWebhookDeliveries(s.db, before.Format(time.RFC3339))
+		}
+	}
+}
+
+//testWebhook send a test event to a webhook, even when it is disabled
+func (s *CoreService) testWebhook(event interface{}) {
+	name, ok := event.(string)
+	if !ok {
+		return
+	}
+	webhook, _ := database.GetWebhook(s.db, name)
+	if webhook == nil {
+		rlog.Error("Unknown webhook " + name)
+		return
+	}
+	s.deliverWebhook(*webhook, webhookEvent{
+		evtType: core.WebhookEventTest,
+		date:    time.Now().UTC(),
+		data:    map[string]interface{}{},
+	})
+}
+
+//postWebhook send the payload once, return the HTTP status and an error when it is not accepted
+func postWebhook(client *http.Client, webhook core.Webhook, delivery core.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(core.WebhookEventHeader, delivery.Event)
+	req.Header.Set(core.WebhookDeliveryHeader, delivery.ID)
+	if webhook.Secret != "" {
+		timestamp := time.Now().UTC().Format(time.RFC3339)
+		req.Header.Set(core.WebhookTimestampHeader, timestamp)
+		req.Header.Set(core.WebhookSignatureHeader, webhook.Sign(timestamp, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.New("HTTP status " + strconv.Itoa(resp.StatusCode))
+	}
+	return resp.StatusCode, nil
+}
+
+//retryWebhook check if a failed attempt is worth retrying, client errors are final
+func retryWebhook(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode < 400 || statusCode >= 500
+}
+
+//deliverWebhook post an event to a webhook, retry with backoff and log the attempts
+func (s *CoreService) deliverWebhook(webhook core.Webhook, evt webhookEvent) {
+	delivery := core.WebhookDelivery{
+		Webhook: webhook.Name,
+		Event:   evt.evtType,
+		Date:    evt.date.Format(time.RFC3339),
+		Status:  core.WebhookPending,
+	}
+	id, err := database.SaveWebhookDelivery(s.db, delivery)
+	if err != nil {
+		rlog.Error("Cannot save webhook " + webhook.Name + " delivery " + err.Error())
+	}
+	delivery.ID = id
+
+	body, err := json.Marshal(core.WebhookPayload{
+		Delivery: delivery.ID,
+		Event:    evt.evtType,
+		Date:     delivery.Date,
+		Data:     evt.data,
+	})
+	if err != nil {
+		delivery.Status = core.WebhookFailed
+		delivery.Error = err.Error()
+		database.SaveWebhookDelivery(s.db, delivery)
+		return
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := webhookRetryDelay
+	for delivery.Status == core.WebhookPending {
+		if delivery.Attempts > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		delivery.Attempts++
+		delivery.LastAttempt = time.Now().UTC().Format(time.RFC3339)
+		delivery.StatusCode, err = postWebhook(client, webhook, delivery, body)
+		if err == nil {
+			delivery.Status = core.WebhookDelivered
+			delivery.Error = ""
+		} else {
+			delivery.Error = err.Error()
+			rlog.Warn("Webhook " + webhook.Name + " " + evt.evtType + " attempt " + strconv.Itoa(delivery.Attempts) + " failed: " + delivery.Error)
+			if delivery.Attempts >= webhookMaxAttempts || !retryWebhook(delivery.StatusCode) {
+				delivery.Status = core.WebhookFailed
+			}
+		}
+		database.SaveWebhookDelivery(s.db, delivery)
+	}
+}